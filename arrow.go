@@ -0,0 +1,312 @@
+package sudoku
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/eliben/go-sudoku/svg"
+)
+
+// Arrow is an "arrow Sudoku" arrow: the digits along its Path add up to the
+// digit in its Circle. Unlike killer cages, digits may repeat along the path
+// (unless the squares are peers for another reason).
+type Arrow struct {
+	Circle Index
+	Path   []Index
+}
+
+// WithArrows returns a new grid that's a copy of g with the given arrows
+// added. g itself is not modified. The first square of an arrow's path has to
+// be a neighbor (sharing an edge or a corner) of its circle, and so does each
+// square of the path with the one before it. An error is returned if an arrow
+// is invalid.
+func (g *Grid) WithArrows(arrows []Arrow) (*Grid, error) {
+	for _, arrow := range arrows {
+		// Every digit on the path is at least 1, so the path can't be longer
+		// than the largest digit.
+		if len(arrow.Path) == 0 || len(arrow.Path) > g.size {
+			return nil, fmt.Errorf("arrow with %v squares in path, want 1-%v", len(arrow.Path), g.size)
+		}
+		if arrow.Circle < 0 || arrow.Circle >= g.NumSquares() {
+			return nil, fmt.Errorf("arrow square %v out of range", arrow.Circle)
+		}
+		prev := arrow.Circle
+		for i, sq := range arrow.Path {
+			if sq < 0 || sq >= g.NumSquares() {
+				return nil, fmt.Errorf("arrow square %v out of range", sq)
+			}
+			if sq == arrow.Circle || slices.Index(arrow.Path, sq) < i {
+				return nil, fmt.Errorf("square %v appears more than once in arrow", sq)
+			}
+			if !g.isNeighbor(prev, sq) {
+				return nil, fmt.Errorf("arrow squares %v and %v are not neighbors", prev, sq)
+			}
+			prev = sq
+		}
+	}
+
+	ng := *g
+	for _, arrow := range arrows {
+		ng.addConstraint(&arrowConstraint{
+			arrow: arrow,
+			sqs:   append([]Index{arrow.Circle}, arrow.Path...),
+		})
+	}
+	ng.arrows = append(ng.arrows[:len(ng.arrows):len(ng.arrows)], arrows...)
+	ng.build()
+	return &ng, nil
+}
+
+// Arrows returns the arrows of g.
+func (g *Grid) Arrows() []Arrow {
+	return g.arrows
+}
+
+// ParseArrows parses a textual list of arrows for grid g. Each arrow is on a
+// separate line, with the index of its circle followed by a colon and then
+// the indices of the squares along its path, starting next to the circle and
+// separated by spaces or commas. Empty lines and lines starting with '#' are
+// ignored. For example:
+//
+//	# An arrow from the top-left corner going right, and one going down
+//	0: 1 2
+//	9: 18 27
+func (g *Grid) ParseArrows(str string) ([]Arrow, error) {
+	var arrows []Arrow
+
+	scanner := bufio.NewScanner(strings.NewReader(str))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		circleStr, pathStr, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("arrow %q: missing ':'", line)
+		}
+		circle, err := g.parseSquareList(circleStr)
+		if err != nil {
+			return nil, fmt.Errorf("arrow %q: %w", line, err)
+		}
+		if len(circle) != 1 {
+			return nil, fmt.Errorf("arrow %q: got %v squares in circle, want 1", line, len(circle))
+		}
+		path, err := g.parseSquareList(pathStr)
+		if err != nil {
+			return nil, fmt.Errorf("arrow %q: %w", line, err)
+		}
+		arrows = append(arrows, Arrow{Circle: circle[0], Path: path})
+	}
+	return arrows, scanner.Err()
+}
+
+// arrowConstraint is the constraint for a single arrow.
+type arrowConstraint struct {
+	arrow Arrow
+
+	// sqs holds the circle followed by the path.
+	sqs []Index
+}
+
+func (ac *arrowConstraint) squares() []Index {
+	return ac.sqs
+}
+
+// propagate enforces bounds on the arrow's sum: the circle's digit is between
+// the sums of the smallest and the largest candidates on the path, and each
+// square on the path leaves room for the smallest and largest candidates of
+// the other squares.
+func (ac *arrowConstraint) propagate(g *Grid, values Values) bool {
+	low, high := 0, 0
+	for _, sq := range ac.arrow.Path {
+		low += minDigit(values[sq])
+		high += maxDigit(values[sq])
+	}
+
+	circle := ac.arrow.Circle
+	if !g.eliminateDigits(values, circle, digitsUpTo(low-1)|g.full&^digitsUpTo(high)) {
+		return false
+	}
+
+	// The bounds computed before eliminating may be stale, but since values only
+	// lose candidates, they're still valid (if not as tight as possible).
+	circleLow, circleHigh := minDigit(values[circle]), maxDigit(values[circle])
+	for _, sq := range ac.arrow.Path {
+		othersLow := low - minDigit(values[sq])
+		othersHigh := high - maxDigit(values[sq])
+		if !g.eliminateDigits(values, sq, digitsUpTo(circleLow-othersHigh-1)|g.full&^digitsUpTo(circleHigh-othersLow)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ac *arrowConstraint) satisfied(values Values) bool {
+	sum := 0
+	for _, sq := range ac.arrow.Path {
+		sum += minDigit(values[sq])
+	}
+	return sum == minDigit(values[ac.arrow.Circle])
+}
+
+// encode goes over the digits the path can have, square by square: each
+// assignment of the whole path implies the circle's digit, and each partial
+// assignment whose sum is already too large is forbidden. The digits on the
+// path may repeat, so unlike a cage's, its combinations of digits aren't
+// enough.
+func (ac *arrowConstraint) encode(enc *cnfEncoder) {
+	path := ac.arrow.Path
+	var clause []int
+	var encodeFrom func(i, sum int)
+	encodeFrom = func(i, sum int) {
+		// Each of the remaining squares adds at least 1 to the sum.
+		if sum+len(path)-i > enc.g.size {
+			enc.add(slices.Clone(clause)...)
+			return
+		}
+		if i == len(path) {
+			enc.add(append(slices.Clone(clause), enc.variable(ac.arrow.Circle, uint16(sum)))...)
+			return
+		}
+		for d := 1; d <= enc.g.size; d++ {
+			clause = append(clause, -enc.variable(path[i], uint16(d)))
+			encodeFrom(i+1, sum+d)
+			clause = clause[:len(clause)-1]
+		}
+	}
+	encodeFrom(0, 0)
+}
+
+// GenerateArrows generates a random arrow Sudoku puzzle with up to numArrows
+// arrows and a single solution on grid g. It returns a new grid with the
+// puzzle's arrows (as created by WithArrows), and the board with the puzzle's
+// hints. Hints are removed from the board like in Generate, until there are
+// at most hintCount left or no more can be removed. Only the Rand field of
+// options is used.
+func (g *Grid) GenerateArrows(numArrows int, hintCount int, options ...GenerateOptions) (*Grid, Values) {
+	rng := randOrGlobal(generateOptions("GenerateArrows", options).Rand)
+	solution, solved := g.Solve(g.EmptyBoard(), SolveOptions{Randomize: true, Rand: rng})
+	if !solved || !g.IsSolved(solution) {
+		log.Fatal("unable to generate solved board from empty")
+	}
+
+	ag, err := g.WithArrows(g.randomArrows(solution, numArrows, rng))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ag, ag.removeHints(ag.engine(BackendPropagation), unboundedSearch(), rng, solution, hintCount)
+}
+
+// randomArrows creates up to numArrows random arrows that don't overlap, each
+// satisfied by solution. The arrows are drawn from rng.
+func (g *Grid) randomArrows(solution Values, numArrows int, rng *rand.Rand) []Arrow {
+	used := make([]bool, g.NumSquares())
+	var arrows []Arrow
+
+	// Try circles in random order; from each, grow a random path while the sum
+	// of its digits is lower than the circle's digit.
+	for _, circle := range rng.Perm(g.NumSquares()) {
+		if len(arrows) >= numArrows {
+			break
+		}
+		if used[circle] {
+			continue
+		}
+
+		target := minDigit(solution[circle])
+		sum := 0
+		var path []Index
+		last := circle
+		for sum < target {
+			var next []Index
+			for _, nb := range g.neighbors(last) {
+				if !used[nb] && nb != circle && !slices.Contains(path, nb) && sum+minDigit(solution[nb]) <= target {
+					next = append(next, nb)
+				}
+			}
+			if len(next) == 0 {
+				break
+			}
+			last = next[rng.Intn(len(next))]
+			path = append(path, last)
+			sum += minDigit(solution[last])
+		}
+
+		if sum == target {
+			used[circle] = true
+			for _, sq := range path {
+				used[sq] = true
+			}
+			arrows = append(arrows, Arrow{Circle: circle, Path: path})
+		}
+	}
+	return arrows
+}
+
+// neighbors returns the squares that share an edge or a corner with sq.
+func (g *Grid) neighbors(sq Index) []Index {
+	var nbs []Index
+	row, col := g.row(sq), g.col(sq)
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			if nb, ok := g.squareAt(row+dr, col+dc); ok && nb != sq {
+				nbs = append(nbs, nb)
+			}
+		}
+	}
+	return nbs
+}
+
+// drawArrows draws the arrows of g onto canvas, as a circle around the circle
+// square and a line with an arrowhead through the centers of the path.
+func (g *Grid) drawArrows(canvas *svg.Canvas, startX, startY, cellsize int) {
+	center := func(sq Index) (int, int) {
+		return startX + g.col(sq)*cellsize + cellsize/2, startY + g.row(sq)*cellsize + cellsize/2
+	}
+
+	radius := cellsize * 2 / 5
+	style := "stroke:gray; stroke-width:3; fill:none; stroke-linecap:round"
+	for _, arrow := range g.arrows {
+		cx, cy := center(arrow.Circle)
+		canvas.Circle(cx, cy, radius, style)
+
+		// The line starts at the edge of the circle, in the direction of the
+		// first square of the path.
+		x1, y1 := center(arrow.Path[0])
+		dx, dy := sign(x1-cx), sign(y1-cy)
+		edge := radius
+		if dx != 0 && dy != 0 {
+			edge = radius * 7 / 10
+		}
+		px, py := cx+dx*edge, cy+dy*edge
+		for _, sq := range arrow.Path {
+			x, y := center(sq)
+			canvas.Line(px, py, x, y, style)
+			dx, dy = sign(x-px), sign(y-py)
+			px, py = x, y
+		}
+
+		// The arrowhead's sides point back from the end of the line, at 45
+		// degrees to it.
+		headSize := cellsize / 6
+		bx, by := -dx, -dy
+		canvas.Line(px, py, px+(bx-by)*headSize, py+(bx+by)*headSize, style)
+		canvas.Line(px, py, px+(bx+by)*headSize, py+(by-bx)*headSize, style)
+	}
+}
+
+// sign returns -1, 0 or 1 according to the sign of n.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
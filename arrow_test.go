@@ -0,0 +1,165 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var arrowList string = `
+# circle: path
+22: 31 30 20
+76: 67 59
+12: 4 5
+57: 49
+65: 64 54
+23: 33
+38: 39 48
+52: 60 69
+2: 10 1
+7: 8 17
+41: 42 43
+46: 37 27
+`
+
+// An arrow puzzle with the arrowList arrows.
+var arrowBoard string = `
+. . . |. . . |. . .
+. . . |. . 2 |. . .
+. 5 . |9 . . |. . 6
+------+------+------
+. . . |. . 7 |. . .
+. . . |. . . |. . .
+. . . |. . . |. . .
+------+------+------
+. . . |. . 6 |. . .
+8 . . |. . . |. . .
+. . . |. . . |. . 7`
+
+func TestParseArrows(t *testing.T) {
+	arrows, err := standard.ParseArrows(arrowList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arrows) != 12 {
+		t.Errorf("got %v arrows, want 12", len(arrows))
+	}
+	if arrows[0].Circle != 22 || len(arrows[0].Path) != 3 || arrows[0].Path[2] != 20 {
+		t.Errorf("got arrow %v, want 22: 31 30 20", arrows[0])
+	}
+
+	for _, bad := range []string{"1 2", "1 2: 3", ": 1", "1:", "x: 1", "1: 81"} {
+		if _, err := standard.ParseArrows(bad); err == nil {
+			t.Errorf("ParseArrows(%q): got no error", bad)
+		}
+	}
+}
+
+func TestWithArrowsErrors(t *testing.T) {
+	var tests = []struct {
+		arrows  []Arrow
+		wantErr string
+	}{
+		{[]Arrow{{Circle: 0}}, "0 squares"},
+		{[]Arrow{{Circle: 0, Path: []Index{2}}}, "not neighbors"},
+		{[]Arrow{{Circle: 0, Path: []Index{1, 3}}}, "not neighbors"},
+		{[]Arrow{{Circle: 0, Path: []Index{1, 0}}}, "more than once"},
+		{[]Arrow{{Circle: 0, Path: []Index{1, 10, 1}}}, "more than once"},
+		{[]Arrow{{Circle: 81, Path: []Index{80}}}, "out of range"},
+	}
+
+	for _, tt := range tests {
+		_, err := standard.WithArrows(tt.arrows)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("WithArrows(%v): got error %v, want %q", tt.arrows, err, tt.wantErr)
+		}
+	}
+}
+
+func TestArrowPropagation(t *testing.T) {
+	// Propagation works with bounds on each square's candidates: the circle of
+	// a 3-square arrow is at least 3, and each square on the path is at most 7.
+	ag, err := standard.WithArrows([]Arrow{{Circle: 0, Path: []Index{1, 2, 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := ag.EmptyBoard()
+	if !ag.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	if v[0] != ag.full&^digitsUpTo(2) {
+		t.Errorf("got %v for circle, want 3456789", v[0])
+	}
+	for _, sq := range []Index{1, 2, 3} {
+		if v[sq] != digitsUpTo(7) {
+			t.Errorf("got %v for square %v, want 1234567", v[sq], sq)
+		}
+	}
+
+	// Assigning digits on the path bounds the circle, and vice versa.
+	if !ag.assign(v, 1, 4) || !ag.assign(v, 2, 2) {
+		t.Fatal("contradiction on assign")
+	}
+	if v[0] != ag.full&^digitsUpTo(6) || v[3] != SingleDigitSet(1).Add(3) {
+		t.Errorf("got %v, %v, want 789, 13", v[0], v[3])
+	}
+	if !ag.assign(v, 0, 7) || v[3] != SingleDigitSet(1) {
+		t.Errorf("got %v, want 1", v[3])
+	}
+}
+
+func TestArrowSolve(t *testing.T) {
+	arrows, err := standard.ParseArrows(arrowList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ag, err := standard.WithArrows(arrows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := ag.ParseBoard(arrowBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := ag.SolveAll(v, -1)
+	if len(vs) != 1 {
+		t.Fatalf("got %v solutions, want 1", len(vs))
+	}
+	if !ag.IsSolved(vs[0]) {
+		t.Errorf("got unsolved board:\n%v", ag.Display(vs[0]))
+	}
+}
+
+func TestGenerateArrows(t *testing.T) {
+	ag, board := standard.GenerateArrows(10, 0)
+	if len(ag.Arrows()) == 0 || len(ag.Arrows()) > 10 {
+		t.Errorf("got %v arrows, want 1-10", len(ag.Arrows()))
+	}
+	if !ag.HasUniqueSolution(board) {
+		t.Errorf("expect a unique solution")
+	}
+}
+
+func TestArrowSVG(t *testing.T) {
+	ag, err := standard.WithArrows([]Arrow{{Circle: 0, Path: []Index{1, 2}}, {Circle: 40, Path: []Index{50}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	ag.DisplayAsSVG(&buf, ag.EmptyBoard(), 1.0)
+	svgText := buf.String()
+
+	if got := strings.Count(svgText, "<circle"); got != 2 {
+		t.Errorf("got %v circles, want 2", got)
+	}
+	// A line per square on the path, and two for the arrowhead.
+	if got := strings.Count(svgText, "<line"); got != 7 {
+		t.Errorf("got %v lines, want 7", got)
+	}
+	// The first arrow starts at the right edge of its circle.
+	if !strings.Contains(svgText, `<line x1="122" y1="90" x2="170" y2="90"`) {
+		t.Errorf("expect arrow to start at the circle's edge")
+	}
+}
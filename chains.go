@@ -0,0 +1,411 @@
+package sudoku
+
+import "slices"
+
+// This file has the techniques based on chains of links between candidates:
+// the colorings of conjugate pairs, X-cycles and alternating inference chains.
+
+// cluster is a connected set of conjugate pairs of a digit, whose squares are
+// colored in two alternating colors: one of the colors has the digit in all
+// its squares, and the other in none.
+type cluster struct {
+	colors [2][]Index
+	links  []Link
+}
+
+// color returns the color of square sq in c, or -1 if sq isn't in c.
+func (c *cluster) color(sq Index) int {
+	for i, squares := range c.colors {
+		if slices.Contains(squares, sq) {
+			return i
+		}
+	}
+	return -1
+}
+
+// clusters returns the clusters of the conjugate pairs of digit d: the pairs
+// of squares that are the only candidates of d in a unit.
+func (ls *logicState) clusters(d uint16) []*cluster {
+	adjacent := make(map[Index][]Index)
+	for _, unit := range ls.g.unitlist {
+		if ls.placed(unit).IsMember(d) {
+			continue
+		}
+		if pair := ls.positions(unit, d); len(pair) == 2 && !slices.Contains(adjacent[pair[0]], pair[1]) {
+			adjacent[pair[0]] = append(adjacent[pair[0]], pair[1])
+			adjacent[pair[1]] = append(adjacent[pair[1]], pair[0])
+		}
+	}
+
+	var clusters []*cluster
+	colored := make(map[Index]bool)
+	for sq := range ls.values {
+		if colored[sq] || adjacent[sq] == nil {
+			continue
+		}
+		c := &cluster{}
+		colored[sq] = true
+		c.colors[0] = append(c.colors[0], sq)
+		for queue := []Index{sq}; len(queue) > 0; queue = queue[1:] {
+			from := queue[0]
+			color := c.color(from)
+			for _, to := range adjacent[from] {
+				if from < to {
+					c.links = append(c.links, Link{From: Candidate{from, d}, To: Candidate{to, d}, Strong: true})
+				}
+				if !colored[to] {
+					colored[to] = true
+					c.colors[1-color] = append(c.colors[1-color], to)
+					queue = append(queue, to)
+				}
+			}
+		}
+		slices.Sort(c.colors[0])
+		slices.Sort(c.colors[1])
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
+
+// seesAny checks whether square sq sees any of the squares.
+func (ls *logicState) seesAny(sq Index, squares []Index) bool {
+	return slices.ContainsFunc(squares, func(other Index) bool { return ls.sees(sq, other) })
+}
+
+func (ls *logicState) findSimpleColoring() *Step {
+	for d := uint16(1); d <= uint16(ls.g.size); d++ {
+		for _, c := range ls.clusters(d) {
+			step := &Step{Technique: SimpleColoring, Digits: SingleDigitSet(d), Links: c.links}
+
+			// Color wrap: a color with two squares that see each other doesn't
+			// have the digit, so the other color does.
+			for i, squares := range c.colors {
+				if slices.ContainsFunc(squares, func(sq Index) bool { return ls.seesAny(sq, squares) }) {
+					for _, sq := range squares {
+						step.Eliminations = append(step.Eliminations, Candidate{sq, d})
+					}
+					step.Colors = [][]Index{squares, c.colors[1-i]}
+					step.Squares = append(slices.Clone(squares), c.colors[1-i]...)
+					return step
+				}
+			}
+
+			// Color trap: the squares that see both colors don't have the digit.
+			for sq := range ls.values {
+				if !ls.solved[sq] && ls.values[sq].IsMember(d) && c.color(sq) < 0 &&
+					ls.seesAny(sq, c.colors[0]) && ls.seesAny(sq, c.colors[1]) {
+					step.Eliminations = append(step.Eliminations, Candidate{sq, d})
+				}
+			}
+			if len(step.Eliminations) > 0 {
+				step.Colors = [][]Index{c.colors[0], c.colors[1]}
+				step.Squares = append(slices.Clone(c.colors[0]), c.colors[1]...)
+				return step
+			}
+		}
+	}
+	return nil
+}
+
+func (ls *logicState) findMultiColoring() *Step {
+	for d := uint16(1); d <= uint16(ls.g.size); d++ {
+		clusters := ls.clusters(d)
+		for i, c1 := range clusters {
+			for _, c2 := range clusters[i+1:] {
+				for _, colors := range [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+					// When color a of c1 sees color b of c2, they can't both have
+					// the digit, so one of their opposite colors A and B has it.
+					a, b := c1.colors[colors[0]], c2.colors[colors[1]]
+					if !slices.ContainsFunc(a, func(sq Index) bool { return ls.seesAny(sq, b) }) {
+						continue
+					}
+					opA, opB := c1.colors[1-colors[0]], c2.colors[1-colors[1]]
+					var elims []Candidate
+					for sq := range ls.values {
+						if !ls.solved[sq] && ls.values[sq].IsMember(d) && c1.color(sq) < 0 && c2.color(sq) < 0 &&
+							ls.seesAny(sq, opA) && ls.seesAny(sq, opB) {
+							elims = append(elims, Candidate{sq, d})
+						}
+					}
+					if len(elims) > 0 {
+						var squares []Index
+						for _, color := range [][]Index{a, opA, b, opB} {
+							squares = append(squares, color...)
+						}
+						return &Step{
+							Technique:    MultiColoring,
+							Digits:       SingleDigitSet(d),
+							Squares:      squares,
+							Links:        append(slices.Clone(c1.links), c2.links...),
+							Colors:       [][]Index{a, opA, b, opB},
+							Eliminations: elims,
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (ls *logicState) findXCycle() *Step {
+	for d := uint16(1); d <= uint16(ls.g.size); d++ {
+		if step := ls.newChainGraph(d).shortestChain(); step != nil {
+			step.Technique = XCycle
+			return step
+		}
+	}
+	return nil
+}
+
+func (ls *logicState) findAIC() *Step {
+	if step := ls.newChainGraph(0).shortestChain(); step != nil {
+		step.Technique = AIC
+		return step
+	}
+	return nil
+}
+
+// chainGraph is the graph of the links between the candidates of a board, for
+// finding alternating inference chains.
+type chainGraph struct {
+	ls *logicState
+
+	// nodes are the candidates of the unsolved squares, and ids maps each
+	// candidate (by square and digit) to its index in nodes, or -1.
+	nodes []Candidate
+	ids   []int
+
+	// strong has the nodes with a strong link to each node, and weak the nodes
+	// with a weak link to it that also have strong links, which are the only
+	// ones that can continue a chain.
+	strong, weak [][]int
+}
+
+// newChainGraph creates the graph of the candidates of digit d, or of all
+// the digits if d is 0. The strong links are between the only two candidates
+// of a digit in a unit and, for all the digits, between the two candidates of
+// a square; the weak links are between candidates of the same digit in peers
+// and, for all the digits, between the candidates of a square.
+func (ls *logicState) newChainGraph(d uint16) *chainGraph {
+	g := ls.g
+	cg := &chainGraph{ls: ls, ids: make([]int, len(ls.values)*g.size)}
+	for i := range cg.ids {
+		cg.ids[i] = -1
+	}
+	for sq, digits := range ls.values {
+		if ls.solved[sq] {
+			continue
+		}
+		for digit := uint16(1); digit <= uint16(g.size); digit++ {
+			if digits.IsMember(digit) && (d == 0 || digit == d) {
+				cg.ids[sq*g.size+int(digit)-1] = len(cg.nodes)
+				cg.nodes = append(cg.nodes, Candidate{sq, digit})
+			}
+		}
+	}
+
+	cg.strong = make([][]int, len(cg.nodes))
+	addStrong := func(a, b int) {
+		if !slices.Contains(cg.strong[a], b) {
+			cg.strong[a] = append(cg.strong[a], b)
+			cg.strong[b] = append(cg.strong[b], a)
+		}
+	}
+	for _, unit := range g.unitlist {
+		for digit := uint16(1); digit <= uint16(g.size); digit++ {
+			if (d != 0 && digit != d) || ls.placed(unit).IsMember(digit) {
+				continue
+			}
+			if pair := ls.positions(unit, digit); len(pair) == 2 {
+				addStrong(cg.id(Candidate{pair[0], digit}), cg.id(Candidate{pair[1], digit}))
+			}
+		}
+	}
+	if d == 0 {
+		for sq := range ls.values {
+			if ls.isBivalue(sq) {
+				d1, d2 := ls.values[sq].twoMemberDigits()
+				addStrong(cg.id(Candidate{sq, d1}), cg.id(Candidate{sq, d2}))
+			}
+		}
+	}
+
+	cg.weak = make([][]int, len(cg.nodes))
+	for a, c := range cg.nodes {
+		for _, b := range cg.weakLinks(c) {
+			if len(cg.strong[b]) > 0 {
+				cg.weak[a] = append(cg.weak[a], b)
+			}
+		}
+	}
+	return cg
+}
+
+// id returns the index of candidate c in cg.nodes, or -1.
+func (cg *chainGraph) id(c Candidate) int {
+	return cg.ids[c.Square*cg.ls.g.size+int(c.Digit)-1]
+}
+
+// weakLinks returns the nodes with a weak link to candidate c.
+func (cg *chainGraph) weakLinks(c Candidate) []int {
+	var links []int
+	for digit := uint16(1); digit <= uint16(cg.ls.g.size); digit++ {
+		if id := cg.id(Candidate{c.Square, digit}); digit != c.Digit && id >= 0 {
+			links = append(links, id)
+		}
+	}
+	for _, peer := range cg.ls.g.peers[c.Square] {
+		if id := cg.id(Candidate{peer, c.Digit}); id >= 0 {
+			links = append(links, id)
+		}
+	}
+	return links
+}
+
+// isWeak checks whether there's a weak link between candidates a and b.
+func (cg *chainGraph) isWeak(a, b Candidate) bool {
+	if a.Square == b.Square {
+		return a.Digit != b.Digit
+	}
+	return a.Digit == b.Digit && cg.ls.sees(a.Square, b.Square)
+}
+
+// shortestChain returns the step of the shortest alternating inference chain
+// with eliminations or a placement, or nil if there's none.
+//
+// The chains are found with a breadth-first search from each node that is
+// assumed false: a strong link makes the next node true, and a weak link from
+// a true node makes the next node false. A chain ends at a node that it made
+// true: one of its ends is true. The search only keeps the shortest path to
+// each node and truth value, and skips the paths that visit a node twice.
+func (cg *chainGraph) shortestChain() *Step {
+	var best *Step
+	// The states of the search are the nodes with their truth values, as
+	// 2*node+1 for true nodes and 2*node for false ones.
+	parent := make([]int, 2*len(cg.nodes))
+	for start := range cg.nodes {
+		if len(cg.strong[start]) == 0 {
+			continue
+		}
+		for i := range parent {
+			parent[i] = -1
+		}
+		parent[2*start] = 2 * start
+		queue := []int{2 * start}
+		for len(queue) > 0 {
+			state := queue[0]
+			queue = queue[1:]
+			node, on := state/2, state%2 == 1
+			next := cg.strong[node]
+			if on {
+				next = cg.weak[node]
+			}
+			for _, to := range next {
+				toState := 2*to + 1
+				if on {
+					toState = 2 * to
+				}
+				if parent[toState] >= 0 {
+					continue
+				}
+				parent[toState] = state
+				path := cg.path(parent, toState)
+				if path == nil {
+					continue
+				}
+				if best != nil && len(path)-1 >= len(best.Links) {
+					// The rest of the search has no shorter paths.
+					queue = nil
+					break
+				}
+				queue = append(queue, toState)
+				if !on {
+					if step := cg.chainStep(path); step != nil {
+						best = step
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// path returns the nodes of the path of the search to state, from its start,
+// or nil if it visits a node twice (except for a path that ends at its
+// start).
+func (cg *chainGraph) path(parent []int, state int) []int {
+	var path []int
+	for {
+		path = append(path, state/2)
+		if parent[state] == state {
+			break
+		}
+		state = parent[state]
+	}
+	slices.Reverse(path)
+	inner := slices.Clone(path[1:])
+	if path[len(path)-1] == path[0] {
+		inner = inner[:len(inner)-1]
+	}
+	slices.Sort(inner)
+	if len(slices.Compact(inner)) != len(inner) || slices.Contains(inner, path[0]) {
+		return nil
+	}
+	return path
+}
+
+// chainStep returns the step of the chain of nodes path, which starts with a
+// false node and ends with a true one, or nil if it has no eliminations.
+func (cg *chainGraph) chainStep(path []int) *Step {
+	candidates := make([]Candidate, len(path))
+	for i, node := range path {
+		candidates[i] = cg.nodes[node]
+	}
+	first, last := candidates[0], candidates[len(candidates)-1]
+	step := &Step{Links: chainLinks(candidates...)}
+
+	switch {
+	case first == last:
+		// The first candidate can't be false.
+		step.Placements = []Candidate{first}
+	case len(candidates) >= 4 && cg.isWeak(last, first):
+		// A loop: either the even or the odd candidates are true, so each weak
+		// link has one true candidate.
+		step.Links = append(step.Links, Link{From: last, To: first})
+		for _, link := range step.Links {
+			if !link.Strong {
+				step.Eliminations = append(step.Eliminations, cg.eliminations(link.From, link.To, candidates)...)
+			}
+		}
+		slices.SortFunc(step.Eliminations, func(a, b Candidate) int {
+			return (a.Square-b.Square)*cg.ls.g.size + int(a.Digit) - int(b.Digit)
+		})
+		step.Eliminations = slices.Compact(step.Eliminations)
+	default:
+		step.Eliminations = cg.eliminations(first, last, candidates)
+	}
+	if len(step.Placements)+len(step.Eliminations) == 0 {
+		return nil
+	}
+
+	for _, c := range candidates {
+		step.Digits = step.Digits.Add(c.Digit)
+		if !slices.Contains(step.Squares, c.Square) {
+			step.Squares = append(step.Squares, c.Square)
+		}
+	}
+	return step
+}
+
+// eliminations returns the candidates with weak links to both a and b, one of
+// which is true, except for the candidates of the chain.
+func (cg *chainGraph) eliminations(a, b Candidate, chain []Candidate) []Candidate {
+	var elims []Candidate
+	for _, id := range cg.weakLinks(a) {
+		if c := cg.nodes[id]; !slices.Contains(chain, c) && cg.isWeak(c, b) {
+			elims = append(elims, c)
+		}
+	}
+	return elims
+}
@@ -0,0 +1,104 @@
+package sudoku
+
+import (
+	"slices"
+	"testing"
+)
+
+// digitBoard returns an empty board where digit d is only a candidate of the
+// given squares.
+func digitBoard(d uint16, squares ...Index) Values {
+	v := EmptyBoard()
+	for sq := range v {
+		if !slices.Contains(squares, sq) {
+			v[sq] = v[sq].Remove(d)
+		}
+	}
+	return v
+}
+
+// colorTrapBoard has a cluster of conjugate pairs of 1 in r1c1 and r1c5 (in
+// the first row), r3c6 (in the second box) and r7c6 (in the sixth column),
+// and r7c1 sees both of its colors. r4c1 and r7c9 leave 1 in three squares
+// of the first column and the seventh row.
+var colorTrapBoard = digitBoard(1, 0, 4, 23, 59, 54, 27, 62)
+
+func TestSimpleColoring(t *testing.T) {
+	step := standard.newLogicState(colorTrapBoard).findSimpleColoring()
+	if step == nil || step.Technique != SimpleColoring || step.Digits != SingleDigitSet(1) {
+		t.Fatalf("got step %+v, want simple coloring", step)
+	}
+	if !slices.EqualFunc(step.Colors, [][]Index{{0, 23}, {4, 59}}, slices.Equal) || len(step.Links) != 3 {
+		t.Errorf("got colors %v and links %v", step.Colors, step.Links)
+	}
+	if !slices.Equal(step.Eliminations, []Candidate{{54, 1}}) {
+		t.Errorf("got eliminations %v, want 1 in r7c1", step.Eliminations)
+	}
+
+	// The cluster of r1c1, r1c5, r5c5, r5c2 and r2c2 colors r1c1 and r2c2,
+	// which are both in the first box, in the same color.
+	v := digitBoard(1, 0, 4, 40, 37, 10, 20)
+	step = standard.newLogicState(v).findSimpleColoring()
+	if step == nil || !slices.EqualFunc(step.Colors, [][]Index{{0, 10, 40}, {4, 37}}, slices.Equal) {
+		t.Fatalf("got step %+v, want simple coloring", step)
+	}
+	if !slices.Equal(step.Eliminations, []Candidate{{0, 1}, {10, 1}, {40, 1}}) {
+		t.Errorf("got eliminations %v, want the first color", step.Eliminations)
+	}
+	if got, want := DescribeStep(step), "Simple coloring: the conjugate pairs of 1 color r1c1, r2c2 and r5c5 against r1c5 and r5c2; two squares of the first color see each other, so it doesn't have 1, which can be eliminated from its squares: r1c1, r2c2 and r5c5."; got != want {
+		t.Errorf("got description\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestXCycle(t *testing.T) {
+	// The coloring is also the chain 1r1c1 = 1r1c5 - 1r3c6 = 1r7c6.
+	step := standard.newLogicState(colorTrapBoard).findXCycle()
+	if step == nil || step.Technique != XCycle || !slices.Equal(step.Eliminations, []Candidate{{54, 1}}) {
+		t.Fatalf("got step %+v, want X-cycle", step)
+	}
+	want := chainLinks(Candidate{0, 1}, Candidate{4, 1}, Candidate{23, 1}, Candidate{59, 1})
+	if !slices.Equal(step.Links, want) || !slices.Equal(step.Squares, []Index{0, 4, 23, 59}) {
+		t.Errorf("got links %v, want %v", step.Links, want)
+	}
+}
+
+func TestAIC(t *testing.T) {
+	// r1c1, r1c5 and r5c5 have 12, 23 and 13: either r1c1 or r5c5 has 1.
+	v := EmptyBoard()
+	v[0] = Digits(0).Add(1).Add(2)
+	v[4] = Digits(0).Add(2).Add(3)
+	v[40] = Digits(0).Add(1).Add(3)
+	step := standard.newLogicState(v).findAIC()
+	if step == nil || step.Technique != AIC || step.Digits != v[0]|v[4] || !slices.Equal(step.Eliminations, []Candidate{{36, 1}}) {
+		t.Fatalf("got step %+v, want AIC", step)
+	}
+	want := chainLinks(Candidate{0, 1}, Candidate{0, 2}, Candidate{4, 2}, Candidate{4, 3}, Candidate{40, 3}, Candidate{40, 1})
+	if !slices.Equal(step.Links, want) {
+		t.Errorf("got links %v, want %v", step.Links, want)
+	}
+	if got, want := DescribeStep(step), "Alternating inference chain: 1r1c1 = 2r1c1 - 2r1c5 = 3r1c5 - 3r5c5 = 1r5c5, so one of its ends is true, and the candidates that see both ends can be eliminated: r5c1 (1)."; got != want {
+		t.Errorf("got description\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestChainLoop(t *testing.T) {
+	// The X-Wing of 1 in the first and fifth rows is the loop 1r1c1 = 1r1c5 -
+	// 1r5c5 = 1r5c1 - 1r1c1, whose weak links eliminate 1 from the rest of
+	// the first and fifth columns.
+	var squares []Index
+	for sq := range 81 {
+		if (standard.row(sq) != 0 && standard.row(sq) != 4) || standard.col(sq) == 0 || standard.col(sq) == 4 {
+			squares = append(squares, sq)
+		}
+	}
+	v := digitBoard(1, squares...)
+	step := standard.newLogicState(v).findXCycle()
+	if step == nil || len(step.Links) != 4 || step.Links[3].Strong || len(step.Eliminations) != 14 {
+		t.Fatalf("got step %+v, want loop", step)
+	}
+	for _, c := range step.Eliminations {
+		if col := standard.col(c.Square); col != 0 && col != 4 {
+			t.Errorf("got elimination %v", c)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -18,6 +19,8 @@ var symFlag = flag.Bool("sym", false, "generate a symmetrical puzzle")
 var diffFlag = flag.Float64("diff", 2.5, "minimal difficulty for generated puzzle")
 var hintCountFlag = flag.Int("hintcount", 28, "hint count for generation; higher counts lead to easier puzzles")
 var svgOutFlag = flag.String("svgout", "", "file name for SVG output, if needed")
+var backendFlag = flag.String("backend", "propagation", "solver backend for generation: propagation, dlx, sat")
+var seedFlag = flag.Int64("seed", 0, "seed for the random generator, to reproduce a puzzle; 0 picks a random seed")
 
 func main() {
 	flag.Usage = func() {
@@ -28,21 +31,35 @@ func main() {
 	}
 	flag.Parse()
 
-	rand.Seed(time.Now().UnixNano())
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	backend, err := sudoku.ParseBackend(*backendFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	options := sudoku.GenerateOptions{Backend: backend, Rand: rng}
 
 	count := 0
 	maxDifficultySeen := 0.0
 
 	for {
 		var board sudoku.Values
+		var err error
 
 		if *symFlag {
-			board = sudoku.GenerateSymmetrical(*hintCountFlag)
+			board, err = sudoku.GenerateSymmetricalContext(context.Background(), *hintCountFlag, options)
 		} else {
-			board = sudoku.Generate(*hintCountFlag)
+			board, err = sudoku.GenerateContext(context.Background(), *hintCountFlag, options)
+		}
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		d, err := sudoku.EvaluateDifficulty(board)
+		d, err := sudoku.EvaluateDifficulty(board, sudoku.DifficultyOptions{Rand: rng})
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -50,6 +67,7 @@ func main() {
 		if d >= *diffFlag {
 			fmt.Println(sudoku.DisplayAsInput(board))
 			fmt.Printf("Difficulty: %.2f\n", d)
+			fmt.Printf("Seed: %v\n", seed)
 
 			if len(*svgOutFlag) > 0 {
 				f, err := os.Create(*svgOutFlag)
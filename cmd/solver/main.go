@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -15,7 +16,10 @@ import (
 
 var statsFlag = flag.Bool("stats", false, "enable stats for solving")
 var randomizeFlag = flag.Bool("randomize", false, "randomize solving order")
-var actionFlag = flag.String("action", "solve", "action to perform: solve, count")
+var actionFlag = flag.String("action", "solve", "action to perform: solve, count, cnf, logic")
+var backendFlag = flag.String("backend", "propagation", "solver backend: propagation, dlx, sat")
+var subsetsFlag = flag.Int("subsets", 0, "apply naked and hidden subsets of up to this size after each guess (propagation backend)")
+var seedFlag = flag.Int64("seed", 0, "seed for randomized solving, to reproduce a run; 0 uses a random seed")
 
 func main() {
 	flag.Usage = func() {
@@ -31,6 +35,10 @@ func main() {
 		solveAndReport()
 	case "count":
 		countHints()
+	case "cnf":
+		writeCNF()
+	case "logic":
+		solveLogically()
 	default:
 		flag.Usage()
 		log.Fatal("Please select one of the supported actions.")
@@ -43,15 +51,19 @@ func solveAndReport() {
 	var totalSearches uint64 = 0
 	var totalDifficulty float64
 	var maxSearches uint64 = 0
+	var totalBacktracks uint64 = 0
+	var maxDepth int = 0
 	var numBoards int = 0
 	var numSolved int = 0
 
-	if *statsFlag {
-		sudoku.EnableStats = true
+	backend, err := sudoku.ParseBackend(*backendFlag)
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	if *randomizeFlag {
-		rand.Seed(time.Now().UnixNano())
+	var stats sudoku.SolveStats
+	options := sudoku.SolveOptions{Randomize: *randomizeFlag, Backend: backend, SubsetSize: *subsetsFlag, Stats: &stats}
+	if *seedFlag != 0 {
+		options.Rand = rand.New(rand.NewSource(*seedFlag))
 	}
 
 	boards := getInputBoards()
@@ -70,7 +82,7 @@ func solveAndReport() {
 
 		tStart := time.Now()
 		sudoku.EliminateAll(v)
-		v, _ = sudoku.Solve(v, sudoku.SolveOptions{Randomize: *randomizeFlag})
+		v, _, err = sudoku.SolveContext(context.Background(), v, options)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -86,11 +98,14 @@ func solveAndReport() {
 		}
 
 		if *statsFlag {
-			totalSearches += sudoku.Stats.NumSearches
-			if sudoku.Stats.NumSearches > maxSearches {
-				maxSearches = sudoku.Stats.NumSearches
+			totalSearches += stats.NumSearches
+			if stats.NumSearches > maxSearches {
+				maxSearches = stats.NumSearches
+			}
+			totalBacktracks += stats.NumBacktracks
+			if stats.MaxDepth > maxDepth {
+				maxDepth = stats.MaxDepth
 			}
-			sudoku.Stats.Reset()
 		}
 	}
 
@@ -99,6 +114,7 @@ func solveAndReport() {
 	fmt.Printf("Duration average=%-15v max=%v\n", totalDuration/time.Duration(numBoards), maxDuration)
 	if *statsFlag {
 		fmt.Printf("Searches average=%-15.2f max=%v\n", float64(totalSearches)/float64(numBoards), maxSearches)
+		fmt.Printf("Backtracks average=%-13.2f max depth=%v\n", float64(totalBacktracks)/float64(numBoards), maxDepth)
 	}
 }
 
@@ -126,6 +142,42 @@ func countHints() {
 	}
 }
 
+// writeCNF writes the SAT formula of each input board to stdout in the DIMACS
+// format, for solving with an external SAT solver.
+func writeCNF() {
+	for _, board := range getInputBoards() {
+		v, err := sudoku.ParseBoard(board, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("c board: %v\n", board)
+		if err := sudoku.EncodeCNF(v).WriteDIMACS(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// solveLogically solves each input board without guessing, and prints the
+// steps it took.
+func solveLogically() {
+	for _, board := range getInputBoards() {
+		fmt.Println("board:", board)
+		v, err := sudoku.ParseBoard(board, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		v, steps, solved := sudoku.SolveLogically(v)
+		for i, step := range steps {
+			fmt.Printf("%4v. %v\n", i+1, sudoku.DescribeStep(&step))
+		}
+		if solved {
+			fmt.Printf("solved in %v steps\n\n", len(steps))
+		} else {
+			fmt.Printf("stuck after %v steps:\n%v\n", len(steps), sudoku.Display(v))
+		}
+	}
+}
+
 // getInputBoards reads input boards from stdin, ignores comments and empty
 // lines and returns them.
 func getInputBoards() []string {
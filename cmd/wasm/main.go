@@ -4,6 +4,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"syscall/js"
@@ -23,20 +24,34 @@ func main() {
 
 // jsGenerateBoard wraps the functionality we need from this package, for use
 // in the web interface. It creates a function that takes two parameters:
-// an integer hint count, and a boolean "is symmetrical" flag. It returns
-// the SVG generated for the board as a string.
+// an integer hint count, and a boolean "is symmetrical" flag, and optionally
+// a third: the name of the solver backend to generate with (e.g. "dlx"). It
+// returns the SVG generated for the board as a string.
 var jsGenerateBoard = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-	if len(args) != 2 {
-		return fmt.Sprintf("got %v args, want 2", len(args))
+	if len(args) != 2 && len(args) != 3 {
+		return fmt.Sprintf("got %v args, want 2 or 3", len(args))
 	}
 	hintCount := args[0].Int()
 	symmetrical := args[1].Bool()
 
+	var options sudoku.GenerateOptions
+	if len(args) == 3 {
+		backend, err := sudoku.ParseBackend(args[2].String())
+		if err != nil {
+			return err.Error()
+		}
+		options.Backend = backend
+	}
+
 	var board sudoku.Values
+	var err error
 	if symmetrical {
-		board = sudoku.GenerateSymmetrical(hintCount)
+		board, err = sudoku.GenerateSymmetricalContext(context.Background(), hintCount, options)
 	} else {
-		board = sudoku.Generate(hintCount)
+		board, err = sudoku.GenerateContext(context.Background(), hintCount, options)
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	d, err := sudoku.EvaluateDifficulty(board)
@@ -0,0 +1,225 @@
+package sudoku
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A board is encoded as a SAT formula with a Boolean variable for each square
+// and digit, which is true if the square has the digit; see CNFVariable for
+// their numbering. The formula has clauses for these rules:
+//
+//   - Each square has one of its candidates, and at most one digit.
+//   - Each unit has each digit at least once, and each pair of peers has each
+//     digit at most once.
+//   - The additional constraints of the grid, like killer cages, which may
+//     need auxiliary variables; they're numbered after the square variables.
+//
+// The models of the formula (its satisfying assignments) are the solutions of
+// the board, although several models may have the same square variables, and
+// so be the same solution, if the formula has auxiliary variables.
+
+// CNF is a Boolean formula in conjunctive normal form: a conjunction of
+// clauses, each of which is a disjunction of literals.
+type CNF struct {
+	// NumVars is the number of variables; they're numbered 1 to NumVars.
+	NumVars int
+
+	// Clauses holds the clauses of the formula. A literal is the number of a
+	// variable, or its negation for the negated variable, as in DIMACS.
+	Clauses [][]int
+}
+
+// ErrUnsatisfiable is returned by ReadDIMACSModel when the SAT solver's
+// output says the formula is unsatisfiable, so the board has no solution.
+var ErrUnsatisfiable = errors.New("the formula is unsatisfiable")
+
+// EncodeCNF encodes the board values as a SAT formula, whose models are the
+// solutions of the board.
+func EncodeCNF(values Values) *CNF {
+	return standard.EncodeCNF(values)
+}
+
+// EncodeCNF is the equivalent of the package-level EncodeCNF for boards of
+// grid g, encoding the constraints of the grid too.
+func (g *Grid) EncodeCNF(values Values) *CNF {
+	enc := &cnfEncoder{g: g, cnf: &CNF{NumVars: g.NumSquares() * g.size}}
+	for sq, digits := range values {
+		var candidates []int
+		for d := uint16(1); d <= uint16(g.size); d++ {
+			if digits.IsMember(d) {
+				candidates = append(candidates, enc.variable(sq, d))
+			} else {
+				enc.add(-enc.variable(sq, d))
+			}
+		}
+		enc.add(candidates...)
+		for d1 := uint16(1); d1 <= uint16(g.size); d1++ {
+			for d2 := d1 + 1; d2 <= uint16(g.size); d2++ {
+				enc.add(-enc.variable(sq, d1), -enc.variable(sq, d2))
+			}
+		}
+	}
+
+	for _, unit := range g.unitlist {
+		for d := uint16(1); d <= uint16(g.size); d++ {
+			clause := make([]int, len(unit))
+			for i, sq := range unit {
+				clause[i] = enc.variable(sq, d)
+			}
+			enc.add(clause...)
+		}
+	}
+	for sq, peers := range g.peers {
+		for _, peer := range peers {
+			if peer > sq {
+				for d := uint16(1); d <= uint16(g.size); d++ {
+					enc.add(-enc.variable(sq, d), -enc.variable(peer, d))
+				}
+			}
+		}
+	}
+
+	for _, c := range g.constraints {
+		c.encode(enc)
+	}
+	return enc.cnf
+}
+
+// CNFVariable returns the number of the variable of the formulas created by
+// EncodeCNF that's true when square sq has the given digit.
+func CNFVariable(sq Index, digit uint16) int {
+	return standard.CNFVariable(sq, digit)
+}
+
+// CNFVariable is the equivalent of the package-level CNFVariable for the
+// formulas of grid g. The variables of square sq are numbered from
+// sq*g.Size()+1 to (sq+1)*g.Size(), in the order of their digits.
+func (g *Grid) CNFVariable(sq Index, digit uint16) int {
+	return sq*g.size + int(digit)
+}
+
+// cnfEncoder builds the formula of a board of grid g.
+type cnfEncoder struct {
+	g   *Grid
+	cnf *CNF
+}
+
+// variable returns the variable of square sq and digit d.
+func (enc *cnfEncoder) variable(sq Index, d uint16) int {
+	return enc.g.CNFVariable(sq, d)
+}
+
+// newVariable adds an auxiliary variable, and returns it.
+func (enc *cnfEncoder) newVariable() int {
+	enc.cnf.NumVars++
+	return enc.cnf.NumVars
+}
+
+// add adds a clause with the given literals; the clause keeps the slice of
+// literals it's given.
+func (enc *cnfEncoder) add(literals ...int) {
+	enc.cnf.Clauses = append(enc.cnf.Clauses, literals)
+}
+
+// WriteDIMACS writes the formula to w in the DIMACS CNF format, which is the
+// input format of most SAT solvers.
+func (c *CNF) WriteDIMACS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "p cnf %v %v\n", c.NumVars, len(c.Clauses))
+	var buf []byte
+	for _, clause := range c.Clauses {
+		buf = buf[:0]
+		for _, lit := range clause {
+			buf = strconv.AppendInt(buf, int64(lit), 10)
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, "0\n"...)
+		bw.Write(buf)
+	}
+	return bw.Flush()
+}
+
+// ReadDIMACSModel reads the output of a SAT solver for a formula created by
+// EncodeCNF, and returns the solution in the model it found. The output can be
+// in the format of the SAT competitions, with an "s SATISFIABLE" line and the
+// model's literals in "v" lines, or in the format of MiniSat's result file,
+// with a "SAT" line followed by the literals. If the output says the formula
+// is unsatisfiable, ErrUnsatisfiable is returned.
+//
+// Variables missing from the model are taken to be false, and auxiliary
+// variables are ignored. An error is returned if the model doesn't have
+// exactly one digit in each square, but the solution isn't checked otherwise;
+// use IsSolved to check it.
+func ReadDIMACSModel(r io.Reader) (Values, error) {
+	return standard.ReadDIMACSModel(r)
+}
+
+// ReadDIMACSModel is the equivalent of the package-level ReadDIMACSModel for
+// the formulas of grid g.
+func (g *Grid) ReadDIMACSModel(r io.Reader) (Values, error) {
+	values := make(Values, g.NumSquares())
+	numSquareVars := g.NumSquares() * g.size
+	status := ""
+
+	// Solvers like MiniSat write the whole model on one line, which can be much
+	// longer than the scanner's default limit.
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, math.MaxInt)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		switch fields[0] {
+		case "s":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("status line without a status")
+			}
+			status = strings.Join(fields[1:], " ")
+			continue
+		case "SAT", "UNSAT":
+			status = fields[0]
+			continue
+		case "v":
+			fields = fields[1:]
+		}
+
+		for _, field := range fields {
+			lit, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid literal %q", field)
+			}
+			if lit <= 0 || lit > numSquareVars {
+				continue
+			}
+			sq, d := (lit-1)/g.size, uint16((lit-1)%g.size+1)
+			if values[sq] != 0 {
+				return nil, fmt.Errorf("square %v has more than one digit", sq)
+			}
+			values[sq] = SingleDigitSet(d)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case "UNSATISFIABLE", "UNSAT":
+		return nil, ErrUnsatisfiable
+	case "", "SATISFIABLE", "SAT":
+	default:
+		return nil, fmt.Errorf("the SAT solver found no model: %v", status)
+	}
+	for sq, digits := range values {
+		if digits == 0 {
+			return nil, fmt.Errorf("square %v has no digit", sq)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,163 @@
+package sudoku
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// satisfies checks whether the assignment of the square variables of a
+// formula by the solved board values satisfies it; the formula can't have
+// auxiliary variables.
+func satisfies(g *Grid, cnf *CNF, values Values) bool {
+	for _, clause := range cnf.Clauses {
+		if !slices.ContainsFunc(clause, func(lit int) bool {
+			v := max(lit, -lit)
+			sq, d := (v-1)/g.size, uint16((v-1)%g.size+1)
+			return values[sq].IsMember(d) == (lit > 0)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncodeCNF(t *testing.T) {
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnf := EncodeCNF(v)
+	if cnf.NumVars != 729 {
+		t.Errorf("got %v variables, want 729", cnf.NumVars)
+	}
+	if CNFVariable(80, 9) != 729 || CNFVariable(1, 2) != 11 {
+		t.Errorf("got variables %v and %v, want 729 and 11", CNFVariable(80, 9), CNFVariable(1, 2))
+	}
+
+	solution, solved := Solve(v)
+	if !solved || !satisfies(standard, cnf, solution) {
+		t.Errorf("expect the solution to satisfy the formula")
+	}
+	swapped := slices.Clone(solution)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if satisfies(standard, cnf, swapped) {
+		t.Errorf("expect a wrong solution not to satisfy the formula")
+	}
+
+	// Diagonals and chess constraints don't need auxiliary variables.
+	g := standard.WithDiagonals().WithAntiKnight()
+	board := g.Generate(40)
+	variantSolution, _ := g.Solve(board)
+	if cnf := g.EncodeCNF(board); cnf.NumVars != 729 || !satisfies(g, cnf, variantSolution) {
+		t.Errorf("expect the solution of the variant to satisfy its formula")
+	}
+	if g.IsSolved(solution) || satisfies(g, g.EncodeCNF(EmptyBoard()), solution) {
+		t.Errorf("expect a solution that breaks the variant not to satisfy the formula")
+	}
+}
+
+func TestWriteDIMACS(t *testing.T) {
+	cnf := &CNF{NumVars: 3, Clauses: [][]int{{1, -2}, {3}, {}}}
+	var buf bytes.Buffer
+	if err := cnf.WriteDIMACS(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "p cnf 3 3\n1 -2 0\n3 0\n0\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestReadDIMACSModel(t *testing.T) {
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, _ := g.Solve(g.EmptyBoard())
+
+	// The model has all the variables of the formula, with a line break in
+	// the middle.
+	var model []string
+	for sq := range solution {
+		for d := uint16(1); d <= 4; d++ {
+			lit := g.CNFVariable(sq, d)
+			if !solution[sq].IsMember(d) {
+				lit = -lit
+			}
+			model = append(model, fmt.Sprint(lit))
+		}
+	}
+	half := strings.Join(model[:32], " ")
+	rest := strings.Join(model[32:], " ")
+
+	for _, output := range []string{
+		"c a comment\ns SATISFIABLE\nv " + half + "\nv " + rest + " 65 -66 0\n",
+		"SAT\n" + half + "\n" + rest + " 0\n",
+	} {
+		v, err := g.ReadDIMACSModel(strings.NewReader(output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(v, solution) {
+			t.Errorf("got model\n%v\nwant\n%v", g.Display(v), g.Display(solution))
+		}
+	}
+
+	// A model on one long line, with many auxiliary variables.
+	var aux strings.Builder
+	for lit := 65; lit < 100000; lit++ {
+		fmt.Fprintf(&aux, " -%v", lit)
+	}
+	v, err := g.ReadDIMACSModel(strings.NewReader("SAT\n" + half + " " + rest + aux.String() + " 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(v, solution) {
+		t.Errorf("got model\n%v\nwant\n%v", g.Display(v), g.Display(solution))
+	}
+
+	if _, err := g.ReadDIMACSModel(strings.NewReader("s UNSATISFIABLE\n")); !errors.Is(err, ErrUnsatisfiable) {
+		t.Errorf("got error %v, want ErrUnsatisfiable", err)
+	}
+	for _, bad := range []string{
+		"s UNKNOWN\n",
+		"v 1 2 0\n",
+		"v " + half + " 0\n",
+		"v " + half + " x 0\n",
+	} {
+		if _, err := g.ReadDIMACSModel(strings.NewReader(bad)); err == nil || errors.Is(err, ErrUnsatisfiable) {
+			t.Errorf("ReadDIMACSModel(%q): got error %v, want error", bad, err)
+		}
+	}
+}
+
+func TestSATModelRoundTrip(t *testing.T) {
+	// Solve a formula with the SAT backend's solver, and read back its model
+	// in the output format of the SAT competitions, like an external solver's.
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := newSATSolver(EncodeCNF(v), nil)
+	if !x.solve(unboundedSearch()) {
+		t.Fatal("got no model")
+	}
+	var out strings.Builder
+	out.WriteString("s SATISFIABLE\nv")
+	for i, value := range x.assigns {
+		fmt.Fprintf(&out, " %v", int(value)*(i+1))
+	}
+	out.WriteString(" 0\n")
+
+	solution, err := ReadDIMACSModel(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := Solve(v); !slices.Equal(solution, want) {
+		t.Errorf("got solution\n%v\nwant\n%v", Display(solution), Display(want))
+	}
+}
@@ -0,0 +1,57 @@
+package sudoku
+
+// constraint is a rule that applies to some squares on the board, beyond the
+// rule that every unit contains each digit exactly once - for example, the sum
+// of a killer cage. Constraints take part in constraint propagation: whenever
+// a candidate is eliminated from one of the squares of a constraint, the
+// constraint gets a chance to eliminate more candidates.
+type constraint interface {
+	// squares returns the squares the constraint applies to.
+	squares() []Index
+
+	// propagate eliminates candidates that can't satisfy the constraint from
+	// its squares, using g.eliminate to propagate further. It returns false if
+	// a contradiction was found. When all the constraint's squares have a
+	// single candidate, propagate must return false if the constraint isn't
+	// satisfied.
+	propagate(g *Grid, values Values) bool
+
+	// satisfied checks whether the constraint is satisfied by values, where
+	// each of the constraint's squares has a single candidate.
+	satisfied(values Values) bool
+
+	// encode adds clauses to the formula of a board (see EncodeCNF) that are
+	// satisfied by the assignments of digits that satisfy the constraint.
+	// Each square is known to have exactly one digit, and peers different
+	// digits.
+	encode(enc *cnfEncoder)
+}
+
+// propagateConstraints runs propagation for all the constraints of g. It
+// returns false if a contradiction was found.
+func (g *Grid) propagateConstraints(values Values) bool {
+	for _, c := range g.constraints {
+		if !c.propagate(g, values) {
+			return false
+		}
+	}
+	return true
+}
+
+// constraintsSatisfied checks whether all the constraints of g are satisfied
+// by values, which is expected to have a single candidate in every square.
+func (g *Grid) constraintsSatisfied(values Values) bool {
+	for _, c := range g.constraints {
+		if !c.satisfied(values) {
+			return false
+		}
+	}
+	return true
+}
+
+// addConstraint adds c to the constraints of g; it should be called before
+// build, which computes which constraints apply to which square.
+func (g *Grid) addConstraint(c constraint) {
+	// Copy the slice to avoid aliasing with grids g was copied from.
+	g.constraints = append(g.constraints[:len(g.constraints):len(g.constraints)], c)
+}
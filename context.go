@@ -0,0 +1,119 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrNodeBudgetExceeded is the cause of a SearchAbortedError for searches that
+// visited more nodes than their budget allows (see SolveOptions.MaxNodes and
+// GenerateOptions.MaxNodes).
+var ErrNodeBudgetExceeded = errors.New("node budget exceeded")
+
+// SearchAbortedError is returned by the context-aware functions like
+// SolveContext when a search is aborted before it completes, either because
+// its context is done or because it exceeded its node budget.
+type SearchAbortedError struct {
+	// Cause is the reason the search was aborted: the context's error (e.g.
+	// context.DeadlineExceeded), or ErrNodeBudgetExceeded.
+	Cause error
+
+	// Nodes is the number of search nodes visited before the search was
+	// aborted.
+	Nodes uint64
+}
+
+func (e *SearchAbortedError) Error() string {
+	return fmt.Sprintf("search aborted after %v nodes: %v", e.Nodes, e.Cause)
+}
+
+func (e *SearchAbortedError) Unwrap() error {
+	return e.Cause
+}
+
+// searchState is shared by all the nodes of a search (or a sequence of
+// searches, like the ones done by the generator), for aborting it when its
+// context is done or when it exceeds its node budget, and for collecting its
+// statistics.
+type searchState struct {
+	ctx  context.Context
+	done <-chan struct{}
+
+	// maxNodes is the node budget; 0 means no limit. The number of nodes
+	// visited so far is stats.NumSearches, or sharedNodes for the workers of
+	// a parallel search, which share the budget.
+	maxNodes    uint64
+	sharedNodes *atomic.Uint64
+
+	// err is set when the search is aborted; from that point on, every
+	// visit fails.
+	err error
+
+	// depth is the number of nodes on the current search path.
+	depth int
+	stats SolveStats
+}
+
+func newSearchState(ctx context.Context, maxNodes uint64) *searchState {
+	return &searchState{ctx: ctx, done: ctx.Done(), maxNodes: maxNodes}
+}
+
+// unboundedSearch returns a state for searches that are never aborted.
+func unboundedSearch() *searchState {
+	return newSearchState(context.Background(), 0)
+}
+
+// visit counts a search node, and returns false if the search has to be
+// aborted. Otherwise the node is entered, and the caller has to call leave
+// when it's done with it.
+func (s *searchState) visit() bool {
+	if s.err != nil {
+		return false
+	}
+	nodes := s.stats.NumSearches
+	if s.sharedNodes != nil {
+		nodes = s.sharedNodes.Load()
+	}
+	if s.maxNodes > 0 && nodes >= s.maxNodes {
+		s.err = &SearchAbortedError{Cause: ErrNodeBudgetExceeded, Nodes: nodes}
+		return false
+	}
+	if s.done != nil {
+		select {
+		case <-s.done:
+			s.err = &SearchAbortedError{Cause: s.ctx.Err(), Nodes: nodes}
+			return false
+		default:
+		}
+	}
+	s.stats.NumSearches++
+	if s.sharedNodes != nil {
+		s.sharedNodes.Add(1)
+	}
+	s.depth++
+	s.stats.MaxDepth = max(s.stats.MaxDepth, s.depth)
+	return true
+}
+
+// leave leaves the search node entered by the last successful visit.
+func (s *searchState) leave() {
+	s.depth--
+}
+
+// addStats adds the statistics of another search, which is part of s, to the
+// statistics of s.
+func (s *searchState) addStats(stats SolveStats) {
+	s.stats.NumSearches += stats.NumSearches
+	s.stats.NumAssigns += stats.NumAssigns
+	s.stats.NumEliminations += stats.NumEliminations
+	s.stats.NumBacktracks += stats.NumBacktracks
+	s.stats.MaxDepth = max(s.stats.MaxDepth, stats.MaxDepth)
+}
+
+// backtrack counts a guess the search undid, to try another candidate or to
+// return to the previous node.
+func (s *searchState) backtrack() {
+	s.stats.NumBacktracks++
+}
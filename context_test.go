@@ -0,0 +1,130 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSolveContext(t *testing.T) {
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	vs, solved, err := SolveContext(context.Background(), v, SolveOptions{MaxNodes: 1000})
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+	if !solved || !IsSolved(vs) {
+		t.Errorf("got unsolved board")
+	}
+}
+
+func TestSolveContextBudget(t *testing.T) {
+	v, err := ParseBoard(impossible, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	vcopy := slices.Clone(v)
+
+	vs, solved, err := SolveContext(context.Background(), v, SolveOptions{MaxNodes: 100})
+	if solved {
+		t.Errorf("got solved, want aborted")
+	}
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Fatalf("got error %v, want ErrNodeBudgetExceeded", err)
+	}
+	var aborted *SearchAbortedError
+	if !errors.As(err, &aborted) || aborted.Nodes != 100 {
+		t.Errorf("got error %#v, want SearchAbortedError after 100 nodes", err)
+	}
+	if !slices.Equal(vs, vcopy) || !slices.Equal(v, vcopy) {
+		t.Errorf("got modified values")
+	}
+}
+
+func TestSolveContextCancel(t *testing.T) {
+	v, err := ParseBoard(impossible, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, solved, err := SolveContext(ctx, v)
+	if solved || !errors.Is(err, context.Canceled) {
+		t.Errorf("got solved=%v err=%v, want context.Canceled", solved, err)
+	}
+
+	// The impossible board takes seconds to solve, so this search hits the
+	// deadline.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, solved, err = SolveContext(ctx, v)
+	if solved || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got solved=%v err=%v, want context.DeadlineExceeded", solved, err)
+	}
+}
+
+func TestSolveAllContextPartial(t *testing.T) {
+	// The empty board has a huge number of solutions; the budget runs out long
+	// before they are all found, and we get the ones found so far.
+	v := EmptyBoard()
+	vs, err := SolveAllContext(context.Background(), v, -1, SolveOptions{MaxNodes: 2000})
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Fatalf("got error %v, want ErrNodeBudgetExceeded", err)
+	}
+	if len(vs) == 0 {
+		t.Errorf("got no partial solutions")
+	}
+	for _, v := range vs {
+		if !IsSolved(v) {
+			t.Errorf("got unsolved board %v", v)
+		}
+	}
+
+	// With a max, the search completes within the budget.
+	vs, err = SolveAllContext(context.Background(), v, 10, SolveOptions{MaxNodes: 2000})
+	if err != nil || len(vs) < 10 {
+		t.Errorf("got %v solutions and error %v, want at least 10 and nil", len(vs), err)
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	board, err := GenerateContext(context.Background(), 30, GenerateOptions{MaxNodes: 1000000})
+	if err != nil {
+		t.Fatalf("got error %v, want nil", err)
+	}
+	if vs := SolveAll(board, -1); len(vs) != 1 {
+		t.Errorf("got %v solutions, want 1", len(vs))
+	}
+
+	// A low hint count and a small budget: the board generated so far is
+	// returned, and it still has a single solution.
+	board, err = GenerateContext(context.Background(), 17, GenerateOptions{MaxNodes: 100})
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Fatalf("got error %v, want ErrNodeBudgetExceeded", err)
+	}
+	if vs := SolveAll(board, -1); len(vs) != 1 {
+		t.Errorf("got %v solutions, want 1", len(vs))
+	}
+	hints := 0
+	for _, d := range board {
+		if d.Size() == 1 {
+			hints++
+		}
+	}
+	if hints <= 17 {
+		t.Errorf("got %v hints, want more than 17", hints)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	board, err = GenerateSymmetricalContext(ctx, 30)
+	if board != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("got board %v and error %v, want nil and context.Canceled", board, err)
+	}
+}
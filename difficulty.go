@@ -1,8 +1,9 @@
 package sudoku
 
 import (
+	"context"
 	"fmt"
-
+	"math/rand"
 	"slices"
 )
 
@@ -22,63 +23,97 @@ import (
 //
 // This approach was partially inspired by the paper "Sudoku Puzzles Generating:
 // from Easy to Evil" by Xiang-Sun ZHANG's research group.
-func EvaluateDifficulty(values Values) (float64, error) {
-	hintsBeforeElimination := CountHints(values)
+func EvaluateDifficulty(values Values, options ...DifficultyOptions) (float64, error) {
+	return standard.EvaluateDifficulty(values, options...)
+}
 
-	// Count the lower bound (minimal number) of hints in individual rows and
-	// cols, pre elimination.
-	minHints := 9
+// DifficultyOptions is a container of options for the EvaluateDifficulty
+// function.
+type DifficultyOptions struct {
+	// Solver runs the searches whose guesses are counted. It should randomize
+	// its searches, since their guesses are averaged. The default is a
+	// randomized solver with the propagation backend, for which the heuristics
+	// were tuned; other backends guess at different rates, so they rate boards
+	// differently. The solvers created by NewSolver can be shared with other
+	// goroutines.
+	Solver Solver
+
+	// Rand is the source of randomness of the default solver, for reproducible
+	// difficulty scores; if it's nil, the global source of math/rand is used.
+	// It's ignored if Solver is set.
+	Rand *rand.Rand
+}
 
-	index := func(row, col int) Index {
-		return row*9 + col
+// EvaluateDifficulty is the equivalent of the package-level EvaluateDifficulty
+// for boards of grid g. The heuristics were tuned for 9x9 boards; for other
+// grids, hint counts are scaled proportionally to the number of squares (and
+// the number of squares in a row).
+func (g *Grid) EvaluateDifficulty(values Values, options ...DifficultyOptions) (float64, error) {
+	if len(options) > 1 {
+		panic("EvaluateDifficulty cannot accept more than a single DifficultyOptions")
 	}
+	var opts DifficultyOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	solver := opts.Solver
+	if solver == nil {
+		solver = g.NewSolver(SolveOptions{Randomize: true, Rand: opts.Rand})
+	}
+
+	hintsBeforeElimination := g.scaleHints(CountHints(values))
+
+	// Count the lower bound (minimal number) of hints in individual rows and
+	// cols, pre elimination.
+	minHints := g.size
 
-	// ... first the rows.
-	for row := 0; row < 9; row++ {
-		rowCount := 0
-		for col := 0; col < 9; col++ {
-			if values[index(row, col)].Size() == 1 {
-				rowCount++
+	// ... first the rows, then the columns, of every subgrid.
+	for _, sub := range g.subgrids {
+		for row := 0; row < g.size; row++ {
+			rowCount := 0
+			for col := 0; col < g.size; col++ {
+				if values[g.index(sub[0]+row, sub[1]+col)].Size() == 1 {
+					rowCount++
+				}
+			}
+			if rowCount < minHints {
+				minHints = rowCount
 			}
 		}
-		if rowCount < minHints {
-			minHints = rowCount
-		}
-	}
 
-	// ... then the columns.
-	for col := 0; col < 9; col++ {
-		colCount := 0
-		for row := 0; row < 9; row++ {
-			if values[index(row, col)].Size() == 1 {
-				colCount++
+		for col := 0; col < g.size; col++ {
+			colCount := 0
+			for row := 0; row < g.size; row++ {
+				if values[g.index(sub[0]+row, sub[1]+col)].Size() == 1 {
+					colCount++
+				}
+			}
+			if colCount < minHints {
+				minHints = colCount
 			}
-		}
-		if colCount < minHints {
-			minHints = colCount
 		}
 	}
 
 	// Run elimination and count how many hints are on the board after it.
 	vcopy := slices.Clone(values)
-	if !EliminateAll(vcopy) {
+	if !g.EliminateAll(vcopy) {
 		return 0, fmt.Errorf("contradiction in board")
 	}
-	hintsAfterElimination := CountHints(vcopy)
+	hintsAfterElimination := g.scaleHints(CountHints(vcopy))
 
 	// Run a number of randomized searches and count the average search count.
-	EnableStats = true
-	var totalSearches uint64 = 0
+	var totalSearches uint64
 	iterations := 10
 	for i := 0; i < iterations; i++ {
-		Stats.Reset()
-		_, solved := Solve(vcopy, SolveOptions{Randomize: true})
+		solved, searches, err := countSearches(solver, vcopy)
+		if err != nil {
+			return 0, err
+		}
 		if !solved {
 			return 0, fmt.Errorf("cannot solve")
 		}
-		totalSearches += Stats.NumSearches
+		totalSearches += searches
 	}
-	EnableStats = false
 	averageSearches := float64(totalSearches) / float64(iterations)
 
 	// Assign difficulty scores based on ranges in each category.
@@ -108,6 +143,9 @@ func EvaluateDifficulty(values Values) (float64, error) {
 		hintsAfterDifficulty = 5.0
 	}
 
+	// Scale the row/column hint count to a 9-square row.
+	minHints = minHints * 9 / g.size
+
 	var minHintsDifficulty float64
 	if minHints >= 5 {
 		minHintsDifficulty = 1.0
@@ -142,3 +180,23 @@ func EvaluateDifficulty(values Values) (float64, error) {
 
 	return difficulty, nil
 }
+
+// countSearches solves values with sv, and returns the number of searches it
+// took. The solvers created by NewSolver report the statistics of each
+// search; for other implementations of Solver, the searches are counted with
+// their Stats method, which is only accurate if nothing else uses them.
+func countSearches(sv Solver, values Values) (bool, uint64, error) {
+	if ps, ok := sv.(*solver); ok {
+		_, solved, stats, err := ps.solve(context.Background(), values)
+		return solved, stats.NumSearches, err
+	}
+	searchesBefore := sv.Stats().NumSearches
+	_, solved, err := sv.Solve(context.Background(), values)
+	return solved, sv.Stats().NumSearches - searchesBefore, err
+}
+
+// scaleHints scales a count of hints on a board of grid g to the equivalent
+// count on a 9x9 board.
+func (g *Grid) scaleHints(hints int) int {
+	return hints * 81 / g.NumSquares()
+}
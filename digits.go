@@ -1,27 +1,33 @@
 package sudoku
 
 import (
-	"fmt"
 	"math/bits"
 	"strings"
 )
 
 // Digits represents a set of possible digits for a Sudoku square. The functions
 // in this file perform set operations on Digits, as needed for Sudoku.
-type Digits uint16
+// Digits can hold up to 16 digits, enough for the largest supported grid.
+type Digits uint32
 
 // Internal representation: digit N is represented by the Nth lowest bit in
 // the Digits value, e.g.:
 //
-//    Digits = 0b0000_0000_0110_0010
+//    Digits = 0b0000_0000_0000_0000_0000_0000_0110_0010
 //
 // has the bits N=1,5,6 are set, so it represents the set of digits {1, 5, 6}
 
-// FullDigitsSet returns a Digits with all possible digits set.
+// FullDigitsSet returns a Digits with all possible digits of the standard
+// 9x9 grid set.
 func FullDigitsSet() Digits {
 	return 0b0000001111111110
 }
 
+// fullDigitsSetOfSize returns a Digits with all digits 1..size set.
+func fullDigitsSetOfSize(size int) Digits {
+	return Digits(1)<<(size+1) - 2
+}
+
 // SingleDigitSet returns a Digits with a single digit 'n' set.
 func SingleDigitSet(n uint16) Digits {
 	return 1 << n
@@ -50,30 +56,31 @@ func (d Digits) RemoveAll(dn Digits) Digits {
 
 // Size returns the size of the set - the number of digits in it.
 func (d Digits) Size() int {
-	return bits.OnesCount16(uint16(d))
+	return bits.OnesCount32(uint32(d))
 }
 
 // SingleMemberDigit returns the digit that's a member of a 1-element set; this
 // assumes that the set indeed has a single element.
 func (d Digits) SingleMemberDigit() uint16 {
-	return uint16(bits.TrailingZeros16(uint16(d)))
+	return uint16(bits.TrailingZeros32(uint32(d)))
 }
 
 // twoMemberDigits returns the only two digits that are member of a 2-element
 // set; this assumes that the set indeed has two elements.
 func (d Digits) twoMemberDigits() (uint16, uint16) {
-	d1 := uint16(bits.TrailingZeros16(uint16(d)))
-	d2 := 16 - uint16(bits.LeadingZeros16(uint16(d))) - 1
+	d1 := uint16(bits.TrailingZeros32(uint32(d)))
+	d2 := 32 - uint16(bits.LeadingZeros32(uint32(d))) - 1
 	return d1, d2
 }
 
-// String implements the fmt.Stringer interface for Digits.
+// String implements the fmt.Stringer interface for Digits. Digits above 9
+// are represented by letters: A for 10, B for 11 and so on.
 func (d Digits) String() string {
-	var parts []string
-	for i := uint16(1); i <= uint16(9); i++ {
+	var sb strings.Builder
+	for i := uint16(1); i <= uint16(len(digitRunes)); i++ {
 		if d.IsMember(i) {
-			parts = append(parts, fmt.Sprintf("%v", i))
+			sb.WriteByte(digitRunes[i-1])
 		}
 	}
-	return strings.Join(parts, "")
+	return sb.String()
 }
@@ -0,0 +1,407 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Backend selects the search algorithm used to solve boards; see
+// SolveOptions.
+type Backend int
+
+const (
+	// BackendPropagation is the default backend: constraint propagation with a
+	// backtracking search, based on Peter Norvig's solver.
+	BackendPropagation Backend = iota
+
+	// BackendDLX solves boards as an exact cover problem, with Knuth's
+	// Algorithm X implemented with "dancing links". It's often faster than
+	// BackendPropagation for enumerating or counting the solutions of boards
+	// with many of them. The units and peers of the grid are part of the exact
+	// cover problem, but additional constraints (like killer cages or
+	// thermometers) are only checked on complete solutions, so it's slow for
+	// grids that rely on them.
+	BackendDLX
+
+	// BackendSAT encodes boards as SAT formulas (see EncodeCNF), which are
+	// solved with a small built-in CDCL solver. All the constraints of the grid
+	// are part of the formula. It's slower than the other backends, but it's
+	// independent of them, which makes it useful for cross-checking them.
+	BackendSAT
+)
+
+var backendNames = []string{
+	BackendPropagation: "propagation",
+	BackendDLX:         "dlx",
+	BackendSAT:         "sat",
+}
+
+func (b Backend) String() string {
+	if b < 0 || int(b) >= len(backendNames) {
+		return fmt.Sprintf("Backend(%d)", int(b))
+	}
+	return backendNames[b]
+}
+
+// ParseBackend returns the backend with the given name, as returned by its
+// String method (e.g. "dlx").
+func ParseBackend(name string) (Backend, error) {
+	if i := slices.Index(backendNames, name); i >= 0 {
+		return Backend(i), nil
+	}
+	return 0, fmt.Errorf("unknown solver backend %q", name)
+}
+
+// The exact cover problem for a board of grid g has an option (a row of the
+// exact cover matrix) for each candidate digit of each square, and these
+// columns:
+//
+//   - A primary column for each square, since each square has exactly one
+//     digit.
+//   - A primary column for each unit and digit, since each unit has each
+//     digit exactly once.
+//   - A secondary column for each digit and each pair of peers that don't
+//     share a unit (e.g. squares a knight's move apart on anti-knight grids),
+//     since each such pair has each digit at most once.
+//
+// dlx holds the matrix in Knuth's "dancing links" representation: circular
+// doubly-linked lists of the nodes in each column and in each option. Nodes
+// 0..numColumns are the column headers, where node 0 is the root of the list
+// of primary columns; secondary columns aren't on this list, so they don't
+// have to be covered.
+type dlx struct {
+	left, right, up, down []int32
+
+	// col is the column header of each node, and option is the index in
+	// options of the option each node belongs to.
+	col    []int32
+	option []int32
+
+	// size is the number of nodes in each column, indexed by header.
+	size []int32
+
+	// options holds the square and digit of each option, and chosen is the
+	// stack of options chosen by the search.
+	options []dlxOption
+	chosen  []int32
+}
+
+type dlxOption struct {
+	sq    Index
+	digit uint16
+}
+
+// newDLX creates the exact cover problem for the board values.
+func (g *Grid) newDLX(values Values) *dlx {
+	numSquares := g.NumSquares()
+
+	// Find the units of each square, and the peers that don't share a unit.
+	squareUnits := make([][]int, numSquares)
+	for u, unit := range g.unitlist {
+		for _, sq := range unit {
+			squareUnits[sq] = append(squareUnits[sq], u)
+		}
+	}
+	squarePairs := make([][]int, numSquares)
+	numPairs := 0
+	for sq := 0; sq < numSquares; sq++ {
+		for _, peer := range g.peers[sq] {
+			if peer > sq && !slices.ContainsFunc(squareUnits[sq], func(u int) bool {
+				return slices.Contains(squareUnits[peer], u)
+			}) {
+				squarePairs[sq] = append(squarePairs[sq], numPairs)
+				squarePairs[peer] = append(squarePairs[peer], numPairs)
+				numPairs++
+			}
+		}
+	}
+
+	x := &dlx{}
+	numPrimary := numSquares + len(g.unitlist)*g.size
+	x.addNode(0, -1)
+	for c := 1; c <= numPrimary+numPairs*g.size; c++ {
+		x.addColumn(c <= numPrimary)
+	}
+	unitColumn := func(u int, d uint16) int32 {
+		return int32(1 + numSquares + u*g.size + int(d) - 1)
+	}
+	pairColumn := func(p int, d uint16) int32 {
+		return int32(1 + numPrimary + p*g.size + int(d) - 1)
+	}
+
+	var cols []int32
+	for sq, digits := range values {
+		for d := uint16(1); d <= uint16(g.size); d++ {
+			if !digits.IsMember(d) {
+				continue
+			}
+			cols = append(cols[:0], int32(1+sq))
+			for _, u := range squareUnits[sq] {
+				cols = append(cols, unitColumn(u, d))
+			}
+			for _, p := range squarePairs[sq] {
+				cols = append(cols, pairColumn(p, d))
+			}
+			x.addOption(dlxOption{sq: sq, digit: d}, cols)
+		}
+	}
+	return x
+}
+
+// addNode adds a node in column c that's not linked to any other node, and
+// returns it.
+func (x *dlx) addNode(c int32, option int32) int32 {
+	n := int32(len(x.col))
+	x.left = append(x.left, n)
+	x.right = append(x.right, n)
+	x.up = append(x.up, n)
+	x.down = append(x.down, n)
+	x.col = append(x.col, c)
+	x.option = append(x.option, option)
+	x.size = append(x.size, 0)
+	return n
+}
+
+// addColumn adds a column header; primary columns are added at the end of the
+// root's list.
+func (x *dlx) addColumn(primary bool) {
+	c := x.addNode(int32(len(x.col)), -1)
+	if primary {
+		x.left[c] = x.left[0]
+		x.right[c] = 0
+		x.right[x.left[0]] = c
+		x.left[0] = c
+	}
+}
+
+// addOption adds an option with nodes in the given columns.
+func (x *dlx) addOption(opt dlxOption, cols []int32) {
+	option := int32(len(x.options))
+	x.options = append(x.options, opt)
+
+	first := int32(-1)
+	for _, c := range cols {
+		n := x.addNode(c, option)
+		x.size[c]++
+
+		// Link at the bottom of the column.
+		x.up[n] = x.up[c]
+		x.down[n] = c
+		x.down[x.up[c]] = n
+		x.up[c] = n
+
+		// Link at the end of the option.
+		if first < 0 {
+			first = n
+		} else {
+			x.left[n] = x.left[first]
+			x.right[n] = first
+			x.right[x.left[first]] = n
+			x.left[first] = n
+		}
+	}
+}
+
+// cover removes column c from the root's list, and the options that have a
+// node in c from all the other columns.
+func (x *dlx) cover(c int32) {
+	x.right[x.left[c]] = x.right[c]
+	x.left[x.right[c]] = x.left[c]
+	for i := x.down[c]; i != c; i = x.down[i] {
+		for j := x.right[i]; j != i; j = x.right[j] {
+			x.down[x.up[j]] = x.down[j]
+			x.up[x.down[j]] = x.up[j]
+			x.size[x.col[j]]--
+		}
+	}
+}
+
+// uncover undoes cover(c).
+func (x *dlx) uncover(c int32) {
+	for i := x.up[c]; i != c; i = x.up[i] {
+		for j := x.left[i]; j != i; j = x.left[j] {
+			x.size[x.col[j]]++
+			x.down[x.up[j]] = j
+			x.up[x.down[j]] = j
+		}
+	}
+	x.right[x.left[c]] = c
+	x.left[x.right[c]] = c
+}
+
+// search runs Algorithm X, calling found for every exact cover, with the
+// chosen options in x.chosen. It returns false if the search should stop,
+// either because found returned false or because it was aborted.
+func (x *dlx) search(s *searchState, rng *rand.Rand, found func() bool) bool {
+	if x.right[0] == 0 {
+		return found()
+	}
+
+	// If the column with the fewest options has none, there's no exact cover.
+	c := x.chooseColumn()
+	if x.size[c] == 0 {
+		return true
+	}
+
+	// Like the propagation search, only count the nodes where we have to guess.
+	guess := x.size[c] > 1
+	if guess {
+		if !s.visit() {
+			return false
+		}
+		defer s.leave()
+	}
+
+	rows := make([]int32, 0, x.size[c])
+	for r := x.down[c]; r != c; r = x.down[r] {
+		rows = append(rows, r)
+	}
+	if rng != nil {
+		rng.Shuffle(len(rows), func(i, j int) {
+			rows[i], rows[j] = rows[j], rows[i]
+		})
+	}
+
+	x.cover(c)
+	for _, r := range rows {
+		x.chosen = append(x.chosen, x.option[r])
+		for j := x.right[r]; j != r; j = x.right[j] {
+			x.cover(x.col[j])
+		}
+		ok := x.search(s, rng, found)
+		for j := x.left[r]; j != r; j = x.left[j] {
+			x.uncover(x.col[j])
+		}
+		x.chosen = x.chosen[:len(x.chosen)-1]
+		if !ok {
+			x.uncover(c)
+			return false
+		}
+		if guess {
+			s.backtrack()
+		}
+	}
+	x.uncover(c)
+	return true
+}
+
+// chooseColumn returns the primary column with the fewest options, which has
+// to be covered next.
+func (x *dlx) chooseColumn() int32 {
+	c := x.right[0]
+	for i := x.right[c]; i != 0 && x.size[c] > 0; i = x.right[i] {
+		if x.size[i] < x.size[c] {
+			c = i
+		}
+	}
+	return c
+}
+
+// split is like search, but it calls found for the partial covers where the
+// search has made depth guesses instead of going deeper, as well as for the
+// exact covers found with fewer guesses. guesses is the number of guesses made
+// so far. It returns false if found stopped it.
+func (x *dlx) split(s *searchState, guesses, depth int, found func(guesses int) bool) bool {
+	if x.right[0] == 0 {
+		return found(guesses)
+	}
+	c := x.chooseColumn()
+	if x.size[c] == 0 {
+		return true
+	}
+	guess := x.size[c] > 1
+	if guess {
+		if guesses == depth {
+			return found(guesses)
+		}
+		s.visit()
+		defer s.leave()
+		guesses++
+	}
+
+	x.cover(c)
+	defer x.uncover(c)
+	for r := x.down[c]; r != c; r = x.down[r] {
+		x.chosen = append(x.chosen, x.option[r])
+		for j := x.right[r]; j != r; j = x.right[j] {
+			x.cover(x.col[j])
+		}
+		ok := x.split(s, guesses, depth, found)
+		for j := x.left[r]; j != r; j = x.left[j] {
+			x.uncover(x.col[j])
+		}
+		x.chosen = x.chosen[:len(x.chosen)-1]
+		if !ok {
+			return false
+		}
+		if guess {
+			s.backtrack()
+		}
+	}
+	return true
+}
+
+// dlxEngine is the engine of BackendDLX.
+type dlxEngine struct {
+	g *Grid
+}
+
+func (e dlxEngine) solve(s *searchState, values Values, rng *rand.Rand) (Values, bool) {
+	vresult, solved := values, false
+	e.search(s, values, rng, func(solution Values) bool {
+		vresult, solved = solution, true
+		return false
+	})
+	return vresult, solved
+}
+
+func (e dlxEngine) enumerate(s *searchState, values Values, yield func(Values) bool) bool {
+	return e.search(s, values, nil, yield)
+}
+
+func (e dlxEngine) count(s *searchState, values Values, limit int) int {
+	if s == nil {
+		s = unboundedSearch()
+	}
+	count := 0
+	e.search(s, values, nil, func(Values) bool {
+		count++
+		return limit <= 0 || count < limit
+	})
+	return count
+}
+
+// split splits the search of values for a parallel search, assigning the
+// options chosen by the search to the squares of each subproblem. Since the
+// DLX search of a subproblem starts with the same options, it continues like
+// the search of values would.
+func (e dlxEngine) split(s *searchState, values Values, depth int, yield func(subproblem) bool) bool {
+	x := e.g.newDLX(values)
+	return x.split(s, 0, depth, func(guesses int) bool {
+		sub := subproblem{values: slices.Clone(values), depth: guesses}
+		for _, option := range x.chosen {
+			opt := x.options[option]
+			sub.values[opt.sq] = SingleDigitSet(opt.digit)
+		}
+		return yield(sub)
+	})
+}
+
+// search solves the exact cover problem of values, and calls yield with each
+// solution that satisfies the additional constraints of the grid. It returns
+// false if the search was stopped.
+func (e dlxEngine) search(s *searchState, values Values, rng *rand.Rand, yield func(Values) bool) bool {
+	x := e.g.newDLX(values)
+	return x.search(s, rng, func() bool {
+		solution := slices.Clone(values)
+		for _, option := range x.chosen {
+			opt := x.options[option]
+			solution[opt.sq] = SingleDigitSet(opt.digit)
+		}
+		if !e.g.constraintsSatisfied(solution) {
+			return true
+		}
+		return yield(solution)
+	})
+}
@@ -0,0 +1,217 @@
+package sudoku
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var dlxOptions = SolveOptions{Backend: BackendDLX}
+
+// readInputBoards reads the boards from a file in the inputs directory, one
+// per line, ignoring empty lines and lines starting with '#'.
+func readInputBoards(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var boards []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		board := strings.TrimSpace(scanner.Text())
+		if len(board) == 0 || strings.HasPrefix(board, "#") {
+			continue
+		}
+		boards = append(boards, board)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return boards
+}
+
+// checkBackendsAgree checks that the propagation, DLX and SAT backends find
+// the same solutions for the board values of grid g.
+func checkBackendsAgree(t *testing.T, g *Grid, values Values) {
+	t.Helper()
+	vcopy := slices.Clone(values)
+
+	count := g.CountSolutions(values, 10)
+	vs, solved := g.Solve(values)
+	for _, backend := range []Backend{BackendDLX, BackendSAT} {
+		options := SolveOptions{Backend: backend}
+		if n := g.CountSolutions(values, 10, options); n != count {
+			t.Errorf("got %v solutions with %v, want %v", n, backend, count)
+		}
+
+		backendVs, backendSolved := g.Solve(values, options)
+		if backendSolved != solved {
+			t.Fatalf("got solved=%v with %v, want %v", backendSolved, backend, solved)
+		}
+		if solved {
+			if !g.IsSolved(backendVs) {
+				t.Errorf("got unsolved board with %v:\n%v", backend, g.Display(backendVs))
+			}
+			for sq, d := range values {
+				if backendVs[sq]&^d != 0 {
+					t.Errorf("%v solution has %v in square %v, want one of %v", backend, backendVs[sq], sq, d)
+				}
+			}
+			if count == 1 && !slices.Equal(vs, backendVs) {
+				t.Errorf("got different solutions with %v for board with a single solution", backend)
+			}
+		}
+
+		if !slices.Equal(values, vcopy) {
+			t.Errorf("%v modified its input values", backend)
+		}
+	}
+}
+
+func TestDLXInputs(t *testing.T) {
+	paths, err := filepath.Glob("inputs/*.txt")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no input files: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if testing.Short() && strings.Contains(path, "hardlong") {
+				t.Skip("skipping slow board in short mode.")
+			}
+			for _, board := range readInputBoards(t, path) {
+				v, err := ParseBoard(board, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// The propagation solver needs eliminated boards, but DLX doesn't;
+				// it should find the same solutions either way.
+				dlxCount := CountSolutions(v, 10, dlxOptions)
+				dlxVs, dlxSolved := Solve(v, dlxOptions)
+				if !EliminateAll(v) {
+					t.Fatalf("contradiction in board %v", board)
+				}
+				if n := CountSolutions(v, 10, dlxOptions); n != dlxCount {
+					t.Errorf("got %v solutions for eliminated board, %v for uneliminated", n, dlxCount)
+				}
+				if vs, solved := Solve(v, dlxOptions); solved != dlxSolved || (dlxCount == 1 && !slices.Equal(vs, dlxVs)) {
+					t.Errorf("got different solutions for eliminated and uneliminated board")
+				}
+				checkBackendsAgree(t, standard, v)
+			}
+		})
+	}
+}
+
+func TestDLXCount(t *testing.T) {
+	// A 4x4 grid has 288 solutions.
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := g.CountSolutions(g.EmptyBoard(), -1, dlxOptions); n != 288 {
+		t.Errorf("got %v solutions, want 288", n)
+	}
+	vs := g.SolveAll(g.EmptyBoard(), -1)
+	dlxVs, err := g.SolveAllContext(context.Background(), g.EmptyBoard(), -1, dlxOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range dlxVs {
+		if !slices.ContainsFunc(vs, func(w Values) bool { return slices.Equal(v, w) }) {
+			t.Errorf("DLX solution not found by propagation:\n%v", g.Display(v))
+		}
+	}
+
+	// A contradiction: the same digit twice in a row.
+	v, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	v[1] = v[0]
+	if n := CountSolutions(v, -1, dlxOptions); n != 0 {
+		t.Errorf("got %v solutions, want 0", n)
+	}
+}
+
+func TestDLXVariants(t *testing.T) {
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := jg.ParseBoard(jigsawBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBackendsAgree(t, jg, v)
+
+	tg, err := standard.ParseThermometers(thermoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thg, err := standard.WithThermometers(tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = thg.ParseBoard(thermoBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBackendsAgree(t, thg, v)
+
+	sg := NewSamurai()
+	v, err = sg.ParseBoard(samuraiBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBackendsAgree(t, sg, v)
+
+	// Anti-knight and Sudoku-X boards are generated, and then some of their
+	// hints removed so they have multiple solutions.
+	for _, g := range []*Grid{standard.WithAntiKnight(), standard.WithDiagonals().WithAntiKing()} {
+		board := g.Generate(30)
+		checkBackendsAgree(t, g, board)
+		removed := 0
+		for sq := range board {
+			if board[sq].Size() == 1 && removed < 4 {
+				board[sq] = g.full
+				removed++
+			}
+		}
+		checkBackendsAgree(t, g, board)
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, b := range []Backend{BackendPropagation, BackendDLX, BackendSAT} {
+		got, err := ParseBackend(b.String())
+		if err != nil || got != b {
+			t.Errorf("ParseBackend(%q) = %v, %v; want %v", b.String(), got, err, b)
+		}
+	}
+	if _, err := ParseBackend("magic"); err == nil {
+		t.Errorf("got no error for unknown backend")
+	}
+}
+
+func BenchmarkCountSolutions(b *testing.B) {
+	// hardlong has a large number of solutions.
+	v, err := ParseBoard(hardlong, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, backend := range []Backend{BackendPropagation, BackendDLX, BackendSAT} {
+		b.Run(backend.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CountSolutions(v, 100, SolveOptions{Backend: backend})
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package sudoku
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+	"sync"
+)
+
+// fastSquares is the number of squares on the grids the fast solver handles.
+const fastSquares = 81
+
+// fastGrid is the precomputed geometry of a 9x9 grid without additional
+// constraints (though it may have variant units and peers, like Sudoku-X or
+// jigsaw regions), for the fast solver. The fast solver solves boards the same
+// way as the propagation solver, but it keeps the board in a fixed-size array
+// and backtracks by undoing changes recorded on a trail, instead of copying
+// boards; with the peers of each square, its units and the places of each
+// digit in bitmasks, this avoids memory allocations during the search.
+type fastGrid struct {
+	// peers[sq] is the set of the peers of sq.
+	peers [fastSquares]squareSet
+
+	// units[sq] are the sets of the squares of the units that contain sq.
+	units [fastSquares][]squareSet
+}
+
+// squareSet is a set of squares of a 9x9 grid, with bit sq%64 of word sq/64
+// set for each square sq in the set.
+type squareSet [2]uint64
+
+// and returns the intersection of ss and other.
+func (ss squareSet) and(other squareSet) squareSet {
+	return squareSet{ss[0] & other[0], ss[1] & other[1]}
+}
+
+// empty checks whether ss has no squares.
+func (ss squareSet) empty() bool {
+	return ss[0]|ss[1] == 0
+}
+
+// single checks whether ss has exactly one square.
+func (ss squareSet) single() bool {
+	if ss[0] != 0 {
+		return ss[0]&(ss[0]-1) == 0 && ss[1] == 0
+	}
+	return ss[1] != 0 && ss[1]&(ss[1]-1) == 0
+}
+
+// first returns the smallest square in ss, which must not be empty.
+func (ss squareSet) first() int {
+	if ss[0] != 0 {
+		return bits.TrailingZeros64(ss[0])
+	}
+	return 64 + bits.TrailingZeros64(ss[1])
+}
+
+// newFastGrid creates the fastGrid for g, or returns nil if g isn't supported
+// by the fast solver.
+func newFastGrid(g *Grid) *fastGrid {
+	if g.size != 9 || g.NumSquares() != fastSquares || len(g.constraints) > 0 {
+		return nil
+	}
+	fg := &fastGrid{}
+	for sq := 0; sq < fastSquares; sq++ {
+		for _, peer := range g.peers[sq] {
+			fg.peers[sq][peer/64] |= 1 << (peer % 64)
+		}
+		for _, unit := range g.units[sq] {
+			var set squareSet
+			for _, usq := range unit {
+				set[usq/64] |= 1 << (usq % 64)
+			}
+			fg.units[sq] = append(fg.units[sq], set)
+		}
+	}
+	return fg
+}
+
+// fastSolver is the state of a search of the fast solver: the board and the
+// trail of changes made to it.
+type fastSolver struct {
+	fg    *fastGrid
+	board [fastSquares]Digits
+	trail []trailEntry
+
+	// places[d] is the set of the squares of the board with candidate d.
+	places [10]squareSet
+
+	// s is the state of the search; unbounded is used for searches that are
+	// never aborted. stats points to the statistics of s.
+	s         *searchState
+	unbounded searchState
+	stats     *SolveStats
+}
+
+// trailEntry records the candidates square sq had before it was changed.
+type trailEntry struct {
+	sq  uint8
+	old Digits
+}
+
+// fastSolverPool holds fastSolvers for reuse between searches, so they don't
+// have to reallocate their trails.
+var fastSolverPool = sync.Pool{
+	New: func() any {
+		return &fastSolver{trail: make([]trailEntry, 0, 1024)}
+	},
+}
+
+// getFastSolver gets a fastSolver from the pool for a search with state s,
+// loaded with values; if s is nil, the search is never aborted. It
+// should be returned with putFastSolver when it's no longer needed.
+func (fg *fastGrid) getFastSolver(s *searchState, values Values) *fastSolver {
+	f := fastSolverPool.Get().(*fastSolver)
+	f.fg = fg
+	if s == nil {
+		f.unbounded = searchState{}
+		s = &f.unbounded
+	}
+	f.s, f.stats = s, &s.stats
+	f.trail = f.trail[:0]
+	f.board, f.places = [fastSquares]Digits{}, [10]squareSet{}
+	for sq, d := range values {
+		f.update(sq, d)
+	}
+	return f
+}
+
+func putFastSolver(f *fastSolver) {
+	f.fg, f.s, f.stats = nil, nil, nil
+	fastSolverPool.Put(f)
+}
+
+// set sets the candidates of sq to d, recording the old candidates on the
+// trail.
+func (f *fastSolver) set(sq int, d Digits) {
+	f.trail = append(f.trail, trailEntry{sq: uint8(sq), old: f.board[sq]})
+	f.update(sq, d)
+}
+
+// update sets the candidates of sq to d, and updates places for the digits
+// that changed.
+func (f *fastSolver) update(sq int, d Digits) {
+	bit := uint64(1) << (sq % 64)
+	for changed := f.board[sq] ^ d; changed != 0; changed &= changed - 1 {
+		f.places[bits.TrailingZeros32(uint32(changed))][sq/64] ^= bit
+	}
+	f.board[sq] = d
+}
+
+// undo undoes the changes to the board since the trail had length mark.
+func (f *fastSolver) undo(mark int) {
+	for i := len(f.trail) - 1; i >= mark; i-- {
+		f.update(int(f.trail[i].sq), f.trail[i].old)
+	}
+	f.trail = f.trail[:mark]
+}
+
+// assign is the fast solver's equivalent of Grid.assign.
+func (f *fastSolver) assign(sq int, digit uint16) bool {
+	f.stats.NumAssigns++
+
+	others := f.board[sq].Remove(digit)
+	for others != 0 {
+		d := uint16(bits.TrailingZeros32(uint32(others)))
+		others = others.Remove(d)
+		if !f.eliminate(sq, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// eliminate is the fast solver's equivalent of Grid.eliminate.
+func (f *fastSolver) eliminate(sq int, digit uint16) bool {
+	if !f.board[sq].IsMember(digit) {
+		return true
+	}
+	f.set(sq, f.board[sq].Remove(digit))
+	f.stats.NumEliminations++
+
+	switch f.board[sq].Size() {
+	case 0:
+		return false
+	case 1:
+		// Only the peers with the remaining digit have to be visited; the ones
+		// that lose it in the meantime are skipped by eliminate.
+		remaining := f.board[sq].SingleMemberDigit()
+		for w, word := range f.fg.peers[sq].and(f.places[remaining]) {
+			for word != 0 {
+				peer := w*64 + bits.TrailingZeros64(word)
+				word &= word - 1
+				if !f.eliminate(peer, remaining) {
+					return false
+				}
+			}
+		}
+	}
+
+	for _, unit := range f.fg.units[sq] {
+		places := unit.and(f.places[digit])
+		if places.empty() {
+			return false
+		}
+		if places.single() && !f.assign(places.first(), digit) {
+			return false
+		}
+	}
+	return true
+}
+
+// eliminateAll is the fast solver's equivalent of Grid.EliminateAll.
+func (f *fastSolver) eliminateAll() bool {
+	for sq, d := range &f.board {
+		if d.Size() == 1 {
+			digit := d.SingleMemberDigit()
+			f.update(sq, fullDigits9)
+			for dn := uint16(1); dn <= 9; dn++ {
+				if dn != digit && !f.eliminate(sq, dn) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// fullDigits9 has all the digits of a 9x9 grid.
+const fullDigits9 = Digits(0b1111111110)
+
+// search is the fast solver's equivalent of Grid.enumerate: it calls found
+// for each solution, which is on the board when found is called. It returns
+// false if the search was stopped, either by found or because it was aborted;
+// if found stopped it, the solution remains on the board.
+func (f *fastSolver) search(rng *rand.Rand, found func() bool) bool {
+	squareToTry := -1
+	minSize := 10
+	for sq, d := range &f.board {
+		if size := d.Size(); size > 1 && size < minSize {
+			minSize = size
+			squareToTry = sq
+			if size == 2 {
+				// No square has fewer candidates.
+				break
+			}
+		}
+	}
+	if squareToTry == -1 {
+		return found()
+	}
+
+	if !f.s.visit() {
+		return false
+	}
+	defer f.s.leave()
+
+	candidates := [9]uint16{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	if rng != nil {
+		rng.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+	}
+
+	mark := len(f.trail)
+	for _, d := range candidates {
+		if f.board[squareToTry].IsMember(d) {
+			if f.assign(squareToTry, d) && !f.search(rng, found) {
+				return false
+			}
+			f.undo(mark)
+			f.s.backtrack()
+		}
+	}
+	return true
+}
+
+// solve is the fast solver's equivalent of Grid.search.
+func (fg *fastGrid) solve(s *searchState, values Values, rng *rand.Rand) (Values, bool) {
+	f := fg.getFastSolver(s, values)
+	defer putFastSolver(f)
+
+	solved := false
+	f.search(rng, func() bool {
+		solved = true
+		return false
+	})
+	if !solved {
+		return values, false
+	}
+	return slices.Clone(Values(f.board[:])), true
+}
+
+// enumerate is the fast solver's equivalent of Grid.enumerate; values is not
+// modified.
+func (fg *fastGrid) enumerate(s *searchState, values Values, yield func(Values) bool) bool {
+	f := fg.getFastSolver(s, values)
+	defer putFastSolver(f)
+
+	return f.search(nil, func() bool {
+		return yield(slices.Clone(Values(f.board[:])))
+	})
+}
+
+// count is the fast solver's equivalent of solutionCounter.countSolutions; s
+// may be nil for searches that are never aborted.
+func (fg *fastGrid) count(s *searchState, values Values, limit int) int {
+	f := fg.getFastSolver(s, values)
+	defer putFastSolver(f)
+
+	count := 0
+	if f.eliminateAll() {
+		f.search(nil, func() bool {
+			count++
+			return limit <= 0 || count < limit
+		})
+	}
+	return count
+}
@@ -0,0 +1,112 @@
+package sudoku
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// withoutFast returns a copy of grid g that doesn't use the fast solver.
+func withoutFast(g *Grid) *Grid {
+	ng := *g
+	ng.fast = nil
+	return &ng
+}
+
+// checkFastAgrees checks that the fast solver and the generic propagation
+// solver search the board values of grid g the same way.
+func checkFastAgrees(t *testing.T, g *Grid, values Values) {
+	t.Helper()
+	if g.fast == nil {
+		t.Fatalf("grid doesn't use the fast solver")
+	}
+	generic := withoutFast(g)
+	vcopy := slices.Clone(values)
+
+	// The solvers eliminate peers in a different order, so they may make a
+	// different number of assignments and eliminations, but they guess in the
+	// same places.
+	var stats, genericStats SolveStats
+	vs, solved := g.Solve(values, SolveOptions{Stats: &stats})
+	genericVs, genericSolved := generic.Solve(values, SolveOptions{Stats: &genericStats})
+	if solved != genericSolved || !slices.Equal(vs, genericVs) {
+		t.Errorf("got different solutions from fast and generic solvers")
+	}
+	if stats.NumSearches != genericStats.NumSearches || stats.NumBacktracks != genericStats.NumBacktracks || stats.MaxDepth != genericStats.MaxDepth {
+		t.Errorf("got stats %+v from fast solver, want %+v", stats, genericStats)
+	}
+
+	if n, want := g.CountSolutions(values, 10), generic.CountSolutions(values, 10); n != want {
+		t.Errorf("got %v solutions from fast solver, want %v", n, want)
+	}
+	all, genericAll := g.SolveAll(values, 10), generic.SolveAll(values, 10)
+	if !slices.EqualFunc(all, genericAll, slices.Equal) {
+		t.Errorf("got different solutions from fast and generic SolveAll")
+	}
+
+	if !slices.Equal(values, vcopy) {
+		t.Errorf("fast solver modified its input values")
+	}
+}
+
+func TestFastInputs(t *testing.T) {
+	paths, err := filepath.Glob("inputs/*.txt")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no input files: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if strings.Contains(path, "hardlong") {
+				// TestHardlong solves it; the generic solver is too slow here.
+				t.Skip("skipping slow board.")
+			}
+			for _, board := range readInputBoards(t, path) {
+				v, err := ParseBoard(board, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkFastAgrees(t, standard, v)
+			}
+		})
+	}
+}
+
+func TestFastVariants(t *testing.T) {
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := jg.ParseBoard(jigsawBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFastAgrees(t, jg, v)
+
+	for _, g := range []*Grid{standard.WithDiagonals(), standard.WithAntiKnight()} {
+		checkFastAgrees(t, g, g.EmptyBoard())
+		board := g.Generate(30)
+		checkFastAgrees(t, g, board)
+	}
+
+	// Grids of other sizes, or with additional constraints, use the generic
+	// solver.
+	g4, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g4.fast != nil || NewSamurai().fast != nil {
+		t.Errorf("got fast solver for grid that isn't 9x9")
+	}
+	tg, err := standard.ParseThermometers(thermoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thg, err := standard.WithThermometers(tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thg.fast != nil {
+		t.Errorf("got fast solver for grid with constraints")
+	}
+}
@@ -0,0 +1,312 @@
+package sudoku
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// ApplyFish applies the basic fish strategies to the given board and updates
+// it, for fish of 2 to maxSize base units: X-Wings, Swordfish and Jellyfish,
+// with sizes above 4 taken as 4. It returns the steps it made, and false if
+// there was a contradiction discovered while applying the strategies.
+//
+// A fish of digit d has N rows as its base units, where the candidates of d
+// are all in N columns, its cover units (or the other way around). Each of
+// the rows has d in a different column, so d is placed in each of the
+// columns, and can be eliminated from the rest of them. The steps have the
+// base and cover units of their fish; see Step.FishUnits.
+//
+// Like ApplyNakedSubsets, the eliminations propagate constraints, and the
+// strategies are repeated until they don't change the board.
+func ApplyFish(values Values, maxSize int) ([]Step, bool) {
+	return standard.ApplyFish(values, maxSize)
+}
+
+// ApplyFish is the equivalent of the package-level ApplyFish for boards of
+// grid g.
+func (g *Grid) ApplyFish(values Values, maxSize int) ([]Step, bool) {
+	return g.applyFish(values, maxSize, false)
+}
+
+// ApplyFinnedFish applies the finned and sashimi fish strategies to the given
+// board and updates it, for fish of 2 to maxSize base units (at most 4). It
+// returns the steps it made, and false if there was a contradiction
+// discovered while applying the strategies.
+//
+// A finned fish is like the fish of ApplyFish, except that some of the
+// candidates in its base units, the fins, aren't in its cover units. Either
+// one of the fins has the digit, or the fish without them does, so the digit
+// can only be eliminated from the squares of the cover units that see all the
+// fins. The fins are listed in the steps' Fins. Basic fish, which have no
+// fins, are left to ApplyFish.
+func ApplyFinnedFish(values Values, maxSize int) ([]Step, bool) {
+	return standard.ApplyFinnedFish(values, maxSize)
+}
+
+// ApplyFinnedFish is the equivalent of the package-level ApplyFinnedFish for
+// boards of grid g.
+func (g *Grid) ApplyFinnedFish(values Values, maxSize int) ([]Step, bool) {
+	return g.applyFish(values, maxSize, true)
+}
+
+// FishUnits returns the base units and the cover units of the fish of step,
+// which are its Units.
+func (step *Step) FishUnits() (base, cover []Unit) {
+	n := len(step.Units) / 2
+	return step.Units[:n], step.Units[n:]
+}
+
+// applyFish applies the basic fish strategies to values, or the finned ones if
+// finned is true, for fish of 2 to maxSize base units.
+func (g *Grid) applyFish(values Values, maxSize int, finned bool) ([]Step, bool) {
+	var steps []Step
+	ls := g.strategyState(values)
+	maxSize = min(maxSize, 4)
+	for changed := true; changed; {
+		changed = false
+		for n := 2; n <= maxSize; n++ {
+			for step := ls.findFish(n, finned); step != nil; step = ls.findFish(n, finned) {
+				if !ls.eliminate(step) {
+					return steps, false
+				}
+				steps = append(steps, *step)
+				changed = true
+			}
+		}
+	}
+	return steps, true
+}
+
+// fishLines are the rows or the columns of a grid, as the base or the cover
+// units of fish, whose sets are represented as bit masks of their indices.
+type fishLines struct {
+	units []Unit
+
+	// masks has the set of the units that have each square.
+	masks []uint64
+
+	// overlaps has the set of the other units that overlap each unit.
+	overlaps []uint64
+}
+
+// newFishLines creates the fishLines of units, which are at most 64.
+func (g *Grid) newFishLines(units []Unit) *fishLines {
+	fl := &fishLines{units: units, masks: make([]uint64, g.NumSquares()), overlaps: make([]uint64, len(units))}
+	for i, unit := range units {
+		for _, sq := range unit {
+			fl.masks[sq] |= 1 << i
+		}
+	}
+	for _, m := range fl.masks {
+		for set := m; set != 0; set &= set - 1 {
+			i := bits.TrailingZeros64(set)
+			fl.overlaps[i] |= m &^ (1 << i)
+		}
+	}
+	return fl
+}
+
+// overlapping checks whether some of the units in set overlap.
+func (fl *fishLines) overlapping(set uint64) bool {
+	for s := set; s != 0; s &= s - 1 {
+		if fl.overlaps[bits.TrailingZeros64(s)]&set != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// unitsOf returns the units in set.
+func (fl *fishLines) unitsOf(set uint64) []Unit {
+	var units []Unit
+	for ; set != 0; set &= set - 1 {
+		units = append(units, fl.units[bits.TrailingZeros64(set)])
+	}
+	return units
+}
+
+// findFish finds the steps of fish with n base units, which are finned if
+// finned is true. The units of a fish can't overlap, which only matters on
+// boards with several subgrids.
+//
+// The fins of a finned fish are only looked for in one less line than the
+// width of a box: when the fins are in a box, which is the only place where
+// they have peers in the cover units, at least one line of the box is a cover
+// unit.
+func (ls *logicState) findFish(n int, finned bool) *Step {
+	g := ls.g
+	rows, cols := g.lines()
+	if len(rows) > 64 || len(cols) > 64 {
+		return nil
+	}
+	rowLines, colLines := g.newFishLines(rows), g.newFishLines(cols)
+	limit := n
+	if finned {
+		limit += max(g.boxRows, g.boxCols) - 1
+	}
+	for _, lines := range [][2]*fishLines{{rowLines, colLines}, {colLines, rowLines}} {
+		base, cover := lines[0], lines[1]
+		for d := uint16(1); d <= uint16(g.size); d++ {
+			// The base units with candidates of d, and the cover units of their
+			// candidates.
+			var candidates []int
+			positions := make([][]Index, len(base.units))
+			covers := make([]uint64, len(base.units))
+			for i, unit := range base.units {
+				if ls.placed(unit).IsMember(d) {
+					continue
+				}
+				positions[i] = ls.positions(unit, d)
+				for _, sq := range positions[i] {
+					covers[i] |= cover.masks[sq]
+				}
+				if len(positions[i]) >= 2 && bits.OnesCount64(covers[i]) <= limit {
+					candidates = append(candidates, i)
+				}
+			}
+
+			// Search the sets of n base units whose candidates are in at most limit
+			// cover units.
+			var search func(from, k int, baseSet, coverSet uint64) *Step
+			search = func(from, k int, baseSet, coverSet uint64) *Step {
+				if k == n {
+					f := fish{ls: ls, digit: d, base: base, cover: cover, baseSet: baseSet}
+					for set := baseSet; set != 0; set &= set - 1 {
+						f.squares = append(f.squares, positions[bits.TrailingZeros64(set)]...)
+					}
+					slices.Sort(f.squares)
+					return f.find(n, coverSet, finned)
+				}
+				for j := from; j < len(candidates); j++ {
+					i := candidates[j]
+					if base.overlaps[i]&baseSet != 0 || bits.OnesCount64(coverSet|covers[i]) > limit {
+						continue
+					}
+					if step := search(j+1, k+1, baseSet|1<<i, coverSet|covers[i]); step != nil {
+						return step
+					}
+				}
+				return nil
+			}
+			if step := search(0, 0, 0, 0); step != nil {
+				return step
+			}
+		}
+	}
+	return nil
+}
+
+// fish is a set of base units with candidates of a digit, for finding the
+// fish they're the base of.
+type fish struct {
+	ls          *logicState
+	digit       uint16
+	base, cover *fishLines
+	baseSet     uint64
+	squares     []Index
+}
+
+// find returns the step of a fish of n cover units, or nil if there's no such
+// fish with eliminations. coverSet is the set of the cover units that have
+// the candidates of the base units: a basic fish has n of them; a finned fish
+// has more, and its cover units are chosen among them, leaving the candidates
+// of the others as fins.
+func (f *fish) find(n int, coverSet uint64, finned bool) *Step {
+	if !finned {
+		if bits.OnesCount64(coverSet) != n {
+			return nil
+		}
+		return f.step(coverSet, nil)
+	}
+	var coverIndices []int
+	for set := coverSet; set != 0; set &= set - 1 {
+		coverIndices = append(coverIndices, bits.TrailingZeros64(set))
+	}
+	for indices := range combinations(coverIndices, n) {
+		var set uint64
+		for _, i := range indices {
+			set |= 1 << i
+		}
+		var fins []Index
+		for _, sq := range f.squares {
+			if f.cover.masks[sq]&set == 0 {
+				fins = append(fins, sq)
+			}
+		}
+		if len(fins) == 0 {
+			continue
+		}
+		if step := f.step(set, fins); step != nil {
+			return step
+		}
+	}
+	return nil
+}
+
+// step returns the step of the fish with the cover units in coverSet and the
+// given fins, or nil if it has no eliminations. Each of the base units must
+// have candidates in the cover units, its body.
+func (f *fish) step(coverSet uint64, fins []Index) *Step {
+	ls, g := f.ls, f.ls.g
+	if f.cover.overlapping(coverSet) {
+		return nil
+	}
+	sashimi := false
+	for set := f.baseSet; set != 0; set &= set - 1 {
+		body := 0
+		for _, sq := range f.squares {
+			if f.base.masks[sq]&(set&-set) != 0 && f.cover.masks[sq]&coverSet != 0 {
+				body++
+			}
+		}
+		if body == 0 {
+			return nil
+		}
+		sashimi = sashimi || body == 1
+	}
+
+	// The digit is eliminated from the squares of the cover units outside of
+	// the base units, which also have to see all the fins.
+	eliminated := func(sq Index) bool {
+		return !ls.solved[sq] && ls.values[sq].IsMember(f.digit) &&
+			f.cover.masks[sq]&coverSet != 0 && f.base.masks[sq]&f.baseSet == 0 &&
+			!slices.ContainsFunc(fins, func(fin Index) bool { return !slices.Contains(g.peers[sq], fin) })
+	}
+	var elims []Candidate
+	if fins == nil {
+		for _, unit := range f.cover.unitsOf(coverSet) {
+			for _, sq := range unit {
+				if eliminated(sq) {
+					elims = append(elims, Candidate{sq, f.digit})
+				}
+			}
+		}
+	} else {
+		for _, sq := range g.peers[fins[0]] {
+			if eliminated(sq) {
+				elims = append(elims, Candidate{sq, f.digit})
+			}
+		}
+		slices.SortFunc(elims, func(a, b Candidate) int { return a.Square - b.Square })
+	}
+	if len(elims) == 0 {
+		return nil
+	}
+
+	n := bits.OnesCount64(f.baseSet)
+	technique := XWing + Technique(n-2)
+	if fins != nil {
+		technique = FinnedXWing + Technique(2*(n-2))
+		if sashimi {
+			technique++
+		}
+	}
+	return &Step{
+		Technique:    technique,
+		Digits:       SingleDigitSet(f.digit),
+		Squares:      f.squares,
+		Fins:         fins,
+		Units:        append(f.base.unitsOf(f.baseSet), f.cover.unitsOf(coverSet)...),
+		Eliminations: elims,
+	}
+}
@@ -0,0 +1,113 @@
+package sudoku
+
+import (
+	"slices"
+	"testing"
+)
+
+// xWingBoard has an X-Wing of 7 in the second and sixth rows, and
+// swordfishBoard has a Swordfish of 8 in the first, fifth and seventh columns
+// (after an X-Wing of 7).
+const xWingBoard = "100000569492056108056109240009640801064010000218035604040500016905061402621000005"
+const swordfishBoard = "529410703006003002003200000052300076637050200190627530300069420200830600960742305"
+
+func TestApplyFish(t *testing.T) {
+	v, err := ParseBoard(xWingBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, ok := ApplyFish(v, 4)
+	if !ok || len(steps) != 1 {
+		t.Fatalf("got %v steps and ok=%v, want one X-Wing", len(steps), ok)
+	}
+	step := steps[0]
+	base, cover := step.FishUnits()
+	if step.Technique != XWing || step.Digits != SingleDigitSet(7) || !slices.Equal(step.Squares, []Index{12, 16, 48, 52}) || step.Fins != nil {
+		t.Errorf("got step %+v, want X-Wing of 7", step)
+	}
+	if !slices.EqualFunc(base, []Unit{standard.unitlist[1], standard.unitlist[5]}, slices.Equal) ||
+		!slices.EqualFunc(cover, []Unit{standard.unitlist[12], standard.unitlist[16]}, slices.Equal) {
+		t.Errorf("got base units %v and cover units %v", base, cover)
+	}
+	for _, c := range step.Eliminations {
+		if (standard.col(c.Square) != 3 && standard.col(c.Square) != 7) || c.Digit != 7 || v[c.Square].IsMember(7) {
+			t.Errorf("got elimination %v", c)
+		}
+	}
+	if len(step.Eliminations) != 8 {
+		t.Errorf("got %v eliminations, want 8", len(step.Eliminations))
+	}
+
+	if v, err = ParseBoard(swordfishBoard, true); err != nil {
+		t.Fatal(err)
+	}
+	steps, ok = ApplyFish(v, 4)
+	if !ok || len(steps) != 2 {
+		t.Fatalf("got %v steps and ok=%v, want an X-Wing and a Swordfish", len(steps), ok)
+	}
+	step = steps[1]
+	base, cover = step.FishUnits()
+	if step.Technique != Swordfish || step.Digits != SingleDigitSet(8) || len(step.Eliminations) != 7 {
+		t.Errorf("got step %+v, want Swordfish of 8", step)
+	}
+	if !slices.EqualFunc(base, []Unit{standard.unitlist[9], standard.unitlist[13], standard.unitlist[15]}, slices.Equal) ||
+		!slices.EqualFunc(cover, []Unit{standard.unitlist[1], standard.unitlist[2], standard.unitlist[3]}, slices.Equal) {
+		t.Errorf("got base units %v and cover units %v", base, cover)
+	}
+
+	// Without Swordfish, only the X-Wing applies.
+	if v, err = ParseBoard(swordfishBoard, true); err != nil {
+		t.Fatal(err)
+	}
+	if steps, _ = ApplyFish(v, 2); len(steps) != 1 || steps[0].Technique != XWing {
+		t.Errorf("got steps %+v, want one X-Wing", steps)
+	}
+}
+
+func TestApplyFinnedFish(t *testing.T) {
+	v, err := ParseBoard(xWingBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, ok := ApplyFinnedFish(v, 4)
+	if !ok || len(steps) == 0 {
+		t.Fatalf("got %v steps and ok=%v", len(steps), ok)
+	}
+
+	// The fin of 8 in r7c6 is in the box of the ninth row's candidate in the
+	// fifth column, which is eliminated.
+	step := steps[0]
+	if step.Technique != FinnedXWing || step.Digits != SingleDigitSet(8) || !slices.Equal(step.Fins, []Index{59}) ||
+		!slices.Equal(step.Eliminations, []Candidate{{76, 8}}) {
+		t.Errorf("got step %+v, want finned X-Wing of 8", step)
+	}
+	var sashimi bool
+	for _, step := range steps {
+		if len(step.Fins) == 0 || step.Technique < FinnedXWing || step.Technique > SashimiJellyfish {
+			t.Errorf("got step %+v, want a finned fish", step)
+		}
+		sashimi = sashimi || step.Technique == SashimiSwordfish
+	}
+	if !sashimi {
+		t.Errorf("got no sashimi Swordfish")
+	}
+}
+
+func TestApplyFishInputs(t *testing.T) {
+	// The fish never eliminate the digits of the solution.
+	for _, board := range readInputBoards(t, "inputs/norvig-hard.txt") {
+		v, err := ParseBoard(board, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solution, _ := Solve(v)
+		for _, apply := range []func(Values, int) ([]Step, bool){ApplyFish, ApplyFinnedFish} {
+			vcopy := slices.Clone(v)
+			steps, ok := apply(vcopy, 4)
+			if !ok {
+				t.Fatalf("got contradiction for board %v", board)
+			}
+			checkSteps(t, steps, solution)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package sudoku
 
 import (
+	"context"
 	"log"
 	"math/rand"
+	"slices"
 )
 
 // Generate generates a random Sudoku board that has a single solution, with
@@ -14,27 +16,115 @@ import (
 // though higher hint counts generally correlate with easier boards. It's
 // recommended to generate a large number of boards using this function and
 // evaluate their difficulty separately using EvaluateDifficulty.
-// Notes:
-//  * Make sure the default rand source is seeded if you really want to get
-//    random boards.
-//  * This function may take a while to run when given a low hintCount.
+// Note: this function may take a while to run when given a low hintCount. To
+// generate boards that can be reproduced from a seed, use GenerateContext with
+// a Rand in its options.
 func Generate(hintCount int) Values {
-	empty := EmptyBoard()
-	board, solved := Solve(empty, SolveOptions{Randomize: true})
-	if !solved || !IsSolved(board) {
+	return standard.Generate(hintCount)
+}
+
+// Generate is the equivalent of the package-level Generate for boards of grid
+// g.
+func (g *Grid) Generate(hintCount int) Values {
+	board, _ := g.GenerateContext(context.Background(), hintCount)
+	return board
+}
+
+// GenerateOptions is a container of options for the context-aware generator
+// functions like GenerateContext.
+type GenerateOptions struct {
+	// MaxNodes is the node budget of the generator: the maximal number of
+	// search nodes to visit, across all the searches it runs, before giving up.
+	// 0 means no limit.
+	MaxNodes uint64
+
+	// Backend selects the search algorithm for the generator's searches, as
+	// in SolveOptions. Most of them check whether boards have a single
+	// solution, so a backend that counts solutions quickly is preferable.
+	Backend Backend
+
+	// Rand is the source of randomness of the generator; if it's nil, the
+	// global source of math/rand is used. A generator given a *rand.Rand with
+	// a known seed generates the same board every time.
+	Rand *rand.Rand
+}
+
+// generateOptions returns the single GenerateOptions in options, or the
+// default options if there are none. fn is the name of the calling function,
+// for the panic message when there are several.
+func generateOptions(fn string, options []GenerateOptions) GenerateOptions {
+	if len(options) > 1 {
+		panic(fn + " cannot accept more than a single GenerateOptions")
+	}
+	var opts GenerateOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	return opts
+}
+
+// GenerateContext is like Generate, but generation is aborted when ctx is done
+// or when it exceeds the node budget set in options. In this case it returns a
+// *SearchAbortedError together with the board generated so far: it still has a
+// single solution, but more than hintCount hints. If generation is aborted
+// before a solved board to remove hints from is found, the board is nil.
+func GenerateContext(ctx context.Context, hintCount int, options ...GenerateOptions) (Values, error) {
+	return standard.GenerateContext(ctx, hintCount, options...)
+}
+
+// GenerateContext is the equivalent of the package-level GenerateContext for
+// boards of grid g.
+func (g *Grid) GenerateContext(ctx context.Context, hintCount int, options ...GenerateOptions) (Values, error) {
+	s, e, rng := g.newGenerateState(ctx, generateOptions("Generate", options))
+	board, err := g.solveRandomly(e, s, rng)
+	if err != nil {
+		return nil, err
+	}
+	return g.removeHints(e, s, rng, board, hintCount), s.err
+}
+
+// newGenerateState creates the search state, the search engine and the source
+// of randomness for a generator function given its options.
+func (g *Grid) newGenerateState(ctx context.Context, opts GenerateOptions) (*searchState, engine, *rand.Rand) {
+	return newSearchState(ctx, opts.MaxNodes), g.engine(opts.Backend), randOrGlobal(opts.Rand)
+}
+
+// solveRandomly creates a random solved board for the generator to remove
+// hints from.
+func (g *Grid) solveRandomly(e engine, s *searchState, rng *rand.Rand) (Values, error) {
+	board, solved := e.solve(s, g.EmptyBoard(), rng)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if !solved || !g.IsSolved(board) {
 		log.Fatal("unable to generate solved board from empty")
 	}
+	return board, nil
+}
 
-	removalOrder := rand.Perm(81)
-	count := 81
+// removeHints removes hints from the solved board in random order, as long as
+// the board keeps having a single solution, until at most hintCount hints are
+// left or no more hints can be removed. board is modified and returned. The
+// searches are run by e; if they're aborted, hints stop being removed and
+// s.err is set. The order is drawn from rng.
+func (g *Grid) removeHints(e engine, s *searchState, rng *rand.Rand, board Values, hintCount int) Values {
+	numSquares := g.NumSquares()
+	removalOrder := rng.Perm(numSquares)
+	count := numSquares
 
 	for _, sq := range removalOrder {
 		savedDigit := board[sq]
 		// Try to remove the number from square sq.
-		board[sq] = FullDigitsSet()
+		board[sq] = g.full
 
-		solutions := SolveAll(board, 2)
-		switch len(solutions) {
+		numSolutions := e.count(s, board, 2)
+		if s.err != nil {
+			// The search didn't complete, so we don't know whether the board still
+			// has a single solution.
+			board[sq] = savedDigit
+			return board
+		}
+		switch numSolutions {
 		case 0:
 			// Some sort of bug, because removing a square from a solved board should
 			// never result in an unsolvable board.
@@ -60,32 +150,57 @@ func Generate(hintCount int) Values {
 // boards with a small hintCount than Generate, so you'll have to run it more
 // times in a loop to find a good low-hint-count board.
 func GenerateSymmetrical(hintCount int) Values {
-	empty := EmptyBoard()
-	board, solved := Solve(empty, SolveOptions{Randomize: true})
-	if !solved || !IsSolved(board) {
-		log.Fatal("unable to generate solved board from empty")
+	return standard.GenerateSymmetrical(hintCount)
+}
+
+// GenerateSymmetrical is the equivalent of the package-level
+// GenerateSymmetrical for boards of grid g.
+func (g *Grid) GenerateSymmetrical(hintCount int) Values {
+	board, _ := g.GenerateSymmetricalContext(context.Background(), hintCount)
+	return board
+}
+
+// GenerateSymmetricalContext is like GenerateSymmetrical, but generation can
+// be aborted like in GenerateContext.
+func GenerateSymmetricalContext(ctx context.Context, hintCount int, options ...GenerateOptions) (Values, error) {
+	return standard.GenerateSymmetricalContext(ctx, hintCount, options...)
+}
+
+// GenerateSymmetricalContext is the equivalent of the package-level
+// GenerateSymmetricalContext for boards of grid g.
+func (g *Grid) GenerateSymmetricalContext(ctx context.Context, hintCount int, options ...GenerateOptions) (Values, error) {
+	s, e, rng := g.newGenerateState(ctx, generateOptions("GenerateSymmetrical", options))
+	board, err := g.solveRandomly(e, s, rng)
+	if err != nil {
+		return nil, err
 	}
 
 	// This function works just like Generate, but instead of picking a random
-	// square out of all 81, it picks a random square from the first half of the
-	// board and then attempts to remove both this square and its reflection.
-	removalOrder := rand.Perm(41)
-	count := 81
+	// square out of all of them, it picks a random square from the first half of
+	// the board and then attempts to remove both this square and its reflection.
+	numSquares := g.NumSquares()
+	removalOrder := rng.Perm((numSquares + 1) / 2)
+	count := numSquares
 
 	for _, sq := range removalOrder {
 		// Find sq's reflection; note that in the middle row reflectSq could equal
 		// sq - we take this into account when counting how many hints remain on
 		// the board.
-		reflectSq := 80 - sq
+		reflectSq := numSquares - 1 - sq
 
 		savedDigit := board[sq]
 		savedReflect := board[reflectSq]
 
-		board[sq] = FullDigitsSet()
-		board[reflectSq] = FullDigitsSet()
+		board[sq] = g.full
+		board[reflectSq] = g.full
 
-		solutions := SolveAll(board, 2)
-		switch len(solutions) {
+		numSolutions := e.count(s, board, 2)
+		if s.err != nil {
+			board[sq] = savedDigit
+			board[reflectSq] = savedReflect
+			return board, s.err
+		}
+		switch numSolutions {
 		case 0:
 			log.Fatal("got a board without solutions")
 		case 1:
@@ -95,7 +210,7 @@ func GenerateSymmetrical(hintCount int) Values {
 				count--
 			}
 			if count <= hintCount {
-				return board
+				return board, nil
 			}
 		default:
 			board[sq] = savedDigit
@@ -103,5 +218,56 @@ func GenerateSymmetrical(hintCount int) Values {
 		}
 	}
 
+	return board, nil
+}
+
+// addHintsUntilUnique adds hints from solution to board until it has a single
+// solution, and returns it. It's used by generators of variants like killer
+// Sudoku, where the variant's constraints go most of the way to a unique
+// solution and only a few hints are needed, if any. The hints are chosen with
+// rng.
+func (g *Grid) addHintsUntilUnique(board Values, solution Values, rng *rand.Rand) Values {
+	// Each hint is placed in a square where two of the solutions differ, so it
+	// rules out at least one of them.
+	var hinted []Index
+	for {
+		solutions := g.solveAllWithElimination(board, 2)
+		if len(solutions) == 0 {
+			log.Fatal("got a board without solutions")
+		} else if len(solutions) == 1 {
+			break
+		}
+
+		var diffs []Index
+		for sq := range board {
+			if solutions[0][sq] != solutions[1][sq] {
+				diffs = append(diffs, sq)
+			}
+		}
+		sq := diffs[rng.Intn(len(diffs))]
+		board[sq] = solution[sq]
+		hinted = append(hinted, sq)
+	}
+
+	// Hints added early may have become unnecessary thanks to later hints;
+	// try to remove them.
+	for _, sq := range hinted {
+		board[sq] = g.full
+		if !g.HasUniqueSolution(board) {
+			board[sq] = solution[sq]
+		}
+	}
 	return board
 }
+
+// solveAllWithElimination is like SolveAll, but it runs elimination on a copy
+// of board first. The generator's boards aren't eliminated, and propagating
+// the hints before searching makes the search much faster - especially on
+// grids larger than 9x9.
+func (g *Grid) solveAllWithElimination(board Values, max int) []Values {
+	vcopy := slices.Clone(board)
+	if !g.EliminateAll(vcopy) {
+		return nil
+	}
+	return g.SolveAll(vcopy, max)
+}
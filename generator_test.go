@@ -1,9 +1,11 @@
 package sudoku
 
 import (
+	"context"
+	"log"
 	"math/rand"
+	"slices"
 	"testing"
-	"time"
 )
 
 func TestGenerate(t *testing.T) {
@@ -19,7 +21,6 @@ func TestGenerate(t *testing.T) {
 }
 
 func TestGenerateSymmetrical(t *testing.T) {
-	rand.Seed(time.Now().UnixNano())
 	//for {
 	board := GenerateSymmetrical(30)
 	vs := SolveAll(board, -1)
@@ -38,3 +39,68 @@ func TestGenerateSymmetrical(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateSeeded(t *testing.T) {
+	// Everything generated from the same seed should be the same.
+	generate := func(seed int64) (Values, Values, Values, float64, []Cage) {
+		rng := rand.New(rand.NewSource(seed))
+		solution, _ := Solve(EmptyBoard(), SolveOptions{Randomize: true, Rand: rng})
+		board, err := GenerateContext(context.Background(), 28, GenerateOptions{Rand: rng})
+		if err != nil {
+			t.Fatal(err)
+		}
+		symmetrical, err := GenerateSymmetricalContext(context.Background(), 30, GenerateOptions{Rand: rng})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := EvaluateDifficulty(board, DifficultyOptions{Rand: rng})
+		if err != nil {
+			t.Fatal(err)
+		}
+		kg, _ := standard.GenerateKiller(4, GenerateOptions{Rand: rng})
+		return solution, board, symmetrical, d, kg.Cages()
+	}
+
+	solution1, board1, sym1, d1, cages1 := generate(42)
+	solution2, board2, sym2, d2, cages2 := generate(42)
+	if !slices.Equal(solution1, solution2) || !slices.Equal(board1, board2) || !slices.Equal(sym1, sym2) {
+		t.Errorf("got different boards from the same seed")
+	}
+	if d1 != d2 {
+		t.Errorf("got difficulties %v and %v from the same seed", d1, d2)
+	}
+	if !slices.EqualFunc(cages1, cages2, func(c1, c2 Cage) bool {
+		return c1.Sum == c2.Sum && slices.Equal(c1.Squares, c2.Squares)
+	}) {
+		t.Errorf("got different killer cages from the same seed")
+	}
+
+	if _, board3, _, _, _ := generate(43); slices.Equal(board1, board3) {
+		t.Errorf("got the same board from different seeds")
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = Generate(25)
+	}
+}
+
+func BenchmarkHasUniqueSolution(b *testing.B) {
+	// A board with a unique solution, and the same board with a hint removed so
+	// it has multiple solutions.
+	unique, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	multiple, err := ParseBoard("."+hardboard1[1:], false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !HasUniqueSolution(unique) || HasUniqueSolution(multiple) {
+			log.Fatal("wrong uniqueness")
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package sudoku
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Grid describes the geometry of a Sudoku board: its size (the number of
+// digits, which is also the number of rows and columns) and the shape of its
+// boxes. A Grid holds the units and peers derived from this geometry, and
+// provides methods for parsing, displaying, solving and generating boards of
+// this shape.
+//
+// The package-level functions like ParseBoard, Solve and Generate operate on
+// the standard 9x9 grid with 3x3 boxes; to work with other grids, create one
+// with NewGrid and use its methods instead.
+type Grid struct {
+	// boxRows and boxCols are the dimensions of a single box, e.g. 2x3 for the
+	// 6x6 grid.
+	boxRows, boxCols int
+
+	// size is the number of digits in the grid, as well as the number of rows
+	// and columns; it's always boxRows*boxCols.
+	size int
+
+	// full is the set of all digits that can appear on this grid.
+	full Digits
+
+	// subgrids holds the (row, col) layout positions of the top-left squares of
+	// the size x size grids that make up the board, on a layout of layoutRows x
+	// layoutCols squares. Most boards have a single subgrid at (0, 0); boards
+	// like Samurai Sudoku have several overlapping subgrids, which share the
+	// squares where they overlap.
+	subgrids               [][2]int
+	layoutRows, layoutCols int
+
+	// squarePos maps each square to its position on the layout
+	// (row*layoutCols + col), and layoutSquares maps layout positions back to
+	// squares, with -1 for positions that aren't covered by any subgrid.
+	squarePos     []int
+	layoutSquares []Index
+
+	// unitlist is the list of all units that exist on the board.
+	unitlist []Unit
+
+	// units maps an index to a list of units that contain that square.
+	// The mapping is a slice, i.e. units[i] is a list of all the units
+	// that contain the square with index i.
+	units [][]Unit
+
+	// peers maps an index to a list of unique peers - other indices that share
+	// some unit with this index (it won't contain the index itself).
+	peers [][]Index
+
+	// diagonals is true if the main diagonals are units ("Sudoku-X").
+	diagonals bool
+
+	// antiKnight and antiKing are true if squares a chess knight's or king's
+	// move apart have to be different.
+	antiKnight, antiKing bool
+
+	// regions maps each square to the index of its irregular region, for
+	// "jigsaw" grids; it's nil for grids with regular boxes.
+	regions []int
+
+	// peerGroups are groups of squares that must have different digits, but
+	// aren't units since they don't have to contain all the digits (e.g.
+	// killer cages). Their squares are added to each other's peers.
+	peerGroups [][]Index
+
+	// cages are the killer cages of the grid, if any.
+	cages []Cage
+
+	// thermometers are the thermometers of the grid, if any.
+	thermometers []Thermometer
+
+	// edges are the Kropki and XV edge markers of the grid, if any.
+	edges []Edge
+
+	// arrows and sandwiches are the arrows and sandwich clues of the grid, if
+	// any.
+	arrows     []Arrow
+	sandwiches []Sandwich
+
+	// constraints is the list of additional constraints on the board, and
+	// squareConstraints maps an index to the constraints that apply to it.
+	constraints       []constraint
+	squareConstraints [][]constraint
+
+	// fast is the geometry for the fast solver, for grids it supports; it's
+	// nil for other grids.
+	fast *fastGrid
+
+	// stats collects the statistics of assignments and eliminations; it's
+	// only set on the copies of a grid made for a single search by withStats.
+	stats *SolveStats
+
+	// subsetSize is the largest size of the subset strategies the search
+	// applies after each guess, or 0 if it doesn't apply them; it's only set
+	// on the copies of a grid made by withSubsets.
+	subsetSize int
+}
+
+// maxGridSize is the largest number of digits supported on a grid.
+const maxGridSize = 16
+
+// digitRunes maps digits to the runes used to represent them in textual
+// boards; digit n is represented by digitRunes[n-1]. Grids larger than 9x9
+// use letters for the digits after 9, as in "hexadoku".
+const digitRunes = "123456789ABCDEFG"
+
+// standard is the standard 9x9 Sudoku grid, used by the package-level
+// functions.
+var standard = mustNewGrid(3, 3)
+
+// NewGrid creates a new grid with boxes of boxRows x boxCols squares. The grid
+// has boxRows*boxCols rows, columns and digits; for example:
+//
+//	NewGrid(2, 2) creates a 4x4 grid with 2x2 boxes
+//	NewGrid(2, 3) creates a 6x6 grid with 2x3 boxes (2 rows, 3 columns each)
+//	NewGrid(3, 3) creates the standard 9x9 grid
+//	NewGrid(3, 4) creates a 12x12 grid with 3x4 boxes
+//	NewGrid(4, 4) creates a 16x16 grid with 4x4 boxes
+//
+// Grids with more than 16 digits are not supported.
+func NewGrid(boxRows, boxCols int) (*Grid, error) {
+	return NewMultiGrid(boxRows, boxCols, [][2]int{{0, 0}})
+}
+
+// NewMultiGrid creates a board made of several overlapping grids with boxes
+// of boxRows x boxCols squares, like Samurai Sudoku (see NewSamurai). Each
+// element of subgrids is the (row, col) position of a grid's top-left square
+// on the board's layout. Each grid has its own rows, columns and boxes, and
+// where grids overlap they share squares; the squares are indexed in row-major
+// order of their layout positions, skipping positions not covered by any grid.
+//
+// The positions have to be aligned to boxes, so that overlapping grids share
+// whole boxes.
+func NewMultiGrid(boxRows, boxCols int, subgrids [][2]int) (*Grid, error) {
+	if boxRows < 1 || boxCols < 1 {
+		return nil, fmt.Errorf("invalid box shape %vx%v", boxRows, boxCols)
+	}
+	size := boxRows * boxCols
+	if size > maxGridSize {
+		return nil, fmt.Errorf("grid size %v is too large, max supported is %v", size, maxGridSize)
+	}
+	if len(subgrids) == 0 {
+		return nil, fmt.Errorf("no subgrids")
+	}
+
+	g := &Grid{
+		boxRows:  boxRows,
+		boxCols:  boxCols,
+		size:     size,
+		full:     fullDigitsSetOfSize(size),
+		subgrids: slices.Clone(subgrids),
+	}
+	for i, sub := range subgrids {
+		if sub[0] < 0 || sub[1] < 0 || sub[0]%boxRows != 0 || sub[1]%boxCols != 0 {
+			return nil, fmt.Errorf("subgrid position %v is not aligned to %vx%v boxes", sub, boxRows, boxCols)
+		}
+		if slices.Index(subgrids, sub) < i {
+			return nil, fmt.Errorf("duplicate subgrid position %v", sub)
+		}
+		g.layoutRows = max(g.layoutRows, sub[0]+size)
+		g.layoutCols = max(g.layoutCols, sub[1]+size)
+	}
+
+	covered := make([]bool, g.layoutRows*g.layoutCols)
+	for _, sub := range subgrids {
+		for row := sub[0]; row < sub[0]+size; row++ {
+			for col := sub[1]; col < sub[1]+size; col++ {
+				covered[row*g.layoutCols+col] = true
+			}
+		}
+	}
+	g.layoutSquares = make([]Index, len(covered))
+	for pos := range covered {
+		if covered[pos] {
+			g.layoutSquares[pos] = len(g.squarePos)
+			g.squarePos = append(g.squarePos, pos)
+		} else {
+			g.layoutSquares[pos] = -1
+		}
+	}
+
+	g.build()
+	return g, nil
+}
+
+// build computes the units and peers of g from its geometry and variants.
+func (g *Grid) build() {
+	g.unitlist = nil
+
+	// Overlapping subgrids may have units in common (e.g. the shared boxes of
+	// Samurai Sudoku); each unit is added once, identified by its kind and its
+	// first square.
+	const (
+		rowKind = iota
+		colKind
+		boxKind
+		mainDiagonalKind
+		antiDiagonalKind
+	)
+	seen := make(map[[2]int]bool)
+	addUnit := func(kind int, unit Unit) {
+		key := [2]int{kind, unit[0]}
+		if !seen[key] {
+			seen[key] = true
+			g.unitlist = append(g.unitlist, unit)
+		}
+	}
+
+	for _, sub := range g.subgrids {
+		top, left := sub[0], sub[1]
+
+		// row units
+		for row := 0; row < g.size; row++ {
+			var rowUnit []Index
+			for col := 0; col < g.size; col++ {
+				rowUnit = append(rowUnit, g.index(top+row, left+col))
+			}
+			addUnit(rowKind, rowUnit)
+		}
+
+		// column units
+		for col := 0; col < g.size; col++ {
+			var colUnit []Index
+			for row := 0; row < g.size; row++ {
+				colUnit = append(colUnit, g.index(top+row, left+col))
+			}
+			addUnit(colKind, colUnit)
+		}
+
+		if g.regions != nil {
+			// irregular region units replace the boxes; they're only supported on
+			// single grids.
+			regionUnits := make([]Unit, g.size)
+			for sq, region := range g.regions {
+				regionUnits[region] = append(regionUnits[region], sq)
+			}
+			g.unitlist = append(g.unitlist, regionUnits...)
+		} else {
+			// box units
+			for blockRow := 0; blockRow < g.size/g.boxRows; blockRow++ {
+				for blockCol := 0; blockCol < g.size/g.boxCols; blockCol++ {
+					var blockUnit []Index
+
+					for row := 0; row < g.boxRows; row++ {
+						for col := 0; col < g.boxCols; col++ {
+							blockUnit = append(blockUnit, g.index(top+blockRow*g.boxRows+row, left+blockCol*g.boxCols+col))
+						}
+					}
+					addUnit(boxKind, blockUnit)
+				}
+			}
+		}
+
+		if g.diagonals {
+			var mainDiagonal, antiDiagonal []Index
+			for i := 0; i < g.size; i++ {
+				mainDiagonal = append(mainDiagonal, g.index(top+i, left+i))
+				antiDiagonal = append(antiDiagonal, g.index(top+i, left+g.size-1-i))
+			}
+			addUnit(mainDiagonalKind, mainDiagonal)
+			addUnit(antiDiagonalKind, antiDiagonal)
+		}
+	}
+
+	g.computeUnitsAndPeers()
+
+	g.squareConstraints = make([][]constraint, g.NumSquares())
+	for _, c := range g.constraints {
+		for _, sq := range c.squares() {
+			g.squareConstraints[sq] = append(g.squareConstraints[sq], c)
+		}
+	}
+
+	g.fast = newFastGrid(g)
+}
+
+// mustNewGrid is like NewGrid, but panics on error. It's meant for grids
+// with shapes known to be valid.
+func mustNewGrid(boxRows, boxCols int) *Grid {
+	g, err := NewGrid(boxRows, boxCols)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// computeUnitsAndPeers fills in g.units and g.peers from g.unitlist and
+// g.peerGroups.
+func (g *Grid) computeUnitsAndPeers() {
+	numSquares := g.NumSquares()
+
+	// For each index i, units[i] is a list of all units that contain i.
+	g.units = make([][]Unit, numSquares)
+	for i := 0; i < numSquares; i++ {
+		for _, unit := range g.unitlist {
+			if slices.Index(unit, i) >= 0 {
+				g.units[i] = append(g.units[i], slices.Clone(unit))
+			}
+		}
+	}
+
+	// For each index i, peers[i] is a list of unique indices that share some
+	// unit or peer group with i.
+	g.peers = make([][]Index, numSquares)
+	addPeers := func(i Index, group []Index) {
+		for _, candidate := range group {
+			// This uses linear search to ensure uniqueness, but this calculation is
+			// only done once at grid creation so we don't particularly care about
+			// its speed.
+			if candidate != i && slices.Index(g.peers[i], candidate) < 0 {
+				g.peers[i] = append(g.peers[i], candidate)
+			}
+		}
+	}
+	for i := 0; i < numSquares; i++ {
+		for _, unit := range g.units[i] {
+			addPeers(i, unit)
+		}
+	}
+	for _, group := range g.peerGroups {
+		for _, i := range group {
+			addPeers(i, group)
+		}
+	}
+}
+
+// Size returns the number of digits in the grid, which is also the number of
+// rows and columns (e.g. 9 for the standard grid).
+func (g *Grid) Size() int {
+	return g.size
+}
+
+// BoxShape returns the number of rows and columns in each box of the grid.
+func (g *Grid) BoxShape() (rows, cols int) {
+	return g.boxRows, g.boxCols
+}
+
+// NumSquares returns the number of squares on the grid (e.g. 81 for the
+// standard grid).
+func (g *Grid) NumSquares() int {
+	return len(g.squarePos)
+}
+
+// FullDigits returns a Digits with all the digits of this grid set.
+func (g *Grid) FullDigits() Digits {
+	return g.full
+}
+
+// Layout returns the number of rows and columns of the layout the squares of
+// the grid are placed on. For a single grid it's Size x Size; for multi-grids
+// (see NewMultiGrid) it's the bounding box of all the subgrids.
+func (g *Grid) Layout() (rows, cols int) {
+	return g.layoutRows, g.layoutCols
+}
+
+// index returns the Index of the square at layout position (row, col), which
+// must hold a square.
+func (g *Grid) index(row, col int) Index {
+	return g.layoutSquares[row*g.layoutCols+col]
+}
+
+// squareAt returns the square at layout position (row, col), and false if
+// there's no square there (either out of the layout's bounds, or in a gap
+// between the subgrids).
+func (g *Grid) squareAt(row, col int) (Index, bool) {
+	if row < 0 || row >= g.layoutRows || col < 0 || col >= g.layoutCols {
+		return -1, false
+	}
+	sq := g.layoutSquares[row*g.layoutCols+col]
+	return sq, sq >= 0
+}
+
+// row returns the layout row of square sq.
+func (g *Grid) row(sq Index) int {
+	return g.squarePos[sq] / g.layoutCols
+}
+
+// col returns the layout column of square sq.
+func (g *Grid) col(sq Index) int {
+	return g.squarePos[sq] % g.layoutCols
+}
+
+// digitCandidates returns a new slice with all the digits of this grid, in
+// ascending order.
+func (g *Grid) digitCandidates() []uint16 {
+	candidates := make([]uint16, g.size)
+	for i := range candidates {
+		candidates[i] = uint16(i + 1)
+	}
+	return candidates
+}
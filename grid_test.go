@@ -0,0 +1,222 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"slices"
+)
+
+func TestNewGridErrors(t *testing.T) {
+	for _, shape := range [][2]int{{0, 3}, {3, -1}, {5, 4}, {4, 5}} {
+		if _, err := NewGrid(shape[0], shape[1]); err == nil {
+			t.Errorf("NewGrid(%v, %v): got no error", shape[0], shape[1])
+		}
+	}
+}
+
+func TestGridUnitsAndPeers(t *testing.T) {
+	var tests = []struct {
+		boxRows, boxCols int
+		wantNumUnits     int
+		wantNumPeers     int
+	}{
+		{2, 2, 12, 7},
+		{2, 3, 18, 12},
+		{3, 3, 27, 20},
+		{3, 4, 36, 28},
+		{4, 4, 48, 39},
+	}
+
+	for _, tt := range tests {
+		g, err := NewGrid(tt.boxRows, tt.boxCols)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.unitlist) != tt.wantNumUnits {
+			t.Errorf("%vx%v: got %v units, want %v", tt.boxRows, tt.boxCols, len(g.unitlist), tt.wantNumUnits)
+		}
+		for sq := 0; sq < g.NumSquares(); sq++ {
+			if len(g.units[sq]) != 3 {
+				t.Errorf("%vx%v: got %v units for square %v, want 3", tt.boxRows, tt.boxCols, len(g.units[sq]), sq)
+			}
+			if len(g.peers[sq]) != tt.wantNumPeers {
+				t.Errorf("%vx%v: got %v peers for square %v, want %v", tt.boxRows, tt.boxCols, len(g.peers[sq]), sq, tt.wantNumPeers)
+			}
+		}
+	}
+}
+
+func TestGrid6x6Boxes(t *testing.T) {
+	g, err := NewGrid(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Square 8 is in row 1, col 2: the top-left 2x3 box.
+	wantBox := Unit{0, 1, 2, 6, 7, 8}
+	if !slices.Equal(g.units[8][2], wantBox) {
+		t.Errorf("got box %v, want %v", g.units[8][2], wantBox)
+	}
+}
+
+// A 6x6 puzzle with 2x3 boxes.
+var board6x6 string = `
+. . 3 |. 1 .
+5 6 . |3 2 .
+------+------
+. 5 4 |2 . 3
+2 . 6 |4 5 .
+------+------
+. 1 2 |. 4 5
+. 4 . |1 . .`
+
+func TestGridParseAndSolve6x6(t *testing.T) {
+	g, err := NewGrid(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := g.ParseBoard(board6x6, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountHints(v) != 20 {
+		t.Errorf("got %v hints, want 20", CountHints(v))
+	}
+
+	// Round-trip through DisplayAsInput.
+	v2, err := g.ParseBoard(g.DisplayAsInput(v), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(v, v2) {
+		t.Errorf("got %v after round-trip, want %v", v2, v)
+	}
+
+	if !g.EliminateAll(v) {
+		t.Fatal("contradiction in elimination")
+	}
+	vs := g.SolveAll(v, -1)
+	if len(vs) != 1 {
+		t.Fatalf("got %v solutions, want 1", len(vs))
+	}
+	if !g.IsSolved(vs[0]) {
+		t.Errorf("got unsolved board:\n%v", g.Display(vs[0]))
+	}
+}
+
+func TestGridParseErrors(t *testing.T) {
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.ParseBoard("1234........", false); err == nil {
+		t.Errorf("got no error for short board")
+	}
+	if _, err := g.ParseBoard("1234.......5....", false); err == nil {
+		t.Errorf("got no error for out-of-range digit")
+	}
+	if _, err := g.ParseBoard("11..............", true); err == nil {
+		t.Errorf("got no error for contradiction")
+	}
+}
+
+func TestGridParseHexadoku(t *testing.T) {
+	g, err := NewGrid(4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solved, ok := g.Solve(g.EmptyBoard())
+	if !ok || !g.IsSolved(solved) {
+		t.Fatal("unable to solve empty 16x16 board")
+	}
+
+	// Letters should round-trip through DisplayAsInput and ParseBoard.
+	text := g.DisplayAsInput(solved)
+	if !strings.ContainsAny(text, "ABCDEFG") {
+		t.Errorf("expect letters in 16x16 board, got:\n%v", text)
+	}
+	v, err := g.ParseBoard(strings.ToLower(text), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(v, solved) {
+		t.Errorf("got %v, want %v", v, solved)
+	}
+}
+
+func TestGridSolveEmpty(t *testing.T) {
+	for _, shape := range [][2]int{{2, 2}, {2, 3}, {3, 2}, {2, 4}, {3, 4}, {4, 4}} {
+		g, err := NewGrid(shape[0], shape[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			vs, solved := g.Solve(g.EmptyBoard(), SolveOptions{Randomize: true})
+			if !solved || !g.IsSolved(vs) {
+				t.Errorf("%vx%v: want solved result board; got:\n%v", shape[0], shape[1], g.Display(vs))
+			}
+		}
+	}
+}
+
+func TestGridGenerate(t *testing.T) {
+	for _, shape := range [][2]int{{2, 2}, {2, 3}, {3, 4}} {
+		g, err := NewGrid(shape[0], shape[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		board := g.Generate(g.NumSquares() / 3)
+		vs := g.solveAllWithElimination(board, -1)
+		if len(vs) != 1 {
+			t.Errorf("%vx%v: got %v solutions, want 1", shape[0], shape[1], len(vs))
+		}
+
+		board = g.GenerateSymmetrical(g.NumSquares() / 2)
+		vs = g.solveAllWithElimination(board, -1)
+		if len(vs) != 1 {
+			t.Errorf("%vx%v: got %v solutions, want 1", shape[0], shape[1], len(vs))
+		}
+		n := g.NumSquares()
+		for sq := 0; sq < n/2; sq++ {
+			if board[sq].Size() != board[n-1-sq].Size() {
+				t.Errorf("squares %v != %v on board, expected symmetry", sq, n-1-sq)
+			}
+		}
+
+		d, err := g.EvaluateDifficulty(board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d < 1.0 || d > 5.0 {
+			t.Errorf("got difficulty %v, want in range [1, 5]", d)
+		}
+	}
+}
+
+func TestGridDisplay(t *testing.T) {
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := g.ParseBoard("1234341221434321", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "1 2 |3 4 \n3 4 |1 2 \n----+----\n2 1 |4 3 \n4 3 |2 1 \n"
+	if got := g.Display(v); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	var buf bytes.Buffer
+	g.DisplayAsSVG(&buf, v, 1.0)
+	if got := strings.Count(buf.String(), "<rect"); got != 20 {
+		t.Errorf("got %v rects in SVG, want 20", got)
+	}
+}
@@ -0,0 +1,341 @@
+package sudoku
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrMultipleSolutions is returned by NextHint when the puzzle has more than
+// one solution, so the player's digits can't be checked against it.
+var ErrMultipleSolutions = errors.New("the board has more than one solution")
+
+// Hint is the next move suggested by NextHint for a player's grid.
+type Hint struct {
+	// Mistakes are the squares where the player went wrong: the squares with
+	// digits that aren't the digits of the solution, and the squares whose
+	// pencil marks don't include the digit of the solution. It's nil if there
+	// are no mistakes.
+	Mistakes []Index
+
+	// Step is the simplest deduction the player can make, if there are no
+	// mistakes; it's nil if there are mistakes, if the grid is solved or if
+	// none of the techniques of SolveLogically applies.
+	Step *Step
+
+	// Description describes the mistakes or the step for the player.
+	Description string
+
+	// Squares are the squares to highlight: the mistakes, or the squares of
+	// the step's pattern followed by the squares it places digits in or
+	// eliminates candidates from.
+	Squares []Index
+}
+
+// NextHint answers a player's "what's my next move?" for a puzzle in
+// progress. givens is the puzzle as given (without elimination), and board is
+// the player's grid: the givens and the digits the player entered, as squares
+// with a single candidate, and empty squares with more candidates (e.g. all
+// the digits, as in EmptyBoard). pencilMarks can be nil, or the player's
+// candidates for the empty squares of board, where squares without pencil
+// marks are 0.
+//
+// NextHint first checks the player's digits and pencil marks against the
+// solution of the puzzle, and returns a hint with the mistakes if it finds
+// any. Otherwise it returns a hint with the step SolveLogically would make
+// next: the candidates of the empty squares are their pencil marks (or all
+// the digits), without the digits of their peers.
+//
+// An error is returned if givens, board or a non-nil pencilMarks don't have
+// a value for each square, if the puzzle has no solution (the first problem
+// found by Validate), or if it has more than one (ErrMultipleSolutions).
+func NextHint(givens, board, pencilMarks Values) (*Hint, error) {
+	return standard.NextHint(givens, board, pencilMarks)
+}
+
+// NextHint is the equivalent of the package-level NextHint for boards of grid
+// g.
+func (g *Grid) NextHint(givens, board, pencilMarks Values) (*Hint, error) {
+	n := g.NumSquares()
+	switch {
+	case len(givens) != n:
+		return nil, fmt.Errorf("got %v squares in givens, want %v", len(givens), n)
+	case len(board) != n:
+		return nil, fmt.Errorf("got %v squares in board, want %v", len(board), n)
+	case pencilMarks != nil && len(pencilMarks) != n:
+		return nil, fmt.Errorf("got %v squares in pencil marks, want %v", len(pencilMarks), n)
+	}
+	// Validate, which searches again, only runs to explain why there's no
+	// solution.
+	var solutions []Values
+	puzzle := slices.Clone(givens)
+	if g.EliminateAll(puzzle) {
+		solutions = g.SolveAll(puzzle, 2)
+	}
+	switch {
+	case len(solutions) == 0:
+		if problems := g.Validate(givens); len(problems) > 0 {
+			return nil, problems[0]
+		}
+		return nil, fmt.Errorf("the board has no solution")
+	case len(solutions) > 1:
+		return nil, ErrMultipleSolutions
+	}
+	solution := solutions[0]
+
+	var wrongDigits, wrongMarks []Index
+	entries := make(Values, len(board))
+	for sq, d := range board {
+		switch {
+		case d.Size() == 1:
+			if d != solution[sq] {
+				wrongDigits = append(wrongDigits, sq)
+			}
+			entries[sq] = d
+		case pencilMarks != nil && pencilMarks[sq] != 0 && pencilMarks[sq]&solution[sq] == 0:
+			wrongMarks = append(wrongMarks, sq)
+			fallthrough
+		default:
+			entries[sq] = g.full
+		}
+	}
+	if len(wrongDigits) > 0 || len(wrongMarks) > 0 {
+		var sentences []string
+		if len(wrongDigits) > 0 {
+			sentences = append(sentences, fmt.Sprintf("The digits in %v are wrong.", g.describeSquares(wrongDigits)))
+		}
+		if len(wrongMarks) > 0 {
+			sentences = append(sentences, fmt.Sprintf("The pencil marks in %v rule out the right digit.", g.describeSquares(wrongMarks)))
+		}
+		mistakes := append(wrongDigits, wrongMarks...)
+		slices.Sort(mistakes)
+		return &Hint{Mistakes: mistakes, Description: strings.Join(sentences, " "), Squares: mistakes}, nil
+	}
+
+	ls := g.newLogicState(entries)
+	if ls.done() {
+		return &Hint{Description: "The board is solved."}, nil
+	}
+	if pencilMarks != nil {
+		for sq, d := range pencilMarks {
+			if !ls.solved[sq] && d != 0 {
+				ls.values[sq] &= d
+			}
+		}
+	}
+	step := ls.nextStep()
+	if step == nil {
+		return &Hint{Description: "None of the techniques applies; the next move needs a guess."}, nil
+	}
+
+	squares := slices.Clone(step.Squares)
+	for _, c := range append(slices.Clone(step.Placements), step.Eliminations...) {
+		if !slices.Contains(squares, c.Square) {
+			squares = append(squares, c.Square)
+		}
+	}
+	return &Hint{Step: step, Description: g.DescribeStep(step), Squares: squares}, nil
+}
+
+// DescribeStep returns a description of step for players, naming squares by
+// their row and column (e.g. r1c2 for the square in the first row and the
+// second column).
+func DescribeStep(step *Step) string {
+	return standard.DescribeStep(step)
+}
+
+// DescribeStep is the equivalent of the package-level DescribeStep for the
+// steps of boards of grid g.
+func (g *Grid) DescribeStep(step *Step) string {
+	name := step.Technique.String()
+	name = strings.ToUpper(name[:1]) + name[1:]
+	digits := describeDigits(step.Digits)
+	squares := g.describeSquares(step.Squares)
+	elims := g.describeEliminations(step)
+
+	switch step.Technique {
+	case NakedSingle:
+		return fmt.Sprintf("%v: %v is the only candidate left in %v.", name, digits, squares)
+	case HiddenSingle:
+		return fmt.Sprintf("%v: %v is the only square in %v where %v can go.", name, squares, g.describeUnit(step.Units[0]), digits)
+	case Pointing, BoxLineReduction:
+		return fmt.Sprintf("%v: in %v, %v can only go in %v, which are all in %v, so it can be eliminated from the rest of %v: %v.",
+			name, g.describeUnit(step.Units[0]), digits, squares, g.describeUnit(step.Units[1]), g.describeUnit(step.Units[1]), elims)
+	case NakedPair, NakedTriple, NakedQuad:
+		return fmt.Sprintf("%v: the only candidates of %v in %v are %v, so these digits can be eliminated from the other squares of the unit: %v.",
+			name, squares, g.describeUnit(step.Units[0]), digits, elims)
+	case HiddenPair, HiddenTriple, HiddenQuad:
+		return fmt.Sprintf("%v: in %v, %v can only go in %v, so the other candidates can be eliminated from these squares: %v.",
+			name, g.describeUnit(step.Units[0]), digits, squares, elims)
+	case XWing, Swordfish, Jellyfish:
+		base, cover := step.FishUnits()
+		return fmt.Sprintf("%v: in %v, %v can only go in %v, so it can be eliminated from the rest of them: %v.",
+			name, g.describeUnits(base), digits, g.describeUnits(cover), elims)
+	case FinnedXWing, SashimiXWing, FinnedSwordfish, SashimiSwordfish, FinnedJellyfish, SashimiJellyfish:
+		base, cover := step.FishUnits()
+		fins := "the fin " + g.describeSquares(step.Fins)
+		if len(step.Fins) > 1 {
+			fins = "the fins " + g.describeSquares(step.Fins)
+		}
+		return fmt.Sprintf("%v: in %v, %v can only go in %v or in %v, so it can be eliminated from the squares of these units that see %v: %v.",
+			name, g.describeUnits(base), digits, g.describeUnits(cover), fins, fins, elims)
+	case XYWing:
+		// The eliminations are all of z, so they're described by their squares.
+		z := step.Eliminations[0].Digit
+		x, y := step.Digits.Remove(z).twoMemberDigits()
+		elims = g.describeEliminations(&Step{Digits: SingleDigitSet(z), Eliminations: step.Eliminations})
+		return fmt.Sprintf("%v: the pivot %v has %v or %v, and either way one of the pincers %v and %v has %v, so it can be eliminated from the squares that see both pincers: %v.",
+			name, g.squareName(step.Squares[0]), digitName(x), digitName(y), g.squareName(step.Squares[1]), g.squareName(step.Squares[2]), digitName(z), elims)
+	case XYZWing:
+		z := step.Eliminations[0].Digit
+		elims = g.describeEliminations(&Step{Digits: SingleDigitSet(z), Eliminations: step.Eliminations})
+		return fmt.Sprintf("%v: the pivot %v has %v, and the pincers %v and %v have %v with one of the others, so one of the three squares has %v, and it can be eliminated from the squares that see all of them: %v.",
+			name, g.squareName(step.Squares[0]), describeDigits(step.Digits), g.squareName(step.Squares[1]), g.squareName(step.Squares[2]), digitName(z), digitName(z), elims)
+	case WWing:
+		y := step.Eliminations[0].Digit
+		x := step.Digits.Remove(y).SingleMemberDigit()
+		elims = g.describeEliminations(&Step{Digits: SingleDigitSet(y), Eliminations: step.Eliminations})
+		a, b, p, q := g.squareName(step.Squares[0]), g.squareName(step.Squares[1]), g.squareName(step.Squares[2]), g.squareName(step.Squares[3])
+		return fmt.Sprintf("%v: %v and %v have only %v, and in %v, %v can only go in %v, which sees %v, and %v, which sees %v, so one of %v and %v has %v, and it can be eliminated from the squares that see both of them: %v.",
+			name, a, b, digits, g.describeUnit(step.Units[0]), digitName(x), p, a, q, b, a, b, digitName(y), elims)
+	case SimpleColoring:
+		colors := fmt.Sprintf("the conjugate pairs of %v color %v against %v", digits, g.describeSquares(step.Colors[0]), g.describeSquares(step.Colors[1]))
+		if slices.Contains(step.Colors[0], step.Eliminations[0].Square) {
+			return fmt.Sprintf("%v: %v; two squares of the first color see each other, so it doesn't have %v, which can be eliminated from its squares: %v.",
+				name, colors, digits, elims)
+		}
+		return fmt.Sprintf("%v: %v; one of the colors has %v, so it can be eliminated from the squares that see both colors: %v.",
+			name, colors, digits, elims)
+	case MultiColoring:
+		return fmt.Sprintf("%v: the conjugate pairs of %v form two clusters, one colored %v against %v and the other %v against %v; the first colors of the clusters see each other, so one of the opposite colors has %v, and it can be eliminated from the squares that see both of them: %v.",
+			name, digits, g.describeSquares(step.Colors[0]), g.describeSquares(step.Colors[1]), g.describeSquares(step.Colors[2]), g.describeSquares(step.Colors[3]), digits, elims)
+	case XCycle, AIC:
+		chain := g.describeChain(step.Links)
+		switch {
+		case len(step.Placements) > 0:
+			c := step.Placements[0]
+			return fmt.Sprintf("%v: %v, so assuming that %v isn't in %v puts it there, and it goes in %v.", name, chain, digitName(c.Digit), g.squareName(c.Square), g.squareName(c.Square))
+		case !step.Links[len(step.Links)-1].Strong:
+			return fmt.Sprintf("%v: %v is a loop, so one of the candidates of each of its weak links is true, and the candidates that see both of them can be eliminated: %v.", name, chain, elims)
+		}
+		return fmt.Sprintf("%v: %v, so one of its ends is true, and the candidates that see both ends can be eliminated: %v.", name, chain, elims)
+	}
+
+	var parts []string
+	if len(step.Placements) > 0 {
+		var placements []string
+		for _, c := range step.Placements {
+			placements = append(placements, digitName(c.Digit)+" in "+g.squareName(c.Square))
+		}
+		parts = append(parts, "places "+joinWords(placements))
+	}
+	if len(step.Eliminations) > 0 {
+		parts = append(parts, "eliminates candidates from "+elims)
+	}
+	return fmt.Sprintf("%v %v.", name, strings.Join(parts, " and "))
+}
+
+// describeChain returns the links of a chain in the notation of alternating
+// inference chains, like 5r1c1 = 5r1c9 - 5r3c9, where = is a strong link and
+// - a weak one.
+func (g *Grid) describeChain(links []Link) string {
+	var b strings.Builder
+	b.WriteString(g.candidateName(links[0].From))
+	for _, link := range links {
+		if link.Strong {
+			b.WriteString(" = ")
+		} else {
+			b.WriteString(" - ")
+		}
+		b.WriteString(g.candidateName(link.To))
+	}
+	return b.String()
+}
+
+// candidateName returns the name of candidate c, with its digit and square,
+// like 5r1c2.
+func (g *Grid) candidateName(c Candidate) string {
+	return digitName(c.Digit) + g.squareName(c.Square)
+}
+
+// squareName returns the name of square sq, with its row and column on the
+// layout of g, like r1c2.
+func (g *Grid) squareName(sq Index) string {
+	return fmt.Sprintf("r%vc%v", g.row(sq)+1, g.col(sq)+1)
+}
+
+// describeSquares returns the names of the squares, as a list.
+func (g *Grid) describeSquares(squares []Index) string {
+	names := make([]string, len(squares))
+	for i, sq := range squares {
+		names[i] = g.squareName(sq)
+	}
+	return joinWords(names)
+}
+
+// describeUnit returns the name of unit: a row or a column by its number, and
+// other units (like boxes) by their kind and their first square.
+func (g *Grid) describeUnit(unit Unit) string {
+	first, last := unit[0], unit[len(unit)-1]
+	switch {
+	case g.isLine(unit) && g.row(first) == g.row(last):
+		return fmt.Sprintf("row %v", g.row(first)+1)
+	case g.isLine(unit):
+		return fmt.Sprintf("column %v", g.col(first)+1)
+	case g.regions != nil && !slices.ContainsFunc(unit, func(sq Index) bool { return g.regions[sq] != g.regions[first] }):
+		return "the region of " + g.squareName(first)
+	case g.row(last)-g.row(first) == g.boxRows-1 && g.col(last)-g.col(first) == g.boxCols-1:
+		return "the box of " + g.squareName(first)
+	}
+	return "the diagonal of " + g.squareName(first)
+}
+
+// describeUnits returns the names of the units, as a list.
+func (g *Grid) describeUnits(units []Unit) string {
+	names := make([]string, len(units))
+	for i, unit := range units {
+		names[i] = g.describeUnit(unit)
+	}
+	return joinWords(names)
+}
+
+// describeEliminations returns a list of the candidates eliminated by step:
+// the squares, if the pattern of the step has a single digit, and otherwise
+// the squares with their digits, like r1c2 (3, 8).
+func (g *Grid) describeEliminations(step *Step) string {
+	var squares []Index
+	digits := make(map[Index]Digits)
+	for _, c := range step.Eliminations {
+		if digits[c.Square] == 0 {
+			squares = append(squares, c.Square)
+		}
+		digits[c.Square] = digits[c.Square].Add(c.Digit)
+	}
+	if step.Digits.Size() == 1 {
+		return g.describeSquares(squares)
+	}
+	names := make([]string, len(squares))
+	for i, sq := range squares {
+		names[i] = fmt.Sprintf("%v (%v)", g.squareName(sq), strings.Join(strings.Split(digits[sq].String(), ""), ", "))
+	}
+	return joinWords(names)
+}
+
+// digitName returns the name of digit d, as in textual boards.
+func digitName(d uint16) string {
+	return string(digitRunes[d-1])
+}
+
+// describeDigits returns the digits of d, as a list.
+func describeDigits(d Digits) string {
+	return joinWords(strings.Split(d.String(), ""))
+}
+
+// joinWords joins words as a list in English: "a", "a and b", "a, b and c".
+func joinWords(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
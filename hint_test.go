@@ -0,0 +1,207 @@
+package sudoku
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNextHint(t *testing.T) {
+	board := "003020600900305001001806400008102900700000008006708200002609500800203009005010300"
+	givens, err := ParseBoard(board, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, steps, _ := SolveLogically(givens)
+
+	// Without entries, the hint is the first step of SolveLogically.
+	hint, err := NextHint(givens, givens, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hint.Step == nil || hint.Mistakes != nil || !slices.Equal(hint.Step.Placements, steps[0].Placements) {
+		t.Fatalf("got hint %+v, want step %+v", hint, steps[0])
+	}
+	if hint.Description != DescribeStep(&steps[0]) || !slices.Equal(hint.Squares, steps[0].Squares) {
+		t.Errorf("got hint %+v", hint)
+	}
+
+	// With the first steps' digits entered, the hint is the next step.
+	entries := slices.Clone(givens)
+	for _, step := range steps[:10] {
+		for _, c := range step.Placements {
+			entries[c.Square] = SingleDigitSet(c.Digit)
+		}
+	}
+	hint, err = NextHint(givens, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hint.Step == nil || len(hint.Step.Placements) != 1 || !solution[hint.Step.Placements[0].Square].IsMember(hint.Step.Placements[0].Digit) {
+		t.Errorf("got hint %+v, want a placement", hint)
+	}
+	if hint, err = NextHint(givens, solution, nil); err != nil || hint.Step != nil || hint.Description != "The board is solved." {
+		t.Errorf("got hint %+v and error %v for a solved board", hint, err)
+	}
+}
+
+func TestNextHintMistakes(t *testing.T) {
+	givens, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, _ := Solve(v)
+
+	// A wrong digit in the first empty square, and pencil marks without the
+	// right digit in the second one.
+	first := slices.IndexFunc(givens, func(d Digits) bool { return d.Size() > 1 })
+	second := first + 1 + slices.IndexFunc(givens[first+1:], func(d Digits) bool { return d.Size() > 1 })
+	board := slices.Clone(givens)
+	board[first] = SingleDigitSet(solution[first].SingleMemberDigit()%9 + 1)
+	pencilMarks := make(Values, len(givens))
+	pencilMarks[second] = standard.full &^ solution[second]
+
+	hint, err := NextHint(givens, board, pencilMarks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hint.Step != nil || !slices.Equal(hint.Mistakes, []Index{first, second}) || !slices.Equal(hint.Squares, hint.Mistakes) {
+		t.Errorf("got hint %+v, want mistakes in squares %v and %v", hint, first, second)
+	}
+	if !strings.Contains(hint.Description, "wrong") || !strings.Contains(hint.Description, "pencil marks") {
+		t.Errorf("got description %q", hint.Description)
+	}
+
+	// With pencil marks of only the right digit, the first hint is the naked
+	// single of the first empty square.
+	pencilMarks[second] = 0
+	pencilMarks[first] = solution[first]
+	hint, err = NextHint(givens, givens, pencilMarks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hint.Step == nil || hint.Step.Technique != NakedSingle || !slices.Equal(hint.Squares, []Index{first}) {
+		t.Errorf("got hint %+v, want naked single in square %v", hint, first)
+	}
+}
+
+func TestNextHintStuck(t *testing.T) {
+	// When SolveLogically gets stuck, its board as the player's grid and pencil
+	// marks gets no hint.
+	for _, board := range readInputBoards(t, "inputs/norvig-hard.txt") {
+		givens, err := ParseBoard(board, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stuck, _, solved := SolveLogically(givens)
+		if solved {
+			continue
+		}
+		hint, err := NextHint(givens, stuck, stuck)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hint.Step != nil || hint.Mistakes != nil || !strings.Contains(hint.Description, "guess") {
+			t.Errorf("got hint %+v, want none", hint)
+		}
+		return
+	}
+	t.Fatal("no board needs guessing")
+}
+
+func TestNextHintErrors(t *testing.T) {
+	if _, err := NextHint(EmptyBoard(), EmptyBoard(), nil); !errors.Is(err, ErrMultipleSolutions) {
+		t.Errorf("got error %v, want ErrMultipleSolutions", err)
+	}
+	v, err := ParseBoard(impossible, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verr *ValidationError
+	if _, err := NextHint(v, v, nil); !errors.As(err, &verr) || verr.Kind != NoSolution {
+		t.Errorf("got error %v, want no solution", err)
+	}
+	dup := EmptyBoard()
+	dup[0], dup[1] = SingleDigitSet(1), SingleDigitSet(1)
+	if _, err := NextHint(dup, dup, nil); !errors.As(err, &verr) || verr.Kind != DuplicateDigit {
+		t.Errorf("got error %v, want duplicate digit", err)
+	}
+
+	givens, err := ParseBoard(easyboard1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ givens, board, pencilMarks Values }{
+		{givens[:40], givens, nil},
+		{givens, givens[:40], nil},
+		{givens, givens, make(Values, 40)},
+	} {
+		if _, err := NextHint(tt.givens, tt.board, tt.pencilMarks); err == nil || !strings.Contains(err.Error(), "got 40 squares") {
+			t.Errorf("got error %v, want wrong number of squares", err)
+		}
+	}
+}
+
+func TestDescribeStep(t *testing.T) {
+	var tests = []struct {
+		step Step
+		want string
+	}{
+		{
+			Step{Technique: NakedSingle, Digits: SingleDigitSet(5), Squares: []Index{10}, Placements: []Candidate{{10, 5}}},
+			"Naked single: 5 is the only candidate left in r2c2.",
+		},
+		{
+			Step{Technique: HiddenSingle, Digits: SingleDigitSet(5), Squares: []Index{10}, Units: []Unit{standard.unitlist[10]}, Placements: []Candidate{{10, 5}}},
+			"Hidden single: r2c2 is the only square in column 2 where 5 can go.",
+		},
+		{
+			Step{Technique: Pointing, Digits: SingleDigitSet(7), Squares: []Index{0, 1}, Units: []Unit{standard.unitlist[18], standard.unitlist[0]}, Eliminations: []Candidate{{5, 7}, {8, 7}}},
+			"Pointing: in the box of r1c1, 7 can only go in r1c1 and r1c2, which are all in row 1, so it can be eliminated from the rest of row 1: r1c6 and r1c9.",
+		},
+		{
+			Step{Technique: NakedPair, Digits: Digits(0).Add(3).Add(8), Squares: []Index{0, 1}, Units: []Unit{standard.unitlist[0]}, Eliminations: []Candidate{{5, 3}, {5, 8}, {8, 8}}},
+			"Naked pair: the only candidates of r1c1 and r1c2 in row 1 are 3 and 8, so these digits can be eliminated from the other squares of the unit: r1c6 (3, 8) and r1c9 (8).",
+		},
+		{
+			Step{Technique: FinnedXWing, Digits: SingleDigitSet(8), Squares: []Index{18, 22, 54, 58, 59}, Fins: []Index{59},
+				Units: []Unit{standard.unitlist[2], standard.unitlist[6], standard.unitlist[9], standard.unitlist[13]}, Eliminations: []Candidate{{76, 8}}},
+			"Finned X-Wing: in row 3 and row 7, 8 can only go in column 1 and column 5 or in the fin r7c6, so it can be eliminated from the squares of these units that see the fin r7c6: r9c5.",
+		},
+		{
+			Step{Technique: XYWing, Digits: Digits(0).Add(1).Add(2).Add(3), Squares: []Index{0, 8, 72}, Eliminations: []Candidate{{80, 3}}},
+			"XY-Wing: the pivot r1c1 has 1 or 2, and either way one of the pincers r1c9 and r9c1 has 3, so it can be eliminated from the squares that see both pincers: r9c9.",
+		},
+	}
+	for _, tt := range tests {
+		if got := DescribeStep(&tt.step); got != tt.want {
+			t.Errorf("got description\n%q\nwant\n%q", got, tt.want)
+		}
+	}
+}
+
+func TestDescribeUnit(t *testing.T) {
+	g := standard.WithDiagonals()
+	var names []string
+	for _, unit := range g.unitlist {
+		names = append(names, g.describeUnit(unit))
+	}
+	for i, want := range map[int]string{0: "row 1", 9: "column 1", 22: "the box of r4c4", 27: "the diagonal of r1c1", 28: "the diagonal of r1c9"} {
+		if names[i] != want {
+			t.Errorf("got unit %v named %q, want %q", i, names[i], want)
+		}
+	}
+
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := jg.describeUnit(jg.unitlist[18]); name != "the region of r1c1" {
+		t.Errorf("got region named %q", name)
+	}
+}
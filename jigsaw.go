@@ -0,0 +1,124 @@
+package sudoku
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// WithRegions returns a new grid that's a copy of g with the boxes replaced by
+// irregular regions, as in "jigsaw" Sudoku. g itself is not modified.
+//
+// regionMap assigns a region to each square: it's a string with NumSquares
+// runes, one per square in Index order, where squares marked with the same
+// rune belong to the same region. Whitespace is ignored, so the map can be
+// given as a multi-line string, e.g. for a 4x4 grid:
+//
+//	AABB
+//	ACBB
+//	ACCD
+//	CCDD
+//
+// There have to be exactly Size regions; each region has to have Size squares
+// and be connected (every square of a region is reachable from the others
+// through squares of the same region sharing an edge). An error is returned if
+// regionMap doesn't satisfy these requirements, or if g is a multi-grid.
+func (g *Grid) WithRegions(regionMap string) (*Grid, error) {
+	if len(g.subgrids) > 1 {
+		return nil, fmt.Errorf("irregular regions are not supported on multi-grids")
+	}
+	regions, err := g.parseRegions(regionMap)
+	if err != nil {
+		return nil, err
+	}
+
+	ng := *g
+	ng.regions = regions
+	ng.build()
+	return &ng, nil
+}
+
+// parseRegions parses and validates a region map for g; see WithRegions for
+// details. It returns a slice mapping each square to its region index.
+func (g *Grid) parseRegions(regionMap string) ([]int, error) {
+	numSquares := g.NumSquares()
+
+	// Region indices are assigned in order of first appearance in the map;
+	// labels[ri] is the rune marking region ri.
+	regionIndex := make(map[rune]int)
+	var labels []rune
+	var regions []int
+	var regionSizes []int
+	for _, r := range regionMap {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		ri, ok := regionIndex[r]
+		if !ok {
+			ri = len(labels)
+			regionIndex[r] = ri
+			labels = append(labels, r)
+			regionSizes = append(regionSizes, 0)
+		}
+		regions = append(regions, ri)
+		regionSizes[ri]++
+	}
+
+	if len(regions) != numSquares {
+		return nil, fmt.Errorf("got %v squares in region map, want %v", len(regions), numSquares)
+	}
+	if len(regionSizes) != g.size {
+		return nil, fmt.Errorf("got %v regions, want %v", len(regionSizes), g.size)
+	}
+	for ri, r := range labels {
+		if regionSizes[ri] != g.size {
+			return nil, fmt.Errorf("region %c has %v squares, want %v", r, regionSizes[ri], g.size)
+		}
+	}
+
+	// Check connectivity: flood-fill each region starting from its first
+	// square, and make sure all the region's squares are reached.
+	visited := make([]bool, numSquares)
+	for ri, r := range labels {
+		start := -1
+		for sq := range regions {
+			if regions[sq] == ri {
+				start = sq
+				break
+			}
+		}
+
+		numReached := 0
+		stack := []Index{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			sq := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			numReached++
+
+			for _, nb := range g.orthogonalNeighbors(sq) {
+				if !visited[nb] && regions[nb] == ri {
+					visited[nb] = true
+					stack = append(stack, nb)
+				}
+			}
+		}
+
+		if numReached != g.size {
+			return nil, fmt.Errorf("region %c is not connected", r)
+		}
+	}
+
+	return regions, nil
+}
+
+// orthogonalNeighbors returns the squares that share an edge with sq.
+func (g *Grid) orthogonalNeighbors(sq Index) []Index {
+	var nbs []Index
+	row, col := g.row(sq), g.col(sq)
+	for _, pos := range [][2]int{{row - 1, col}, {row + 1, col}, {row, col - 1}, {row, col + 1}} {
+		if nb, ok := g.squareAt(pos[0], pos[1]); ok {
+			nbs = append(nbs, nb)
+		}
+	}
+	return nbs
+}
@@ -0,0 +1,169 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"slices"
+)
+
+var jigsawRegions string = `
+AAABBBCCC
+AAABBCCCC
+AADBBBBCC
+ADDEEEEFF
+DDDEEEFFF
+DDDEHFFIF
+GGGEHHIIF
+GGGHHHIII
+GGGHHHIII`
+
+// A jigsaw puzzle with the jigsawRegions region map.
+var jigsawBoard string = `
+. 5 . . . . . . 8
+. . . 3 5 . 7 1 .
+2 . . 9 . 8 . . .
+. 6 . 1 3 . . 8 .
+. . . . 8 . 6 9 .
+. 9 . 5 . . 3 . 4
+. . . . . 3 . . .
+4 . . 8 . 6 . . .
+1 . . . . . . 7 .`
+
+func TestWithRegions(t *testing.T) {
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jg.unitlist) != 27 {
+		t.Errorf("got %v units, want 27", len(jg.unitlist))
+	}
+
+	// Region D of square 20 replaces its 3x3 box.
+	wantRegion := Unit{20, 28, 29, 36, 37, 38, 45, 46, 47}
+	gotRegion := jg.units[20][2]
+	if !slices.Equal(gotRegion, wantRegion) {
+		t.Errorf("got region %v, want %v", gotRegion, wantRegion)
+	}
+
+	// The original grid is unchanged.
+	if standard.regions != nil || len(standard.units[20][2]) != 9 || standard.units[20][2][0] != 0 {
+		t.Errorf("WithRegions modified the original grid")
+	}
+}
+
+func TestWithRegionsErrors(t *testing.T) {
+	g4, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		regionMap string
+		wantErr   string
+	}{
+		{"AABB AABB CCDD CCD", "squares"},
+		{"AABB AABB CCDD CCEE", "regions"},
+		{"AABB AABB CCCD CCDD", "region C has 5"},
+		{"ABAB CDCD ABAB CDCD", "not connected"},
+	}
+
+	for _, tt := range tests {
+		_, err := g4.WithRegions(tt.regionMap)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("WithRegions(%q): got error %v, want %q", tt.regionMap, err, tt.wantErr)
+		}
+	}
+
+	if _, err := g4.WithRegions("AABB\nACBB\nACCD\nCCDD\n"); err == nil {
+		t.Errorf("want error for region with 3 squares")
+	}
+	if _, err := g4.WithRegions("AABB\nABBB\nCCDD\nCCDD\n"); err == nil {
+		t.Errorf("want error for region with 3 squares")
+	}
+	if _, err := g4.WithRegions("AABB\nAABB\nCDDD\nCCCD\n"); err != nil {
+		t.Errorf("got error %v for valid region map", err)
+	}
+}
+
+func TestJigsawSolve(t *testing.T) {
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := jg.ParseBoard(jigsawBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := jg.SolveAll(v, -1)
+	if len(vs) != 1 {
+		t.Fatalf("got %v solutions, want 1", len(vs))
+	}
+	if !jg.IsSolved(vs[0]) {
+		t.Errorf("got unsolved board:\n%v", jg.Display(vs[0]))
+	}
+
+	// The solution doesn't satisfy regular 3x3 boxes.
+	if IsSolved(vs[0]) {
+		t.Errorf("expect jigsaw solution to not be a standard solution")
+	}
+
+	vsolved, solved := jg.Solve(v)
+	if !solved || !jg.IsSolved(vsolved) {
+		t.Errorf("expect board to be solved by Solve")
+	}
+}
+
+func TestJigsawGenerate(t *testing.T) {
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	board := jg.Generate(28)
+	solutions := jg.solveAllWithElimination(board, -1)
+	if len(solutions) != 1 {
+		t.Errorf("got %v solutions, want 1", len(solutions))
+	}
+	if !jg.IsSolved(solutions[0]) {
+		t.Errorf("got unsolved board")
+	}
+
+	board = jg.GenerateSymmetrical(30)
+	solutions = jg.solveAllWithElimination(board, -1)
+	if len(solutions) != 1 {
+		t.Errorf("got %v solutions, want 1", len(solutions))
+	}
+}
+
+func TestJigsawDisplay(t *testing.T) {
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := jg.ParseBoard(jigsawBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := jg.DisplayAsInput(v)
+	if strings.ContainsAny(input, "|-") {
+		t.Errorf("expect no box separators in jigsaw board, got:\n%v", input)
+	}
+
+	var buf bytes.Buffer
+	jg.DisplayAsSVG(&buf, v, 1.0)
+	svgText := buf.String()
+
+	// The region map has 48 internal edges between different regions.
+	if got := strings.Count(svgText, "<line"); got != 48 {
+		t.Errorf("got %v lines in SVG, want 48", got)
+	}
+	// 81 squares and a border around the board.
+	if got := strings.Count(svgText, "<rect"); got != 82 {
+		t.Errorf("got %v rects in SVG, want 82", got)
+	}
+}
@@ -0,0 +1,434 @@
+package sudoku
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/eliben/go-sudoku/svg"
+)
+
+// Cage is a "killer Sudoku" cage: a group of squares whose digits have to be
+// different from each other and add up to Sum.
+type Cage struct {
+	Sum     int
+	Squares []Index
+}
+
+// WithCages returns a new grid that's a copy of g with the given killer cages
+// added. g itself is not modified. The cages don't have to cover the whole
+// board, but a square can't belong to more than one cage, including the cages
+// g already has. An error is returned if the cages are invalid, e.g. if a
+// cage's sum can't be reached with different digits.
+func (g *Grid) WithCages(cages []Cage) (*Grid, error) {
+	inCage := make([]bool, g.NumSquares())
+	for _, cage := range g.cages {
+		for _, sq := range cage.Squares {
+			inCage[sq] = true
+		}
+	}
+	for _, cage := range cages {
+		if len(cage.Squares) == 0 || len(cage.Squares) > g.size {
+			return nil, fmt.Errorf("cage with %v squares, want 1-%v", len(cage.Squares), g.size)
+		}
+		for i, sq := range cage.Squares {
+			if sq < 0 || sq >= len(inCage) {
+				return nil, fmt.Errorf("cage square %v out of range", sq)
+			}
+			if slices.Contains(cage.Squares[:i], sq) {
+				return nil, fmt.Errorf("square %v appears more than once in cage", sq)
+			}
+			if inCage[sq] {
+				return nil, fmt.Errorf("square %v appears in more than one cage", sq)
+			}
+			inCage[sq] = true
+		}
+	}
+
+	ng := *g
+	for _, cage := range cages {
+		cc := newCageConstraint(g, cage)
+		if len(cc.combos) == 0 {
+			return nil, fmt.Errorf("no %v different digits add up to %v", len(cage.Squares), cage.Sum)
+		}
+		ng.addConstraint(cc)
+		ng.peerGroups = append(ng.peerGroups[:len(ng.peerGroups):len(ng.peerGroups)], cage.Squares)
+	}
+	ng.cages = append(ng.cages[:len(ng.cages):len(ng.cages)], cages...)
+	ng.build()
+	return &ng, nil
+}
+
+// Cages returns the killer cages of g.
+func (g *Grid) Cages() []Cage {
+	return g.cages
+}
+
+// ParseCages parses a textual list of killer cages for grid g. Each cage is on
+// a separate line, with the sum followed by a colon and then a list of the
+// indices of its squares, separated by spaces or commas. Empty lines and lines
+// starting with '#' are ignored. For example:
+//
+//	# A cage of two squares at the top-left corner, and a cage below them
+//	3: 0 1
+//	15: 9 10 18
+func (g *Grid) ParseCages(str string) ([]Cage, error) {
+	var cages []Cage
+
+	scanner := bufio.NewScanner(strings.NewReader(str))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sumStr, squaresStr, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("cage %q: missing ':'", line)
+		}
+		sum, err := strconv.Atoi(strings.TrimSpace(sumStr))
+		if err != nil {
+			return nil, fmt.Errorf("cage %q: invalid sum: %w", line, err)
+		}
+
+		squares, err := g.parseSquareList(squaresStr)
+		if err != nil {
+			return nil, fmt.Errorf("cage %q: %w", line, err)
+		}
+		cages = append(cages, Cage{Sum: sum, Squares: squares})
+	}
+	return cages, scanner.Err()
+}
+
+// parseSquareList parses a list of square indices separated by spaces or
+// commas, checking that they're valid squares of g.
+func (g *Grid) parseSquareList(str string) ([]Index, error) {
+	var squares []Index
+	for _, field := range strings.FieldsFunc(str, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		sq, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid square: %w", err)
+		}
+		if sq < 0 || sq >= g.NumSquares() {
+			return nil, fmt.Errorf("square %v out of range", sq)
+		}
+		squares = append(squares, sq)
+	}
+	if len(squares) == 0 {
+		return nil, fmt.Errorf("no squares")
+	}
+	return squares, nil
+}
+
+// cageConstraint is the constraint for a single killer cage.
+type cageConstraint struct {
+	cage Cage
+
+	// combos holds all the sets of len(cage.Squares) different digits that add
+	// up to cage.Sum.
+	combos []Digits
+}
+
+func newCageConstraint(g *Grid, cage Cage) *cageConstraint {
+	cc := &cageConstraint{cage: cage}
+	for d := Digits(0); d <= g.full; d++ {
+		if d&^g.full == 0 && d.Size() == len(cage.Squares) && digitsSum(d) == cage.Sum {
+			cc.combos = append(cc.combos, d)
+		}
+	}
+	return cc
+}
+
+func (cc *cageConstraint) squares() []Index {
+	return cc.cage.Squares
+}
+
+// propagate restricts the candidates of each square in the cage to digits
+// that appear in some possible combination of the cage's digits.
+func (cc *cageConstraint) propagate(g *Grid, values Values) bool {
+	var allowed [maxGridSize]Digits
+	if !addComboSupport(values, cc.cage.Squares, cc.combos, allowed[:]) {
+		return false
+	}
+	for i, sq := range cc.cage.Squares {
+		if !g.eliminateDigits(values, sq, values[sq]&^allowed[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cc *cageConstraint) satisfied(values Values) bool {
+	var dset Digits
+	for _, sq := range cc.cage.Squares {
+		if dset&values[sq] != 0 {
+			return false
+		}
+		dset |= values[sq]
+	}
+	return digitsSum(dset) == cc.cage.Sum
+}
+
+// encode adds an auxiliary variable for each combination of the cage's
+// digits; one of them is true, and each square has a digit of the true
+// combination. Since the squares have different digits, they have all of the
+// combination's digits.
+func (cc *cageConstraint) encode(enc *cnfEncoder) {
+	comboVars := make([]int, len(cc.combos))
+	for i, combo := range cc.combos {
+		comboVars[i] = enc.newVariable()
+		for _, sq := range cc.cage.Squares {
+			clause := []int{-comboVars[i]}
+			for d := combo; d != 0; d &= d - 1 {
+				clause = append(clause, enc.variable(sq, uint16(minDigit(d))))
+			}
+			enc.add(clause...)
+		}
+	}
+	enc.add(comboVars...)
+}
+
+// addComboSupport finds which of combos are possible for squares, where each
+// combination is a set of len(squares) different digits the squares have to
+// take. A combination is possible if every square has a candidate in it, and
+// the squares' candidates cover all of it. For each possible combination, the
+// candidates of squares[i] in it are added to allowed[i]. It returns false if
+// none of the combinations are possible.
+func addComboSupport(values Values, squares []Index, combos []Digits, allowed []Digits) bool {
+	found := false
+
+ComboLoop:
+	for _, combo := range combos {
+		var union Digits
+		for _, sq := range squares {
+			inter := values[sq] & combo
+			if inter == 0 {
+				continue ComboLoop
+			}
+			union |= inter
+		}
+		if union != combo {
+			continue
+		}
+
+		found = true
+		for i, sq := range squares {
+			allowed[i] |= values[sq] & combo
+		}
+	}
+	return found
+}
+
+// digitsSum returns the sum of the digits in d.
+func digitsSum(d Digits) int {
+	sum := 0
+	for d != 0 {
+		sum += bits.TrailingZeros32(uint32(d))
+		d &= d - 1
+	}
+	return sum
+}
+
+// eliminateDigits eliminates all the digits in ds from values[square], with the
+// same semantics as eliminate.
+func (g *Grid) eliminateDigits(values Values, square Index, ds Digits) bool {
+	for d := uint16(1); d <= uint16(g.size); d++ {
+		if ds.IsMember(d) && !g.eliminate(values, square, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateKiller generates a random killer Sudoku puzzle with a single
+// solution on grid g. It returns a new grid with the puzzle's cages (as
+// created by WithCages), and the board with the puzzle's hints.
+//
+// The generator splits a random solved board into connected cages of up to
+// maxCageSize squares, and only adds hints if the cages alone aren't enough
+// for a unique solution; therefore the returned board often has no hints at
+// all. Larger cages make for harder puzzles that are more likely to need
+// hints. Only the Rand field of options is used.
+func (g *Grid) GenerateKiller(maxCageSize int, options ...GenerateOptions) (*Grid, Values) {
+	rng := randOrGlobal(generateOptions("GenerateKiller", options).Rand)
+	solution, solved := g.Solve(g.EmptyBoard(), SolveOptions{Randomize: true, Rand: rng})
+	if !solved || !g.IsSolved(solution) {
+		log.Fatal("unable to generate solved board from empty")
+	}
+
+	kg, err := g.WithCages(g.randomCages(solution, maxCageSize, rng))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return kg, kg.addHintsUntilUnique(kg.EmptyBoard(), solution, rng)
+}
+
+// randomCages splits the board into random connected cages of up to
+// maxCageSize squares, such that the digits in each cage of solution are
+// different. The cages are drawn from rng.
+func (g *Grid) randomCages(solution Values, maxCageSize int, rng *rand.Rand) []Cage {
+	numSquares := g.NumSquares()
+	cageOf := make([]int, numSquares)
+	for sq := range cageOf {
+		cageOf[sq] = -1
+	}
+
+	var cageSquares [][]Index
+	for _, start := range rng.Perm(numSquares) {
+		if cageOf[start] >= 0 {
+			continue
+		}
+
+		ci := len(cageSquares)
+		cage := []Index{start}
+		cageOf[start] = ci
+		digits := solution[start]
+		targetSize := 1 + rng.Intn(maxCageSize)
+		if maxCageSize > 1 && targetSize == 1 {
+			targetSize = 2
+		}
+
+		// Grow the cage with random neighbors that are not in other cages and
+		// don't have a digit that's already in this cage.
+		for len(cage) < targetSize {
+			var frontier []Index
+			for _, sq := range cage {
+				for _, nb := range g.orthogonalNeighbors(sq) {
+					if cageOf[nb] < 0 && solution[nb]&digits == 0 {
+						frontier = append(frontier, nb)
+					}
+				}
+			}
+			if len(frontier) == 0 {
+				break
+			}
+			nb := frontier[rng.Intn(len(frontier))]
+			cage = append(cage, nb)
+			cageOf[nb] = ci
+			digits |= solution[nb]
+		}
+		cageSquares = append(cageSquares, cage)
+	}
+
+	// Cages with a single square are like hints; merge them into a neighboring
+	// cage where possible.
+	for ci, cage := range cageSquares {
+		if len(cage) != 1 {
+			continue
+		}
+		sq := cage[0]
+		for _, nb := range g.orthogonalNeighbors(sq) {
+			nci := cageOf[nb]
+			target := cageSquares[nci]
+			if nci == ci || len(target) >= maxCageSize {
+				continue
+			}
+			var digits Digits
+			for _, tsq := range target {
+				digits |= solution[tsq]
+			}
+			if digits&solution[sq] == 0 {
+				cageSquares[nci] = append(target, sq)
+				cageSquares[ci] = nil
+				cageOf[sq] = nci
+				break
+			}
+		}
+	}
+
+	var cages []Cage
+	for _, squares := range cageSquares {
+		if len(squares) == 0 {
+			continue
+		}
+		var digits Digits
+		for _, sq := range squares {
+			digits |= solution[sq]
+		}
+		cages = append(cages, Cage{Sum: digitsSum(digits), Squares: squares})
+	}
+	return cages
+}
+
+// drawCages draws the cages of g onto canvas, as dashed outlines inside the
+// cages' squares with the sum of each cage at its top-left corner.
+func (g *Grid) drawCages(canvas *svg.Canvas, startX, startY, cellsize int) {
+	cageOf := make([]int, g.NumSquares())
+	for sq := range cageOf {
+		cageOf[sq] = -1
+	}
+	for ci, cage := range g.cages {
+		for _, sq := range cage.Squares {
+			cageOf[sq] = ci
+		}
+	}
+
+	// inCage checks whether the square at (row, col) is in cage ci.
+	inCage := func(row, col, ci int) bool {
+		sq, ok := g.squareAt(row, col)
+		return ok && cageOf[sq] == ci
+	}
+
+	inset := cellsize / 10
+	style := "stroke:black; stroke-width:1; stroke-dasharray:4,3"
+	for ci, cage := range g.cages {
+		for _, sq := range cage.Squares {
+			row, col := g.row(sq), g.col(sq)
+			x := startX + col*cellsize
+			y := startY + row*cellsize
+
+			// extent computes where a side's line starts or ends: inset from the
+			// square's edge if the neighbor in that direction is outside the cage,
+			// at the edge if it's in the cage, and past the edge (to meet the
+			// neighbor's outline) if the diagonal neighbor is in the cage too.
+			extent := func(nbRow, nbCol, diagRow, diagCol int) int {
+				if !inCage(nbRow, nbCol, ci) {
+					return inset
+				} else if inCage(diagRow, diagCol, ci) {
+					return -inset
+				}
+				return 0
+			}
+
+			if !inCage(row-1, col, ci) {
+				x1 := x + extent(row, col-1, row-1, col-1)
+				x2 := x + cellsize - extent(row, col+1, row-1, col+1)
+				canvas.Line(x1, y+inset, x2, y+inset, style)
+			}
+			if !inCage(row+1, col, ci) {
+				x1 := x + extent(row, col-1, row+1, col-1)
+				x2 := x + cellsize - extent(row, col+1, row+1, col+1)
+				canvas.Line(x1, y+cellsize-inset, x2, y+cellsize-inset, style)
+			}
+			if !inCage(row, col-1, ci) {
+				y1 := y + extent(row-1, col, row-1, col-1)
+				y2 := y + cellsize - extent(row+1, col, row+1, col-1)
+				canvas.Line(x+inset, y1, x+inset, y2, style)
+			}
+			if !inCage(row, col+1, ci) {
+				y1 := y + extent(row-1, col, row-1, col+1)
+				y2 := y + cellsize - extent(row+1, col, row+1, col+1)
+				canvas.Line(x+cellsize-inset, y1, x+cellsize-inset, y2, style)
+			}
+		}
+
+		// The sum is written in the top-left square of the cage.
+		labelSq := cage.Squares[0]
+		for _, sq := range cage.Squares {
+			if sq < labelSq {
+				labelSq = sq
+			}
+		}
+		fontsize := cellsize / 5
+		canvas.Text(startX+g.col(labelSq)*cellsize+inset+2, startY+g.row(labelSq)*cellsize+inset+fontsize, strconv.Itoa(cage.Sum),
+			fmt.Sprintf("font-family:Helvetica; font-size:%vpx; fill:black; paint-order:stroke; stroke:white; stroke-width:3px", fontsize))
+	}
+}
@@ -0,0 +1,230 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// A killer puzzle with a unique solution and no hints.
+var killerCages string = `
+# sum: squares
+17: 21 12
+11: 14 23 32
+28: 78 77 79 68
+13: 41 42
+14: 5 6 7
+14: 50 59 60 51
+10: 26 35
+8: 73 64
+11: 69 70
+23: 17 16 15 8
+7: 55 54
+12: 47 56
+15: 44 53 62
+13: 25 34 43
+15: 19 10 18
+15: 9 0 1 2
+8: 48 39
+12: 28 29 30 27
+16: 36 45
+15: 46 37 38
+15: 52 61
+4: 71 80
+10: 75 66
+21: 22 31 40
+8: 65 74
+9: 24 33
+15: 3 4 13
+10: 57 58 49
+15: 11 20
+13: 63 72
+8: 76 67
+`
+
+func TestParseCages(t *testing.T) {
+	cages, err := standard.ParseCages(killerCages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cages) != 31 {
+		t.Errorf("got %v cages, want 31", len(cages))
+	}
+	if cages[2].Sum != 28 || len(cages[2].Squares) != 4 || cages[2].Squares[3] != 68 {
+		t.Errorf("got cage %v, want 28: 78 77 79 68", cages[2])
+	}
+
+	for _, bad := range []string{"17 21 12", "x: 1 2", "17: 1 x", "17: 1 81", "17:"} {
+		if _, err := standard.ParseCages(bad); err == nil {
+			t.Errorf("ParseCages(%q): got no error", bad)
+		}
+	}
+}
+
+func TestWithCagesErrors(t *testing.T) {
+	var tests = []struct {
+		cages   []Cage
+		wantErr string
+	}{
+		{[]Cage{{Sum: 3, Squares: []Index{0, 1}}, {Sum: 4, Squares: []Index{1, 2}}}, "more than one cage"},
+		{[]Cage{{Sum: 4, Squares: []Index{0, 1, 0}}}, "more than once in cage"},
+		{[]Cage{{Sum: 2, Squares: []Index{0, 1}}}, "no 2 different digits"},
+		{[]Cage{{Sum: 18, Squares: []Index{0, 1}}}, "no 2 different digits"},
+		{[]Cage{{Sum: 3, Squares: []Index{0, 90}}}, "out of range"},
+		{[]Cage{{Sum: 3}}, "cage with 0 squares"},
+	}
+
+	for _, tt := range tests {
+		_, err := standard.WithCages(tt.cages)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("WithCages(%v): got error %v, want %q", tt.cages, err, tt.wantErr)
+		}
+	}
+
+	// The new cages can't overlap the cages the grid already has.
+	g, err := standard.WithCages([]Cage{{Sum: 3, Squares: []Index{0, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.WithCages([]Cage{{Sum: 4, Squares: []Index{1, 2}}}); err == nil || !strings.Contains(err.Error(), "more than one cage") {
+		t.Errorf("got error %v, want more than one cage", err)
+	}
+	if _, err := g.WithCages([]Cage{{Sum: 4, Squares: []Index{2, 3}}}); err != nil {
+		t.Errorf("got error %v for a separate cage", err)
+	}
+}
+
+func TestCagePropagation(t *testing.T) {
+	// A 2-square cage with sum 3 must have digits 1 and 2; a 3-square cage with
+	// sum 24 must have 7, 8 and 9.
+	kg, err := standard.WithCages([]Cage{
+		{Sum: 3, Squares: []Index{0, 1}},
+		{Sum: 24, Squares: []Index{9, 10, 11}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := kg.EmptyBoard()
+	if !kg.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	d12 := Digits(0).Add(1).Add(2)
+	if v[0] != d12 || v[1] != d12 {
+		t.Errorf("got %v, %v, want 12 for both", v[0], v[1])
+	}
+	d789 := Digits(0).Add(7).Add(8).Add(9)
+	if v[9] != d789 || v[10] != d789 || v[11] != d789 {
+		t.Errorf("got %v, %v, %v, want 789", v[9], v[10], v[11])
+	}
+	// Assigning a square of a cage eliminates its digit from the other squares
+	// of the cage.
+	if !kg.assign(v, 10, 9) {
+		t.Fatal("contradiction on assign")
+	}
+	if v[9] != Digits(0).Add(7).Add(8) {
+		t.Errorf("got %v, want 78", v[9])
+	}
+}
+
+func TestKillerSolve(t *testing.T) {
+	cages, err := standard.ParseCages(killerCages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kg, err := standard.WithCages(cages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := kg.EmptyBoard()
+	if !kg.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	vs := kg.SolveAll(v, -1)
+	if len(vs) != 1 {
+		t.Fatalf("got %v solutions, want 1", len(vs))
+	}
+	if !kg.IsSolved(vs[0]) || !IsSolved(vs[0]) {
+		t.Errorf("got unsolved board:\n%v", kg.Display(vs[0]))
+	}
+
+	// Swapping two digits in the solution keeps it a valid Sudoku, but breaks
+	// the cages.
+	swapped := make(Values, len(vs[0]))
+	for sq, d := range vs[0] {
+		switch d {
+		case SingleDigitSet(1):
+			swapped[sq] = SingleDigitSet(2)
+		case SingleDigitSet(2):
+			swapped[sq] = SingleDigitSet(1)
+		default:
+			swapped[sq] = d
+		}
+	}
+	if !IsSolved(swapped) || kg.IsSolved(swapped) {
+		t.Errorf("expect swapped board to be solved as Sudoku but not as killer")
+	}
+
+	// Solving without elimination works too.
+	vsolved, solved := kg.Solve(kg.EmptyBoard())
+	if !solved || !kg.IsSolved(vsolved) {
+		t.Errorf("expect board to be solved without elimination")
+	}
+}
+
+func TestGenerateKiller(t *testing.T) {
+	for _, maxCageSize := range []int{2, 3, 5} {
+		kg, board := standard.GenerateKiller(maxCageSize)
+
+		covered := 0
+		for _, cage := range kg.Cages() {
+			if len(cage.Squares) > maxCageSize {
+				t.Errorf("got cage with %v squares, max %v", len(cage.Squares), maxCageSize)
+			}
+			covered += len(cage.Squares)
+		}
+		if covered != 81 {
+			t.Errorf("got %v squares covered by cages, want 81", covered)
+		}
+
+		solutions := kg.solveAllWithElimination(board, -1)
+		if len(solutions) != 1 {
+			t.Fatalf("got %v solutions, want 1", len(solutions))
+		}
+		if !kg.IsSolved(solutions[0]) {
+			t.Errorf("got unsolved board")
+		}
+	}
+
+	// Killer puzzles can be generated on other grids too.
+	g6, err := NewGrid(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kg, board := g6.GenerateKiller(3)
+	if solutions := kg.solveAllWithElimination(board, -1); len(solutions) != 1 {
+		t.Errorf("got %v solutions, want 1", len(solutions))
+	}
+}
+
+func TestKillerSVG(t *testing.T) {
+	kg, err := standard.WithCages([]Cage{
+		{Sum: 3, Squares: []Index{0, 1}},
+		{Sum: 24, Squares: []Index{9, 10, 11}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	kg.DisplayAsSVG(&buf, kg.EmptyBoard(), 1.0)
+	svgText := buf.String()
+
+	// Each cage is a rectangle with 4 dashed sides when all its squares are in
+	// a row; the sides are made of 6 and 8 lines.
+	if got := strings.Count(svgText, "stroke-dasharray"); got != 14 {
+		t.Errorf("got %v dashed lines, want 14", got)
+	}
+	if !strings.Contains(svgText, ">3</text>") || !strings.Contains(svgText, ">24</text>") {
+		t.Errorf("expect cage sums in SVG")
+	}
+}
@@ -0,0 +1,361 @@
+package sudoku
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/eliben/go-sudoku/svg"
+)
+
+// EdgeMarker is a marker placed on the edge between two orthogonally adjacent
+// squares, specifying a relation between their digits: the Kropki dots
+// (WhiteDot, BlackDot) and the XV markers (MarkerX, MarkerV).
+type EdgeMarker int
+
+const (
+	// WhiteDot marks squares with consecutive digits, like 4 and 5.
+	WhiteDot EdgeMarker = iota
+
+	// BlackDot marks squares where one digit is double the other, like 3 and 6.
+	BlackDot
+
+	// MarkerX marks squares whose digits add up to 10.
+	MarkerX
+
+	// MarkerV marks squares whose digits add up to 5.
+	MarkerV
+)
+
+// edgeMarkerRunes maps edge markers to the runes representing them in the
+// textual format of ParseEdges.
+const edgeMarkerRunes = "WBXV"
+
+func (m EdgeMarker) String() string {
+	if m < 0 || int(m) >= len(edgeMarkerRunes) {
+		return fmt.Sprintf("EdgeMarker(%d)", int(m))
+	}
+	return edgeMarkerRunes[m : m+1]
+}
+
+// isDot checks whether m is one of the Kropki dots.
+func (m EdgeMarker) isDot() bool {
+	return m == WhiteDot || m == BlackDot
+}
+
+// excludes checks whether the absence of marker n on an edge with marker m
+// (or with no marker, if marked is false) rules out the relation of n, when n
+// is a negative constraint. The Kropki dots don't exclude each other, since
+// 1 and 2 are both consecutive and in a 1:2 ratio, and can have either dot.
+func (m EdgeMarker) excludes(n EdgeMarker, marked bool) bool {
+	return !marked || (n != m && !(n.isDot() && m.isDot()))
+}
+
+// related checks whether digits a and b satisfy the relation marked by m.
+func (m EdgeMarker) related(a, b int) bool {
+	switch m {
+	case WhiteDot:
+		return a-b == 1 || b-a == 1
+	case BlackDot:
+		return a == 2*b || b == 2*a
+	case MarkerX:
+		return a+b == 10
+	case MarkerV:
+		return a+b == 5
+	}
+	return false
+}
+
+// Edge is an edge marker between squares Sq1 and Sq2, which have to be
+// orthogonally adjacent.
+type Edge struct {
+	Sq1, Sq2 Index
+	Marker   EdgeMarker
+}
+
+// WithEdges returns a new grid that's a copy of g with the given edge markers
+// added. g itself is not modified.
+//
+// The markers in negative are treated as "negative constraints": when all the
+// markers of a kind are given, the absence of a marker between two adjacent
+// squares means their digits don't satisfy its relation. For example, with
+// negative set to WhiteDot and BlackDot (the usual rule of Kropki puzzles),
+// adjacent squares without a dot can't have consecutive digits or digits in a
+// 1:2 ratio. This also applies to edges with other markers: with negative
+// WhiteDot, the squares of a V edge can't have 2 and 3. The only exception is
+// the pair 1 and 2, which can have either dot.
+//
+// An error is returned if an edge's squares aren't adjacent, or if there's
+// more than one marker on the same edge.
+func (g *Grid) WithEdges(edges []Edge, negative ...EdgeMarker) (*Grid, error) {
+	markers := make(map[[2]Index]EdgeMarker)
+	for _, e := range g.edges {
+		markers[edgeKey(e.Sq1, e.Sq2)] = e.Marker
+	}
+	for _, e := range edges {
+		if e.Marker < WhiteDot || e.Marker > MarkerV {
+			return nil, fmt.Errorf("invalid edge marker %v", e.Marker)
+		}
+		if e.Sq1 < 0 || e.Sq1 >= g.NumSquares() || e.Sq2 < 0 || e.Sq2 >= g.NumSquares() {
+			return nil, fmt.Errorf("edge %v-%v out of range", e.Sq1, e.Sq2)
+		}
+		if !slices.Contains(g.orthogonalNeighbors(e.Sq1), e.Sq2) {
+			return nil, fmt.Errorf("edge squares %v and %v are not adjacent", e.Sq1, e.Sq2)
+		}
+		key := edgeKey(e.Sq1, e.Sq2)
+		if _, ok := markers[key]; ok {
+			return nil, fmt.Errorf("more than one marker on edge %v-%v", e.Sq1, e.Sq2)
+		}
+		markers[key] = e.Marker
+	}
+
+	ng := *g
+	for _, e := range edges {
+		ng.addConstraint(newEdgeConstraint(e.Sq1, e.Sq2, func(a, b int) bool {
+			return e.Marker.related(a, b)
+		}))
+	}
+	ng.edges = append(ng.edges[:len(ng.edges):len(ng.edges)], edges...)
+
+	if len(negative) > 0 {
+		// Each edge gets a constraint that rules out the relations of the
+		// negative markers it doesn't have.
+		for sq := 0; sq < g.NumSquares(); sq++ {
+			for _, nb := range g.orthogonalNeighbors(sq) {
+				if nb < sq {
+					continue
+				}
+				marker, marked := markers[edgeKey(sq, nb)]
+				var excluded []EdgeMarker
+				for _, n := range negative {
+					if marker.excludes(n, marked) {
+						excluded = append(excluded, n)
+					}
+				}
+				if len(excluded) == 0 {
+					continue
+				}
+				ng.addConstraint(newEdgeConstraint(sq, nb, func(a, b int) bool {
+					for _, n := range excluded {
+						if n.related(a, b) {
+							return false
+						}
+					}
+					return true
+				}))
+			}
+		}
+	}
+
+	ng.build()
+	return &ng, nil
+}
+
+// Edges returns the edge markers of g.
+func (g *Grid) Edges() []Edge {
+	return g.edges
+}
+
+// edgeKey returns a key identifying the edge between sq1 and sq2, regardless
+// of their order.
+func edgeKey(sq1, sq2 Index) [2]Index {
+	return [2]Index{min(sq1, sq2), max(sq1, sq2)}
+}
+
+// ParseEdges parses a textual list of edge markers for grid g. Each marker is
+// on a separate line, with the marker's letter (W for a white dot, B for a
+// black dot, X or V) followed by a colon and the indices of the two squares
+// it's between, separated by spaces or commas. Empty lines and lines starting
+// with '#' are ignored. For example:
+//
+//	# A white dot between the first two squares, and an X below them
+//	W: 0 1
+//	X: 9 10
+func (g *Grid) ParseEdges(str string) ([]Edge, error) {
+	var edges []Edge
+
+	scanner := bufio.NewScanner(strings.NewReader(str))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		markerStr, squaresStr, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("edge %q: missing ':'", line)
+		}
+		markerStr = strings.ToUpper(strings.TrimSpace(markerStr))
+		marker := strings.Index(edgeMarkerRunes, markerStr)
+		if len(markerStr) != 1 || marker < 0 {
+			return nil, fmt.Errorf("edge %q: invalid marker %q", line, markerStr)
+		}
+
+		squares, err := g.parseSquareList(squaresStr)
+		if err != nil {
+			return nil, fmt.Errorf("edge %q: %w", line, err)
+		}
+		if len(squares) != 2 {
+			return nil, fmt.Errorf("edge %q: got %v squares, want 2", line, len(squares))
+		}
+		edges = append(edges, Edge{Sq1: squares[0], Sq2: squares[1], Marker: EdgeMarker(marker)})
+	}
+	return edges, scanner.Err()
+}
+
+// edgeConstraint is a constraint on the digits of two squares.
+type edgeConstraint struct {
+	sqs [2]Index
+
+	// compatible maps each digit of one square to the set of digits the other
+	// square can have with it. The relations are all symmetric, so it works
+	// in both directions.
+	compatible [maxGridSize + 1]Digits
+}
+
+func newEdgeConstraint(sq1, sq2 Index, related func(a, b int) bool) *edgeConstraint {
+	ec := &edgeConstraint{sqs: [2]Index{sq1, sq2}}
+	for a := 1; a <= maxGridSize; a++ {
+		for b := 1; b <= maxGridSize; b++ {
+			if related(a, b) {
+				ec.compatible[a] = ec.compatible[a].Add(uint16(b))
+			}
+		}
+	}
+	return ec
+}
+
+func (ec *edgeConstraint) squares() []Index {
+	return ec.sqs[:]
+}
+
+// propagate eliminates the candidates of each square that aren't compatible
+// with any candidate of the other square.
+func (ec *edgeConstraint) propagate(g *Grid, values Values) bool {
+	for i, sq := range ec.sqs {
+		other := values[ec.sqs[1-i]]
+		var unsupported Digits
+		for d := values[sq]; d != 0; d &= d - 1 {
+			digit := minDigit(d)
+			if ec.compatible[digit]&other == 0 {
+				unsupported = unsupported.Add(uint16(digit))
+			}
+		}
+		if !g.eliminateDigits(values, sq, unsupported) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ec *edgeConstraint) satisfied(values Values) bool {
+	return ec.compatible[minDigit(values[ec.sqs[0]])]&values[ec.sqs[1]] != 0
+}
+
+// encode forbids each pair of digits that aren't compatible.
+func (ec *edgeConstraint) encode(enc *cnfEncoder) {
+	for a := uint16(1); a <= uint16(enc.g.size); a++ {
+		for b := uint16(1); b <= uint16(enc.g.size); b++ {
+			if !ec.compatible[a].IsMember(b) {
+				enc.add(-enc.variable(ec.sqs[0], a), -enc.variable(ec.sqs[1], b))
+			}
+		}
+	}
+}
+
+// GenerateWithEdges generates a random puzzle with edge markers of the given
+// kinds and a single solution on grid g. It returns a new grid with the
+// puzzle's edges (as created by WithEdges), and the board with the puzzle's
+// hints.
+//
+// The generator marks every edge of a random solved board whose squares
+// satisfy the relation of one of the markers, and then adds hints until the
+// puzzle has a single solution. If negative is true, all of markers are
+// treated as negative constraints, which typically leaves fewer hints; solved
+// boards with edges that can't be marked under these constraints (like 2 and
+// 3 with WhiteDot and MarkerV) are skipped. Only the Rand field of options is
+// used.
+func (g *Grid) GenerateWithEdges(markers []EdgeMarker, negative bool, options ...GenerateOptions) (*Grid, Values) {
+	rng := randOrGlobal(generateOptions("GenerateWithEdges", options).Rand)
+	var solution Values
+	var edges []Edge
+	for ok := false; !ok; {
+		var solved bool
+		solution, solved = g.Solve(g.EmptyBoard(), SolveOptions{Randomize: true, Rand: rng})
+		if !solved || !g.IsSolved(solution) {
+			log.Fatal("unable to generate solved board from empty")
+		}
+		edges, ok = g.markEdges(solution, markers, negative, rng)
+	}
+
+	var negativeMarkers []EdgeMarker
+	if negative {
+		negativeMarkers = markers
+	}
+	eg, err := g.WithEdges(edges, negativeMarkers...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return eg, eg.addHintsUntilUnique(eg.EmptyBoard(), solution, rng)
+}
+
+// markEdges returns the edges of solution marked with markers, for
+// GenerateWithEdges. It returns false if negative is true and the squares of
+// an edge satisfy the relations of several markers that exclude each other,
+// so the edge can't be marked.
+func (g *Grid) markEdges(solution Values, markers []EdgeMarker, negative bool, rng *rand.Rand) ([]Edge, bool) {
+	var edges []Edge
+	for sq := 0; sq < g.NumSquares(); sq++ {
+		for _, nb := range g.orthogonalNeighbors(sq) {
+			if nb < sq {
+				continue
+			}
+			var related []EdgeMarker
+			for _, m := range markers {
+				if m.related(minDigit(solution[sq]), minDigit(solution[nb])) {
+					related = append(related, m)
+				}
+			}
+			// Some pairs satisfy several relations (e.g. 1 and 2 are both
+			// consecutive and in a 1:2 ratio); pick one of them at random,
+			// among the ones that don't exclude the others.
+			var candidates []EdgeMarker
+			for _, m := range related {
+				if !negative || !slices.ContainsFunc(related, func(n EdgeMarker) bool { return m.excludes(n, true) }) {
+					candidates = append(candidates, m)
+				}
+			}
+			if len(related) > 0 && len(candidates) == 0 {
+				return nil, false
+			}
+			if len(candidates) > 0 {
+				edges = append(edges, Edge{Sq1: sq, Sq2: nb, Marker: candidates[rng.Intn(len(candidates))]})
+			}
+		}
+	}
+	return edges, true
+}
+
+// drawEdges draws the edge markers of g onto canvas, centered on the border
+// between their squares: dots as small circles, and X and V as letters.
+func (g *Grid) drawEdges(canvas *svg.Canvas, startX, startY, cellsize int) {
+	fontsize := cellsize / 4
+	for _, e := range g.edges {
+		// The center of the border is the midpoint of the squares' centers.
+		x := startX + (g.col(e.Sq1)+g.col(e.Sq2)+1)*cellsize/2
+		y := startY + (g.row(e.Sq1)+g.row(e.Sq2)+1)*cellsize/2
+
+		switch e.Marker {
+		case WhiteDot:
+			canvas.Circle(x, y, cellsize/10, "stroke:black; stroke-width:2; fill:white")
+		case BlackDot:
+			canvas.Circle(x, y, cellsize/10, "stroke:black; stroke-width:2; fill:black")
+		default:
+			canvas.Text(x, y, e.Marker.String(), fmt.Sprintf("text-anchor:middle; dominant-baseline:middle; font-family:Helvetica; font-weight:bold; font-size:%vpx; fill:black; paint-order:stroke; stroke:white; stroke-width:4px", fontsize))
+		}
+	}
+}
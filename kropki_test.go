@@ -0,0 +1,178 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseEdges(t *testing.T) {
+	edges, err := standard.ParseEdges(`
+	# comment
+	W: 0 1
+	b: 1, 10
+	X: 20 29
+	V: 79 80`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Edge{{0, 1, WhiteDot}, {1, 10, BlackDot}, {20, 29, MarkerX}, {79, 80, MarkerV}}
+	if len(edges) != len(want) {
+		t.Fatalf("got %v edges, want %v", len(edges), len(want))
+	}
+	for i := range want {
+		if edges[i] != want[i] {
+			t.Errorf("got edge %v, want %v", edges[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"W 0 1", "Q: 0 1", "WB: 0 1", "W: 0", "W: 0 1 2", "W: 0 81"} {
+		if _, err := standard.ParseEdges(bad); err == nil {
+			t.Errorf("ParseEdges(%q): got no error", bad)
+		}
+	}
+}
+
+func TestWithEdgesErrors(t *testing.T) {
+	var tests = []struct {
+		edges   []Edge
+		wantErr string
+	}{
+		{[]Edge{{0, 2, WhiteDot}}, "not adjacent"},
+		{[]Edge{{8, 9, WhiteDot}}, "not adjacent"},
+		{[]Edge{{0, 10, MarkerX}}, "not adjacent"},
+		{[]Edge{{0, 1, WhiteDot}, {1, 0, BlackDot}}, "more than one marker"},
+		{[]Edge{{80, 81, MarkerV}}, "out of range"},
+		{[]Edge{{0, 1, EdgeMarker(7)}}, "invalid edge marker"},
+	}
+
+	for _, tt := range tests {
+		_, err := standard.WithEdges(tt.edges)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("WithEdges(%v): got error %v, want %q", tt.edges, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEdgePropagation(t *testing.T) {
+	eg, err := standard.WithEdges([]Edge{{0, 1, BlackDot}, {9, 18, MarkerV}, {30, 31, WhiteDot}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := eg.EmptyBoard()
+	if !eg.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	d123468 := Digits(0).Add(1).Add(2).Add(3).Add(4).Add(6).Add(8)
+	if v[0] != d123468 || v[1] != d123468 {
+		t.Errorf("got %v, %v, want 123468 for both", v[0], v[1])
+	}
+	if v[9] != digitsUpTo(4) || v[18] != digitsUpTo(4) {
+		t.Errorf("got %v, %v, want 1234 for both", v[9], v[18])
+	}
+
+	if !eg.assign(v, 0, 3) || v[1] != SingleDigitSet(6) {
+		t.Errorf("got %v, want 6", v[1])
+	}
+	if !eg.assign(v, 30, 5) || v[31] != Digits(0).Add(4).Add(6) {
+		t.Errorf("got %v, want 46", v[31])
+	}
+}
+
+func TestNegativeEdges(t *testing.T) {
+	// Without any dots, negative Kropki constraints rule out consecutive
+	// digits and 1:2 ratios next to an assigned square.
+	eg, err := standard.WithEdges(nil, WhiteDot, BlackDot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := eg.EmptyBoard()
+	if !eg.assign(v, 40, 4) {
+		t.Fatal("contradiction on assign")
+	}
+	for _, nb := range []Index{31, 39, 41, 49} {
+		if v[nb] != Digits(0).Add(1).Add(6).Add(7).Add(9) {
+			t.Errorf("got %v for square %v, want 1679", v[nb], nb)
+		}
+	}
+	if v[30] != eg.full.Remove(4) {
+		t.Errorf("got %v for diagonal square, want all but 4", v[30])
+	}
+
+	// A marker on an edge takes precedence over the negative constraints.
+	eg, err = standard.WithEdges([]Edge{{40, 41, WhiteDot}}, WhiteDot, BlackDot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v = eg.EmptyBoard()
+	if !eg.assign(v, 40, 4) || v[41] != Digits(0).Add(3).Add(5) {
+		t.Errorf("got %v, want 35", v[41])
+	}
+
+	// Without a white dot, the squares of a V edge can't have 2 and 3.
+	eg, err = standard.WithEdges([]Edge{{40, 41, MarkerV}}, WhiteDot, BlackDot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v = eg.EmptyBoard()
+	if eg.assign(v, 40, 2) {
+		t.Errorf("got %v for square 41, want a contradiction", v[41])
+	}
+	v = eg.EmptyBoard()
+	if !eg.assign(v, 40, 1) || v[41] != SingleDigitSet(4) {
+		t.Errorf("got %v, want 4", v[41])
+	}
+}
+
+func TestGenerateWithEdges(t *testing.T) {
+	for _, tt := range []struct {
+		markers  []EdgeMarker
+		negative bool
+	}{
+		{[]EdgeMarker{WhiteDot, BlackDot}, true},
+		{[]EdgeMarker{WhiteDot, BlackDot}, false},
+		{[]EdgeMarker{MarkerX, MarkerV}, true},
+		{[]EdgeMarker{WhiteDot, BlackDot, MarkerX, MarkerV}, true},
+	} {
+		eg, board := standard.GenerateWithEdges(tt.markers, tt.negative)
+		if len(eg.Edges()) == 0 {
+			t.Errorf("got no edges")
+		}
+
+		solutions := eg.solveAllWithElimination(board, -1)
+		if len(solutions) != 1 {
+			t.Fatalf("got %v solutions, want 1", len(solutions))
+		}
+		if !eg.IsSolved(solutions[0]) {
+			t.Errorf("got unsolved board")
+		}
+		for _, e := range eg.Edges() {
+			if !e.Marker.related(minDigit(solutions[0][e.Sq1]), minDigit(solutions[0][e.Sq2])) {
+				t.Errorf("edge %v not satisfied by solution", e)
+			}
+		}
+	}
+}
+
+func TestEdgesSVG(t *testing.T) {
+	eg, err := standard.WithEdges([]Edge{{0, 1, WhiteDot}, {1, 10, BlackDot}, {20, 29, MarkerX}, {79, 80, MarkerV}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	eg.DisplayAsSVG(&buf, eg.EmptyBoard(), 1.0)
+	svgText := buf.String()
+
+	if got := strings.Count(svgText, "<circle"); got != 2 {
+		t.Errorf("got %v dots, want 2", got)
+	}
+	if !strings.Contains(svgText, ">X</text>") || !strings.Contains(svgText, ">V</text>") {
+		t.Errorf("expect X and V markers in SVG")
+	}
+	// The white dot is centered on the border between squares 0 and 1.
+	if !strings.Contains(svgText, `<circle cx="130" cy="90"`) {
+		t.Errorf("expect white dot at (130, 90)")
+	}
+}
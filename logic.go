@@ -0,0 +1,560 @@
+package sudoku
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Technique is a technique human solvers use to make deductions about a board,
+// without guessing. The techniques of each kind are ordered from the simplest
+// to the most difficult; SolveLogically tries them in order of difficulty
+// across the kinds, for example trying XY-Wings between X-Wings and Swordfish.
+type Technique int
+
+const (
+	// NakedSingle places the only candidate of a square.
+	NakedSingle Technique = iota
+
+	// HiddenSingle places a digit in the only square of a unit that has it as
+	// a candidate.
+	HiddenSingle
+
+	// Pointing eliminates a digit whose candidates in a box (or another unit
+	// that isn't a row or a column) are all in one row or column, from the
+	// rest of that row or column: one of them has the digit, so it can't be
+	// anywhere else in the line.
+	Pointing
+
+	// BoxLineReduction is the converse of Pointing: when the candidates of a
+	// digit in a row or column are all in one box, the digit is eliminated
+	// from the rest of the box.
+	BoxLineReduction
+
+	// NakedPair, NakedTriple and NakedQuad eliminate the digits of N squares
+	// of a unit that have only N candidates between them from the other
+	// squares of the unit, since the N squares have all of these digits.
+	NakedPair
+	NakedTriple
+	NakedQuad
+
+	// HiddenPair, HiddenTriple and HiddenQuad eliminate other candidates from
+	// the only N squares of a unit that have N digits as candidates, since
+	// these squares have all of these digits.
+	HiddenPair
+	HiddenTriple
+	HiddenQuad
+
+	// XWing, Swordfish and Jellyfish eliminate a digit whose candidates in N
+	// rows (the base units) are all in N columns (the cover units) from the
+	// rest of these columns, or the other way around: the N rows have the
+	// digit in N different columns, so it's already placed in each of the
+	// columns.
+	XWing
+	Swordfish
+	Jellyfish
+
+	// The finned fish are fish with some candidates in their base units, the
+	// fins, outside of their cover units. Either one of the fins has the
+	// digit, or the fish without the fins does, so the digit is only
+	// eliminated from the squares of the cover units that see all the fins.
+	// In a sashimi fish, one of the base units has a single candidate in the
+	// cover units, so the fish without the fins would have solved it instead.
+	FinnedXWing
+	SashimiXWing
+	FinnedSwordfish
+	SashimiSwordfish
+	FinnedJellyfish
+	SashimiJellyfish
+
+	// XYWing eliminates digit z from the squares that see both "pincers" with
+	// candidates xz and yz, which see a "pivot" square with candidates xy:
+	// whichever of x and y the pivot has, one of the pincers has z.
+	XYWing
+
+	// XYZWing is like XYWing, with a pivot that also has z as a candidate:
+	// one of the three squares has z, so it's eliminated from the squares
+	// that see all of them.
+	XYZWing
+
+	// WWing eliminates digit y from the squares that see two squares with
+	// candidates xy, when x has only two squares in some unit, each seeing one
+	// of them: one of the two has x, so the other one has y.
+	WWing
+
+	// SimpleColoring colors the squares of the conjugate pairs of a digit,
+	// the two squares of units with only two candidates of the digit, in two
+	// alternating colors; all the squares of one of the colors have the
+	// digit. When two squares of the same color see each other, the other
+	// color has the digit; otherwise, the digit is eliminated from the
+	// squares that see both colors.
+	SimpleColoring
+
+	// MultiColoring colors two clusters of conjugate pairs of a digit: when a
+	// color of one cluster sees a color of the other, one of their opposite
+	// colors has the digit, which is eliminated from the squares that see
+	// both of them.
+	MultiColoring
+
+	// XCycle and AIC find alternating inference chains of candidates, which
+	// alternate strong and weak links (see Link): if the candidate at one
+	// end of such a chain is false, the one at the other end is true, so the
+	// candidates that see both ends are eliminated. When the chain is a loop,
+	// each of its weak links is also strong, and when both ends are the same
+	// candidate, it's placed. The chains of XCycle are of a single digit,
+	// and the ones of AIC of several.
+	XCycle
+	AIC
+)
+
+var techniqueNames = []string{
+	NakedSingle:      "naked single",
+	HiddenSingle:     "hidden single",
+	Pointing:         "pointing",
+	BoxLineReduction: "box/line reduction",
+	NakedPair:        "naked pair",
+	NakedTriple:      "naked triple",
+	NakedQuad:        "naked quad",
+	HiddenPair:       "hidden pair",
+	HiddenTriple:     "hidden triple",
+	HiddenQuad:       "hidden quad",
+	XWing:            "X-Wing",
+	Swordfish:        "Swordfish",
+	Jellyfish:        "Jellyfish",
+	FinnedXWing:      "finned X-Wing",
+	SashimiXWing:     "sashimi X-Wing",
+	FinnedSwordfish:  "finned Swordfish",
+	SashimiSwordfish: "sashimi Swordfish",
+	FinnedJellyfish:  "finned Jellyfish",
+	SashimiJellyfish: "sashimi Jellyfish",
+	XYWing:           "XY-Wing",
+	XYZWing:          "XYZ-Wing",
+	WWing:            "W-Wing",
+	SimpleColoring:   "simple coloring",
+	MultiColoring:    "multi-coloring",
+	XCycle:           "X-cycle",
+	AIC:              "alternating inference chain",
+}
+
+func (t Technique) String() string {
+	if t < 0 || int(t) >= len(techniqueNames) {
+		return fmt.Sprintf("Technique(%d)", int(t))
+	}
+	return techniqueNames[t]
+}
+
+// Candidate is a digit candidate of a square.
+type Candidate struct {
+	Square Index
+	Digit  uint16
+}
+
+// Link is a link between two candidates in a chain. A strong link means that
+// at least one of the candidates is true, so when one is false the other is
+// true, and a weak link that at most one of them is, so when one is true the
+// other is false.
+type Link struct {
+	From, To Candidate
+	Strong   bool
+}
+
+// Step is a deduction made with a single application of a technique, as
+// recorded by SolveLogically.
+type Step struct {
+	Technique Technique
+
+	// Digits are the digits of the pattern the technique found: the digit of
+	// a single, locked candidates, a fish or a coloring, the digits of a
+	// subset, the digits of a wing, or the digits of the candidates of a
+	// chain.
+	Digits Digits
+
+	// Squares are the squares of the pattern: the square of a single, the
+	// squares of a subset, the candidates of the digit of locked candidates or
+	// a fish (including its fins), the pivot and the two pincers of an XY-Wing
+	// or an XYZ-Wing, the two squares of a W-Wing followed by the two of its
+	// strong link, the colored squares of a coloring, or the squares of the
+	// candidates of a chain, in order.
+	Squares []Index
+
+	// Fins are the squares of Squares that are the fins of a finned or
+	// sashimi fish.
+	Fins []Index
+
+	// Units are the units the pattern was found in: the unit of a hidden
+	// single or a subset; for locked candidates, the unit with the candidates
+	// and the unit they were eliminated from; for a fish, its base units
+	// followed by the same number of cover units (see FishUnits); for a
+	// W-Wing, the unit of its strong link. It's nil for the other techniques,
+	// which aren't found in units.
+	Units []Unit
+
+	// Links are the links of the chain of an XY-Wing, a W-Wing, an X-cycle or
+	// an AIC, from one end to the other; the last link of a loop goes back to
+	// its first candidate. For an XYZ-Wing, they're the links from z in each
+	// pincer to the pivot, one pincer after the other. For a coloring, they're
+	// the conjugate pairs of its clusters, as strong links.
+	Links []Link
+
+	// Colors are the squares of each color of a coloring: the two colors of
+	// its cluster, or of each of its two clusters for multi-coloring. The
+	// first color of simple coloring is the one without the digit when two of
+	// its squares see each other, and the first color of each cluster of
+	// multi-coloring is the one that sees the other cluster.
+	Colors [][]Index
+
+	// Placements are the digits placed by the step, and Eliminations the
+	// candidates it eliminated. Placing a digit also removes it from the
+	// candidates of the square's peers; these removals aren't listed in
+	// Eliminations.
+	Placements   []Candidate
+	Eliminations []Candidate
+}
+
+// SolveLogically solves the board values like a human would, without
+// guessing: it repeatedly finds the simplest technique that places a digit or
+// eliminates candidates, until the board is solved or none of the techniques
+// applies. It returns the board it ended with, the steps it made, and whether
+// the board is solved. values isn't modified.
+//
+// The squares of values with a single candidate are taken to be solved, and
+// their digits are removed from the candidates of their peers before the
+// first step. The other squares can have fewer candidates than all the
+// digits, as pencil marks.
+//
+// The techniques use the units and peers of the grid, but not its additional
+// constraints like killer cages' sums, so the boards of such variants may be
+// left unsolved. The steps are only meaningful for boards with a solution.
+func SolveLogically(values Values) (Values, []Step, bool) {
+	return standard.SolveLogically(values)
+}
+
+// SolveLogically is the equivalent of the package-level SolveLogically for
+// boards of grid g.
+func (g *Grid) SolveLogically(values Values) (Values, []Step, bool) {
+	ls := g.newLogicState(values)
+	var steps []Step
+	for !ls.done() {
+		step := ls.nextStep()
+		if step == nil {
+			break
+		}
+		ls.apply(step)
+		steps = append(steps, *step)
+	}
+	return ls.values, steps, g.IsSolved(ls.values)
+}
+
+// logicState is a board solved by SolveLogically: the candidates of its
+// squares, and which of its squares are solved. Unlike the boards of the
+// search, a square with a single candidate isn't solved until the candidate
+// is placed by a step (as a naked single).
+type logicState struct {
+	g      *Grid
+	values Values
+	solved []bool
+
+	// peerMatrix has whether each pair of squares are peers, for sees; it's
+	// created on first use.
+	peerMatrix []bool
+}
+
+// newLogicState creates the state for solving a copy of values, where the
+// squares with a single candidate are solved.
+func (g *Grid) newLogicState(values Values) *logicState {
+	ls := &logicState{g: g, values: slices.Clone(values), solved: make([]bool, len(values))}
+	for sq, d := range values {
+		ls.solved[sq] = d.Size() == 1
+	}
+	for sq, d := range values {
+		if ls.solved[sq] {
+			ls.removeFromPeers(sq, d)
+		}
+	}
+	return ls
+}
+
+// done checks whether all the squares are solved.
+func (ls *logicState) done() bool {
+	return !slices.Contains(ls.solved, false)
+}
+
+// removeFromPeers removes the digits d from the candidates of the unsolved
+// peers of square sq.
+func (ls *logicState) removeFromPeers(sq Index, d Digits) {
+	for _, peer := range ls.g.peers[sq] {
+		if !ls.solved[peer] {
+			ls.values[peer] &^= d
+		}
+	}
+}
+
+// apply makes the placements and eliminations of step.
+func (ls *logicState) apply(step *Step) {
+	for _, c := range step.Eliminations {
+		ls.values[c.Square] = ls.values[c.Square].Remove(c.Digit)
+	}
+	for _, c := range step.Placements {
+		ls.values[c.Square] = SingleDigitSet(c.Digit)
+		ls.solved[c.Square] = true
+		ls.removeFromPeers(c.Square, ls.values[c.Square])
+	}
+}
+
+// logicTechniques are the functions finding the steps of the techniques, in
+// the order they're tried; each returns nil if its technique doesn't apply.
+var logicTechniques = []func(ls *logicState) *Step{
+	(*logicState).findNakedSingle,
+	(*logicState).findHiddenSingle,
+	(*logicState).findLockedCandidates,
+	func(ls *logicState) *Step { return ls.findNakedSubset(2) },
+	func(ls *logicState) *Step { return ls.findHiddenSubset(2) },
+	func(ls *logicState) *Step { return ls.findNakedSubset(3) },
+	func(ls *logicState) *Step { return ls.findHiddenSubset(3) },
+	func(ls *logicState) *Step { return ls.findNakedSubset(4) },
+	func(ls *logicState) *Step { return ls.findHiddenSubset(4) },
+	func(ls *logicState) *Step { return ls.findFish(2, false) },
+	func(ls *logicState) *Step { return ls.findFish(2, true) },
+	(*logicState).findSimpleColoring,
+	(*logicState).findXYWing,
+	func(ls *logicState) *Step { return ls.findFish(3, false) },
+	func(ls *logicState) *Step { return ls.findFish(3, true) },
+	(*logicState).findXYZWing,
+	(*logicState).findWWing,
+	(*logicState).findMultiColoring,
+	func(ls *logicState) *Step { return ls.findFish(4, false) },
+	func(ls *logicState) *Step { return ls.findFish(4, true) },
+	(*logicState).findXCycle,
+	(*logicState).findAIC,
+}
+
+// nextStep returns the step of the first technique that applies, or nil if
+// none does.
+func (ls *logicState) nextStep() *Step {
+	for _, find := range logicTechniques {
+		if step := find(ls); step != nil {
+			return step
+		}
+	}
+	return nil
+}
+
+// placed returns the digits of the solved squares of unit.
+func (ls *logicState) placed(unit Unit) Digits {
+	var d Digits
+	for _, sq := range unit {
+		if ls.solved[sq] {
+			d |= ls.values[sq]
+		}
+	}
+	return d
+}
+
+// positions returns the unsolved squares of unit that have digit d as a
+// candidate.
+func (ls *logicState) positions(unit Unit, d uint16) []Index {
+	var squares []Index
+	for _, sq := range unit {
+		if !ls.solved[sq] && ls.values[sq].IsMember(d) {
+			squares = append(squares, sq)
+		}
+	}
+	return squares
+}
+
+// sees checks whether squares a and b are peers.
+func (ls *logicState) sees(a, b Index) bool {
+	n := len(ls.values)
+	if ls.peerMatrix == nil {
+		ls.peerMatrix = make([]bool, n*n)
+		for sq, peers := range ls.g.peers {
+			for _, peer := range peers {
+				ls.peerMatrix[sq*n+peer] = true
+			}
+		}
+	}
+	return ls.peerMatrix[a*n+b]
+}
+
+// eliminations returns the candidates in digits of the unsolved squares that
+// aren't in exclude.
+func (ls *logicState) eliminations(squares []Index, digits Digits, exclude func(Index) bool) []Candidate {
+	var elims []Candidate
+	for _, sq := range squares {
+		if ls.solved[sq] || exclude(sq) {
+			continue
+		}
+		for d := uint16(1); d <= uint16(ls.g.size); d++ {
+			if digits.IsMember(d) && ls.values[sq].IsMember(d) {
+				elims = append(elims, Candidate{sq, d})
+			}
+		}
+	}
+	return elims
+}
+
+func (ls *logicState) findNakedSingle() *Step {
+	for sq, d := range ls.values {
+		if !ls.solved[sq] && d.Size() == 1 {
+			return &Step{
+				Technique:  NakedSingle,
+				Digits:     d,
+				Squares:    []Index{sq},
+				Placements: []Candidate{{sq, d.SingleMemberDigit()}},
+			}
+		}
+	}
+	return nil
+}
+
+func (ls *logicState) findHiddenSingle() *Step {
+	for _, unit := range ls.g.unitlist {
+		placed := ls.placed(unit)
+		for d := uint16(1); d <= uint16(ls.g.size); d++ {
+			if placed.IsMember(d) {
+				continue
+			}
+			if squares := ls.positions(unit, d); len(squares) == 1 {
+				return &Step{
+					Technique:  HiddenSingle,
+					Digits:     SingleDigitSet(d),
+					Squares:    squares,
+					Units:      []Unit{unit},
+					Placements: []Candidate{{squares[0], d}},
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findLockedCandidates finds the steps of both Pointing and BoxLineReduction:
+// a digit whose candidates in a unit are all in another unit is eliminated
+// from the rest of the other unit.
+func (ls *logicState) findLockedCandidates() *Step {
+	for _, unit := range ls.g.unitlist {
+		placed := ls.placed(unit)
+		for d := uint16(1); d <= uint16(ls.g.size); d++ {
+			if placed.IsMember(d) {
+				continue
+			}
+			squares := ls.positions(unit, d)
+			if len(squares) < 2 {
+				continue
+			}
+			for _, other := range ls.g.units[squares[0]] {
+				if slices.Equal(other, unit) || !containsAll(other, squares) {
+					continue
+				}
+				elims := ls.eliminations(other, SingleDigitSet(d), func(sq Index) bool {
+					return slices.Contains(unit, sq)
+				})
+				if len(elims) > 0 {
+					technique := Pointing
+					if ls.g.isLine(unit) {
+						technique = BoxLineReduction
+					}
+					return &Step{
+						Technique:    technique,
+						Digits:       SingleDigitSet(d),
+						Squares:      squares,
+						Units:        []Unit{unit, other},
+						Eliminations: elims,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findNakedSubset finds the steps of naked subsets of n squares.
+func (ls *logicState) findNakedSubset(n int) *Step {
+	for _, unit := range ls.g.unitlist {
+		if step := ls.nakedSubset(unit, n); step != nil {
+			return step
+		}
+	}
+	return nil
+}
+
+// findHiddenSubset finds the steps of hidden subsets of n digits.
+func (ls *logicState) findHiddenSubset(n int) *Step {
+	for _, unit := range ls.g.unitlist {
+		if step := ls.hiddenSubset(unit, n); step != nil {
+			return step
+		}
+	}
+	return nil
+}
+
+// lines returns the rows and the columns among the units of g.
+func (g *Grid) lines() (rows, cols []Unit) {
+	for _, unit := range g.unitlist {
+		if g.isLine(unit) {
+			if g.row(unit[0]) == g.row(unit[1]) {
+				rows = append(rows, unit)
+			} else {
+				cols = append(cols, unit)
+			}
+		}
+	}
+	return rows, cols
+}
+
+// isLine checks whether unit is a row or a column.
+func (g *Grid) isLine(unit Unit) bool {
+	sameRow, sameCol := true, true
+	for _, sq := range unit[1:] {
+		sameRow = sameRow && g.row(sq) == g.row(unit[0])
+		sameCol = sameCol && g.col(sq) == g.col(unit[0])
+	}
+	return sameRow || sameCol
+}
+
+// containsAll checks whether unit contains all the squares.
+func containsAll(unit Unit, squares []Index) bool {
+	for _, sq := range squares {
+		if !slices.Contains(unit, sq) {
+			return false
+		}
+	}
+	return true
+}
+
+// combinations returns an iterator over the combinations of k of the items,
+// in lexicographic order of their positions. The yielded slice is reused
+// between iterations.
+func combinations[T any](items []T, k int) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		if k > len(items) {
+			return
+		}
+		indices := make([]int, k)
+		for i := range indices {
+			indices[i] = i
+		}
+		combo := make([]T, k)
+		for {
+			for i, idx := range indices {
+				combo[i] = items[idx]
+			}
+			if !yield(combo) {
+				return
+			}
+			// Advance the rightmost index that can move, and reset the ones after
+			// it.
+			i := k - 1
+			for i >= 0 && indices[i] == len(items)-k+i {
+				i--
+			}
+			if i < 0 {
+				return
+			}
+			indices[i]++
+			for j := i + 1; j < k; j++ {
+				indices[j] = indices[j-1] + 1
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package sudoku
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkSteps checks that the steps made by SolveLogically agree with the
+// solution of the board: they only place the solution's digits, and never
+// eliminate them.
+func checkSteps(t *testing.T, steps []Step, solution Values) {
+	t.Helper()
+	for _, step := range steps {
+		for _, c := range step.Placements {
+			if !solution[c.Square].IsMember(c.Digit) {
+				t.Fatalf("%v places %v in square %v, want %v", step.Technique, c.Digit, c.Square, solution[c.Square])
+			}
+		}
+		for _, c := range step.Eliminations {
+			if solution[c.Square].IsMember(c.Digit) {
+				t.Fatalf("%v eliminates the solution's %v from square %v", step.Technique, c.Digit, c.Square)
+			}
+		}
+		if len(step.Placements)+len(step.Eliminations) == 0 {
+			t.Fatalf("%v makes no progress", step.Technique)
+		}
+	}
+}
+
+func TestSolveLogically(t *testing.T) {
+	board := "003020600900305001001806400008102900700000008006708200002609500800203009005010300"
+	v, err := ParseBoard(board, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vcopy := slices.Clone(v)
+	solution, steps, solved := SolveLogically(v)
+	if !solved || !IsSolved(solution) {
+		t.Fatalf("got unsolved board:\n%v", Display(solution))
+	}
+	if !slices.Equal(v, vcopy) {
+		t.Errorf("SolveLogically modified its input values")
+	}
+	if want, _ := Solve(v); !slices.Equal(solution, want) {
+		t.Errorf("got solution\n%v\nwant\n%v", Display(solution), Display(want))
+	}
+	checkSteps(t, steps, solution)
+
+	// This board only needs singles, each of which places a digit in an empty
+	// square.
+	if len(steps) != 81-CountHints(v) {
+		t.Errorf("got %v steps, want %v", len(steps), 81-CountHints(v))
+	}
+	for _, step := range steps {
+		if step.Technique > HiddenSingle || len(step.Placements) != 1 || len(step.Eliminations) != 0 {
+			t.Errorf("got step %+v, want a single", step)
+		}
+	}
+
+	// Without any techniques that apply, the board is left as it is.
+	empty, steps, solved := SolveLogically(EmptyBoard())
+	if solved || len(steps) != 0 || !slices.Equal(empty, EmptyBoard()) {
+		t.Errorf("got %v steps for an empty board, want none", len(steps))
+	}
+}
+
+func TestSolveLogicallyInputs(t *testing.T) {
+	paths, err := filepath.Glob("inputs/*.txt")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no input files: %v", err)
+	}
+
+	// The hard boards need all the techniques, although not all of them can be
+	// solved without guessing.
+	used := make(map[Technique]bool)
+	for _, path := range paths {
+		if strings.Contains(path, "hardlong") {
+			// The board has more than one solution.
+			continue
+		}
+		for _, board := range readInputBoards(t, path) {
+			v, err := ParseBoard(board, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := Solve(v)
+			if v, err = ParseBoard(board, false); err != nil {
+				t.Fatal(err)
+			}
+			solution, steps, solved := SolveLogically(v)
+			if strings.Contains(path, "easy") && !solved {
+				t.Errorf("got unsolved easy board:\n%v", Display(solution))
+			}
+			if solved && !slices.Equal(solution, want) {
+				t.Errorf("got solution\n%v\nwant\n%v", Display(solution), Display(want))
+			}
+			checkSteps(t, steps, want)
+			for _, step := range steps {
+				used[step.Technique] = true
+			}
+		}
+	}
+	for technique := NakedSingle; technique <= AIC; technique++ {
+		// Hidden quads are rare, since they're naked subsets of the other
+		// squares of their unit, which are found first; Jellyfish likewise
+		// come with smaller fish in the other lines.
+		rare := technique == NakedQuad || technique == HiddenQuad ||
+			technique == Jellyfish || technique == FinnedJellyfish || technique == SashimiJellyfish
+		if !used[technique] && !rare {
+			t.Errorf("technique %v wasn't used", technique)
+		}
+	}
+}
+
+func TestLogicNakedPair(t *testing.T) {
+	// As in TestApplyTwinsStrategy, leave only candidates 38 for two squares of
+	// the fifth box, which are also in the fourth row.
+	v := EmptyBoard()
+	d38 := Digits(0).Add(3).Add(8)
+	v[30] = d38
+	v[31] = d38
+
+	ls := standard.newLogicState(v)
+	step := ls.nextStep()
+	if step == nil || step.Technique != NakedPair || step.Digits != d38 || !slices.Equal(step.Squares, []Index{30, 31}) {
+		t.Fatalf("got step %+v, want naked pair", step)
+	}
+	if !slices.EqualFunc(step.Units, []Unit{standard.unitlist[3]}, slices.Equal) || len(step.Eliminations) != 14 {
+		t.Errorf("got step %+v, want 14 eliminations in the fourth row", step)
+	}
+	ls.apply(step)
+	for _, sq := range []Index{27, 28, 29, 32, 33, 34, 35} {
+		if ls.values[sq].IsMember(3) || ls.values[sq].IsMember(8) {
+			t.Errorf("got board[%v]=%s, expect no 3 or 8", sq, ls.values[sq])
+		}
+	}
+
+	// Now 3 is locked in the box in the fourth row, and the simpler box/line
+	// reduction eliminates it from the rest of the box.
+	step = ls.nextStep()
+	if step == nil || step.Technique != BoxLineReduction || step.Digits != SingleDigitSet(3) || len(step.Eliminations) != 6 {
+		t.Errorf("got step %+v, want box/line reduction of 3", step)
+	}
+}
+
+func TestSolveLogicallyGrids(t *testing.T) {
+	g, err := NewGrid(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := g.Generate(12)
+	want, _ := g.Solve(v)
+	solution, steps, solved := g.SolveLogically(v)
+	if solved && !slices.Equal(solution, want) {
+		t.Errorf("got solution\n%v\nwant\n%v", g.Display(solution), g.Display(want))
+	}
+	checkSteps(t, steps, want)
+
+	jg, err := standard.WithRegions(jigsawRegions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = jg.ParseBoard(jigsawBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = jg.Solve(v)
+	solution, steps, solved = jg.SolveLogically(v)
+	if !solved || !slices.Equal(solution, want) {
+		t.Errorf("got solution\n%v\nwant\n%v", jg.Display(solution), jg.Display(want))
+	}
+	checkSteps(t, steps, want)
+
+	sg := NewSamurai()
+	v, err = sg.ParseBoard(samuraiBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = sg.Solve(v)
+	if v, err = sg.ParseBoard(samuraiBoard, false); err != nil {
+		t.Fatal(err)
+	}
+	_, steps, _ = sg.SolveLogically(v)
+	checkSteps(t, steps, want)
+}
+
+func TestLines(t *testing.T) {
+	rows, cols := standard.lines()
+	if len(rows) != 9 || len(cols) != 9 || !slices.Equal(rows[1], standard.unitlist[1]) || !slices.Equal(cols[0], standard.unitlist[9]) {
+		t.Errorf("got rows %v and columns %v", rows, cols)
+	}
+	if standard.isLine(standard.unitlist[18]) {
+		t.Errorf("got box %v as a line", standard.unitlist[18])
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	var got [][]int
+	for combo := range combinations([]int{1, 2, 3, 4}, 2) {
+		got = append(got, slices.Clone(combo))
+	}
+	want := [][]int{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for range combinations([]int{1, 2}, 3) {
+		t.Errorf("got a combination of 3 of 2 items")
+	}
+}
@@ -0,0 +1,218 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// A parallel search splits the top levels of the search tree into subproblems:
+// the boards at the nodes where the split stops, in the order a sequential
+// search would visit them. Each engine splits its search its own way (see
+// engine.split), since the engines branch differently. A pool of workers
+// searches the subproblems, taking them in order, and their results are
+// merged in the same order - so the result doesn't depend on the number of
+// workers or on how they were scheduled.
+
+// parallelSubproblems is the number of subproblems a parallel search aims to
+// split its search into; it's a constant rather than a multiple of the number
+// of workers, so that the results don't depend on the number of workers.
+const parallelSubproblems = 256
+
+// maxSplitDepth is the maximal depth of the search tree to split, in guesses.
+const maxSplitDepth = 8
+
+// subproblem is a board to search in a parallel search, at depth nodes below
+// the root of the search tree.
+type subproblem struct {
+	values Values
+	depth  int
+}
+
+// parallelSearch is the state of a parallel search.
+type parallelSearch struct {
+	g       *Grid
+	e       engine
+	workers int
+
+	// s is the state of the whole search: when the workers are done, it has
+	// the totals of their statistics and the error, if the search was
+	// aborted.
+	s *searchState
+
+	// ctx is cancelled by stop, to stop the workers when the search has the
+	// results it needs. nodes is the shared count of nodes visited, for the
+	// node budget.
+	ctx    context.Context
+	cancel context.CancelFunc
+	nodes  atomic.Uint64
+
+	subproblems []subproblem
+}
+
+// newParallelSearch creates a parallel search of values on grid g with the
+// given number of workers, splitting it into subproblems.
+func newParallelSearch(g *Grid, e engine, s *searchState, workers int, values Values) *parallelSearch {
+	p := &parallelSearch{g: g, e: e, s: s, workers: workers}
+
+	// Split the search deeper and deeper until there are enough subproblems,
+	// or until the search ends before the split's depth. Only the nodes of the
+	// last split are part of the search.
+	var split *searchState
+	for depth := 1; depth <= maxSplitDepth; depth++ {
+		split = unboundedSearch()
+		p.subproblems = p.subproblems[:0]
+		deeper := false
+		e.split(split, values, depth, func(sub subproblem) bool {
+			p.subproblems = append(p.subproblems, sub)
+			deeper = deeper || sub.depth == depth
+			return true
+		})
+		if !deeper || len(p.subproblems) >= parallelSubproblems {
+			break
+		}
+	}
+	s.addStats(split.stats)
+	return p
+}
+
+// split is the propagation engine's split, where maxDepth is the number of
+// guesses where the split stops.
+func (g *Grid) split(s *searchState, values Values, maxDepth int, yield func(subproblem) bool) bool {
+	squareToTry := g.findSquareWithFewestCandidates(values)
+	if squareToTry == -1 || s.depth == maxDepth {
+		return yield(subproblem{values: values, depth: s.depth})
+	}
+
+	s.visit()
+	defer s.leave()
+	for d := uint16(1); d <= uint16(g.size); d++ {
+		if values[squareToTry].IsMember(d) {
+			vcopy := slices.Clone(values)
+			if g.guess(vcopy, squareToTry, d) && !g.split(s, vcopy, maxDepth, yield) {
+				return false
+			}
+			s.backtrack()
+		}
+	}
+	return true
+}
+
+// run runs search on each subproblem i with its board, using the workers. The
+// workers have their own search states, which share the node budget of p.s;
+// search may be called concurrently, and it should call stop when the search
+// has the results it needs.
+func (p *parallelSearch) run(search func(s *searchState, i int, values Values)) {
+	p.ctx, p.cancel = context.WithCancel(p.s.ctx)
+	defer p.cancel()
+	p.nodes.Store(p.s.stats.NumSearches)
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	states := make([]*searchState, min(p.workers, len(p.subproblems)))
+	for w := range states {
+		ws := newSearchState(p.ctx, p.s.maxNodes)
+		ws.sharedNodes = &p.nodes
+		states[w] = ws
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ws.err == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(p.subproblems) {
+					return
+				}
+				ws.depth = p.subproblems[i].depth
+				search(ws, i, p.subproblems[i].values)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The workers' errors can be caused by stop, which isn't an error of the
+	// search; it's only aborted if its own context is done or the budget is
+	// exceeded.
+	budgetExceeded := false
+	for _, ws := range states {
+		p.s.addStats(ws.stats)
+		budgetExceeded = budgetExceeded || errors.Is(ws.err, ErrNodeBudgetExceeded)
+	}
+	if err := p.s.ctx.Err(); err != nil {
+		p.s.err = &SearchAbortedError{Cause: err, Nodes: p.s.stats.NumSearches}
+	} else if budgetExceeded {
+		p.s.err = &SearchAbortedError{Cause: ErrNodeBudgetExceeded, Nodes: p.s.stats.NumSearches}
+	}
+}
+
+// stop stops the workers.
+func (p *parallelSearch) stop() {
+	p.cancel()
+}
+
+// enumerateParallel is like engine.enumerate, but it splits the search among
+// the given number of workers, and it returns the solutions, up to max of them
+// if max > 0. If the search is aborted, it returns the solutions found so far
+// and s.err is set.
+func (g *Grid) enumerateParallel(e engine, s *searchState, workers int, values Values, max int) []Values {
+	p := newParallelSearch(g, e, s, workers, values)
+
+	// Each subproblem's solutions are kept separately, and merged in order.
+	// The workers stop once the subproblems done so far, in order, have max
+	// solutions.
+	var mu sync.Mutex
+	results := make([][]Values, len(p.subproblems))
+	done := make([]bool, len(p.subproblems))
+	numDone, numSolutions := 0, 0
+	p.run(func(ws *searchState, i int, values Values) {
+		var solutions []Values
+		e.enumerate(ws, values, func(solution Values) bool {
+			solutions = append(solutions, solution)
+			return max <= 0 || len(solutions) < max
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		results[i], done[i] = solutions, true
+		for numDone < len(done) && done[numDone] {
+			numSolutions += len(results[numDone])
+			numDone++
+		}
+		if max > 0 && numSolutions >= max {
+			p.stop()
+		}
+	})
+
+	solutions := slices.Concat(results...)
+	if max > 0 && len(solutions) > max {
+		solutions = solutions[:max]
+	}
+	return solutions
+}
+
+// countParallel is like engine.count, but it splits the search among the
+// given number of workers. If the search is aborted, it returns the count so
+// far and s.err is set.
+func (g *Grid) countParallel(e engine, s *searchState, workers int, values Values, limit int) int {
+	// Split the eliminated board, like the sequential count searches it.
+	vcopy := slices.Clone(values)
+	if !g.withStats(&s.stats).EliminateAll(vcopy) {
+		return 0
+	}
+	p := newParallelSearch(g, e, s, workers, vcopy)
+
+	var count atomic.Int64
+	p.run(func(ws *searchState, i int, values Values) {
+		n := count.Add(int64(e.count(ws, values, limit)))
+		if limit > 0 && n >= int64(limit) {
+			p.stop()
+		}
+	})
+
+	if limit > 0 {
+		return min(int(count.Load()), limit)
+	}
+	return int(count.Load())
+}
@@ -0,0 +1,142 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"testing"
+)
+
+func TestParallelSolveAll(t *testing.T) {
+	g4, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := ParseBoard(hardlong, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		g      *Grid
+		values Values
+		max    int
+	}{
+		{g4, g4.EmptyBoard(), -1},
+		{g4, g4.EmptyBoard(), 100},
+		{standard, v, 100},
+		{standard, EmptyBoard(), 50},
+	} {
+		want := tt.g.SolveAll(tt.values, tt.max)
+		var dlxWant []Values
+		for _, workers := range []int{2, 3, 8} {
+			ctx := context.Background()
+			solutions, err := tt.g.SolveAllContext(ctx, tt.values, tt.max, SolveOptions{Workers: workers})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.EqualFunc(solutions, want, slices.Equal) {
+				t.Errorf("got %v solutions with %v workers, want the %v solutions of the sequential search", len(solutions), workers, len(want))
+			}
+
+			// The DLX solutions are in a different order, but it doesn't depend on
+			// the number of workers either.
+			dlxSolutions, err := tt.g.SolveAllContext(ctx, tt.values, tt.max, SolveOptions{Workers: workers, Backend: BackendDLX})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(dlxSolutions) != len(want) {
+				t.Errorf("got %v solutions with DLX, want %v", len(dlxSolutions), len(want))
+			}
+			if dlxWant == nil {
+				dlxWant = dlxSolutions
+			} else if !slices.EqualFunc(dlxSolutions, dlxWant, slices.Equal) {
+				t.Errorf("got different DLX solutions with %v workers", workers)
+			}
+		}
+	}
+}
+
+func TestParallelCount(t *testing.T) {
+	g4, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unique, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	multiple, err := ParseBoard(hardlong, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	contradiction := slices.Clone(unique)
+	contradiction[1] = contradiction[0]
+
+	for _, tt := range []struct {
+		g      *Grid
+		values Values
+		limit  int
+		want   int
+	}{
+		{g4, g4.EmptyBoard(), -1, 288},
+		{g4, g4.EmptyBoard(), 100, 100},
+		{standard, unique, -1, 1},
+		{standard, unique, 2, 1},
+		{standard, contradiction, -1, 0},
+		{standard, multiple, 2, 2},
+		{standard, multiple, 1000, 1000},
+	} {
+		for _, backend := range []Backend{BackendPropagation, BackendDLX, BackendSAT} {
+			var stats SolveStats
+			n := tt.g.CountSolutions(tt.values, tt.limit, SolveOptions{Workers: 4, Backend: backend, Stats: &stats})
+			if n != tt.want {
+				t.Errorf("%v: got %v solutions, want %v", backend, n, tt.want)
+			}
+			if tt.want > 1 && stats.NumSearches == 0 {
+				t.Errorf("%v: got no searches", backend)
+			}
+		}
+	}
+}
+
+func TestParallelAborted(t *testing.T) {
+	sv := NewSolver(SolveOptions{Workers: 4, MaxNodes: 1000})
+	n, err := sv.CountSolutions(context.Background(), EmptyBoard(), -1)
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Errorf("got error %v, want ErrNodeBudgetExceeded", err)
+	}
+	if n == 0 {
+		t.Errorf("got no solutions before the budget was exceeded")
+	}
+	// The workers may exceed the budget a bit, by one node each.
+	if nodes := sv.Stats().NumSearches; nodes < 1000 || nodes > 1004 {
+		t.Errorf("got %v searches, want about 1000", nodes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	solutions, err := SolveAllContext(ctx, EmptyBoard(), -1, SolveOptions{Workers: 4})
+	if !errors.Is(err, context.Canceled) || len(solutions) != 0 {
+		t.Errorf("got %v solutions and error %v, want none and context.Canceled", len(solutions), err)
+	}
+}
+
+func BenchmarkCountSolutionsParallel(b *testing.B) {
+	v, err := ParseBoard(hardlong, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, workers := range []int{1, -1} {
+		name := "sequential"
+		if workers < 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CountSolutions(v, 2000, SolveOptions{Backend: BackendDLX, Workers: workers})
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package sudoku
+
+import "math/rand"
+
+// The randomized functions of this package take a *rand.Rand in their options
+// (see SolveOptions.Rand and GenerateOptions.Rand), so that their results can
+// be reproduced from a seed. When it's nil, they use the global source of
+// math/rand.
+
+// globalRand is a *rand.Rand that uses the global source of math/rand. Unlike
+// the sources created with rand.NewSource, this source is safe for concurrent
+// use, and so are the methods of globalRand used in this package.
+var globalRand = rand.New(globalSource{})
+
+// globalSource is a rand.Source64 that forwards to the global source.
+type globalSource struct{}
+
+func (globalSource) Int63() int64 {
+	return rand.Int63()
+}
+
+func (globalSource) Uint64() uint64 {
+	return rand.Uint64()
+}
+
+func (globalSource) Seed(int64) {
+	panic("the global source can't be seeded through globalSource")
+}
+
+// randOrGlobal returns r if it's not nil, and globalRand otherwise.
+func randOrGlobal(r *rand.Rand) *rand.Rand {
+	if r == nil {
+		return globalRand
+	}
+	return r
+}
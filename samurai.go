@@ -0,0 +1,20 @@
+package sudoku
+
+// samuraiSubgrids are the positions of the five 9x9 grids of Samurai Sudoku
+// on its 21x21 layout: four grids in the corners, and a center grid that
+// shares a corner box with each of them.
+var samuraiSubgrids = [][2]int{{0, 0}, {0, 12}, {6, 6}, {12, 0}, {12, 12}}
+
+// NewSamurai creates a grid for Samurai Sudoku: five overlapping 9x9 grids,
+// with the corner boxes of the center grid shared with the four other grids.
+// The board has 369 squares on a 21x21 layout, indexed row by row. Boards are
+// parsed and displayed in this layout; when parsing, the gaps between the
+// grids (e.g. the 3x6 areas between the top grids) can be left blank or be
+// filled with empty squares.
+func NewSamurai() *Grid {
+	g, err := NewMultiGrid(3, 3, samuraiSubgrids)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
@@ -0,0 +1,215 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// A Samurai puzzle in the standard 21x21 layout.
+var samuraiBoard string = `
+. . . |. 4 7 |3 . . |      |. . . |. 8 . |6 . .
+6 . 8 |. 2 . |5 . . |      |. . 5 |4 6 . |2 7 .
+. . 2 |. . . |. 1 . |      |. . . |. . . |8 . 5
+------+------+------+      +------+------+------
+5 . . |. 7 6 |. 2 . |      |. . . |. . . |. . .
+. . . |. . . |7 . 5 |      |6 . . |7 2 . |. 4 .
+9 . 1 |. 5 . |. . . |      |. 1 . |. . 9 |. . 8
+------+------+------+------+------+------+------
+3 5 . |9 . . |. . . |6 . . |4 . . |6 . . |. . .
+. . . |. . . |. . . |. 1 . |. . . |9 . . |. . .
+4 . . |. . . |. . . |. . 4 |. 7 . |. . . |9 . .
+------+------+------+------+------+------+------
+             |1 . . |. 7 . |. . . |
+             |. 2 9 |. . . |3 8 . |
+             |. . . |. 9 . |. . 7 |
+------+------+------+------+------+------+------
+. . 6 |. . . |. 1 . |8 . . |. . . |. . . |. . 7
+. . . |. . 2 |. . . |. 3 . |. . . |. . . |. . .
+. . . |. . 7 |. . 5 |. . 6 |. . . |. . 9 |. 8 4
+------+------+------+------+------+------+------
+1 . . |8 . . |. 5 . |      |. . . |. 7 . |1 . 2
+. 9 . |. 2 3 |. . 4 |      |3 . 9 |. . . |. . .
+. . . |. . . |. . . |      |. 4 . |3 8 . |. . 6
+------+------+------+      +------+------+------
+6 . 3 |. . . |. . . |      |. 2 . |. . . |4 . .
+. 5 4 |. 8 6 |1 . . |      |. . 7 |. 9 . |2 . 3
+. . 8 |. 4 . |. . . |      |. . 8 |2 4 . |. . .`
+
+func TestNewMultiGridErrors(t *testing.T) {
+	var tests = []struct {
+		subgrids [][2]int
+		wantErr  string
+	}{
+		{nil, "no subgrids"},
+		{[][2]int{{0, 0}, {0, 4}}, "not aligned"},
+		{[][2]int{{0, 0}, {-3, 0}}, "not aligned"},
+		{[][2]int{{0, 0}, {3, 3}, {0, 0}}, "duplicate"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewMultiGrid(3, 3, tt.subgrids)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("NewMultiGrid(%v): got error %v, want %q", tt.subgrids, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSamuraiGeometry(t *testing.T) {
+	g := NewSamurai()
+	if g.NumSquares() != 369 {
+		t.Errorf("got %v squares, want 369", g.NumSquares())
+	}
+	if rows, cols := g.Layout(); rows != 21 || cols != 21 {
+		t.Errorf("got %vx%v layout, want 21x21", rows, cols)
+	}
+
+	// 27 units in each of the 5 grids, but the 4 shared boxes only once.
+	if len(g.unitlist) != 131 {
+		t.Errorf("got %v units, want 131", len(g.unitlist))
+	}
+
+	// Squares in a shared box are in two rows, two columns and one box.
+	shared := g.index(6, 6)
+	if len(g.units[shared]) != 5 {
+		t.Errorf("got %v units for shared square, want 5", len(g.units[shared]))
+	}
+	if len(g.peers[shared]) != 32 {
+		t.Errorf("got %v peers for shared square, want 32", len(g.peers[shared]))
+	}
+	if len(g.peers[0]) != 20 {
+		t.Errorf("got %v peers for corner, want 20", len(g.peers[0]))
+	}
+
+	// The gap between the top grids has no squares; the first square after it
+	// in row 0 is at column 12.
+	if _, ok := g.squareAt(0, 10); ok {
+		t.Errorf("expect no square at (0, 10)")
+	}
+	if sq, ok := g.squareAt(0, 12); !ok || sq != 9 {
+		t.Errorf("got square %v at (0, 12), want 9", sq)
+	}
+}
+
+func TestSamuraiParseAndDisplay(t *testing.T) {
+	g := NewSamurai()
+	v, err := g.ParseBoard(samuraiBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountHints(v) != 100 {
+		t.Errorf("got %v hints, want 100", CountHints(v))
+	}
+
+	// DisplayAsInput produces the same layout it was parsed from, up to
+	// trailing spaces.
+	gotLines := strings.Split(strings.TrimSpace(g.DisplayAsInput(v)), "\n")
+	wantLines := strings.Split(strings.TrimSpace(samuraiBoard), "\n")
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("got %v lines, want %v", len(gotLines), len(wantLines))
+	}
+	for i := range wantLines {
+		if strings.TrimRight(gotLines[i], " ") != wantLines[i] {
+			t.Errorf("got line %q, want %q", gotLines[i], wantLines[i])
+		}
+	}
+
+	// The gaps can also be filled with empty squares.
+	var filled strings.Builder
+	for row := 0; row < 21; row++ {
+		for col := 0; col < 21; col++ {
+			if sq, ok := g.squareAt(row, col); ok && v[sq].Size() == 1 {
+				filled.WriteString(v[sq].String())
+			} else {
+				filled.WriteString("0")
+			}
+		}
+		filled.WriteString("\n")
+	}
+	v2, err := g.ParseBoard(filled.String(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sq := range v {
+		if v[sq] != v2[sq] {
+			t.Fatalf("got %v at square %v, want %v", v2[sq], sq, v[sq])
+		}
+	}
+
+	if _, err := g.ParseBoard("1"+strings.Repeat("0", 440), false); err != nil {
+		t.Errorf("got error %v for a board with a hint at the corner", err)
+	}
+	if _, err := g.ParseBoard(strings.Repeat("0", 10)+"1"+strings.Repeat("0", 430), false); err == nil {
+		t.Errorf("want error for a digit in a gap")
+	}
+}
+
+func TestSamuraiSolve(t *testing.T) {
+	g := NewSamurai()
+	v, err := g.ParseBoard(samuraiBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.HasUniqueSolution(v) {
+		t.Errorf("expect a unique solution")
+	}
+
+	vsolved, solved := g.Solve(v)
+	if !solved || !g.IsSolved(vsolved) {
+		t.Fatalf("expect board to be solved")
+	}
+
+	// Each of the subgrids is a solved standard board.
+	for _, sub := range samuraiSubgrids {
+		var sv Values
+		for row := 0; row < 9; row++ {
+			for col := 0; col < 9; col++ {
+				sv = append(sv, vsolved[g.index(sub[0]+row, sub[1]+col)])
+			}
+		}
+		if !IsSolved(sv) {
+			t.Errorf("got unsolved subgrid at %v:\n%v", sub, Display(sv))
+		}
+	}
+
+	// Removing a couple of hints from the shared box of the center and
+	// top-left grids leaves the board with multiple solutions.
+	v, err = g.ParseBoard(samuraiBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v[g.index(8, 0)] = g.full
+	v[g.index(6, 3)] = g.full
+	v[g.index(5, 0)] = g.full
+	if g.HasUniqueSolution(v) {
+		t.Errorf("expect multiple solutions")
+	}
+}
+
+func TestSamuraiGenerate(t *testing.T) {
+	g := NewSamurai()
+	board := g.GenerateSymmetrical(150)
+	if !g.HasUniqueSolution(board) {
+		t.Errorf("expect a unique solution")
+	}
+}
+
+func TestSamuraiSVG(t *testing.T) {
+	g := NewSamurai()
+	v, err := g.ParseBoard(samuraiBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	g.DisplayAsSVG(&buf, v, 1.0)
+	svgText := buf.String()
+
+	// 369 squares and 41 boxes.
+	if got := strings.Count(svgText, "<rect"); got != 410 {
+		t.Errorf("got %v rects in SVG, want 410", got)
+	}
+	if got := strings.Count(svgText, "</text>"); got != 101 {
+		t.Errorf("got %v texts in SVG, want 101", got)
+	}
+}
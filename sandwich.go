@@ -0,0 +1,335 @@
+package sudoku
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/eliben/go-sudoku/svg"
+)
+
+// Sandwich is a "sandwich Sudoku" clue: the digits between the smallest and
+// the largest digit (1 and 9 on the standard grid) of a row or a column add up
+// to Sum. Line is the index of the row, or of the column if IsColumn is true.
+type Sandwich struct {
+	Line     int
+	IsColumn bool
+	Sum      int
+}
+
+// WithSandwiches returns a new grid that's a copy of g with the given sandwich
+// clues added. g itself is not modified. An error is returned if a clue is
+// invalid (e.g. its sum can't be reached with the digits that can be between
+// the smallest and largest digits), if a row or column has more than one
+// clue, or if g is a multi-grid.
+func (g *Grid) WithSandwiches(sandwiches []Sandwich) (*Grid, error) {
+	if len(g.subgrids) > 1 {
+		return nil, fmt.Errorf("sandwich clues are not supported on multi-grids")
+	}
+
+	hasClue := make(map[[2]int]bool)
+	for _, sw := range g.sandwiches {
+		hasClue[sw.key()] = true
+	}
+	for _, sw := range sandwiches {
+		if sw.Line < 0 || sw.Line >= g.size {
+			return nil, fmt.Errorf("sandwich line %v out of range", sw.Line)
+		}
+		if hasClue[sw.key()] {
+			return nil, fmt.Errorf("more than one sandwich clue for %v", sw.lineName())
+		}
+		hasClue[sw.key()] = true
+	}
+
+	ng := *g
+	for _, sw := range sandwiches {
+		sc := newSandwichConstraint(g, sw)
+		if len(sc.combos) == 0 {
+			return nil, fmt.Errorf("no digits between 1 and %v add up to %v", g.size, sw.Sum)
+		}
+		ng.addConstraint(sc)
+	}
+	ng.sandwiches = append(ng.sandwiches[:len(ng.sandwiches):len(ng.sandwiches)], sandwiches...)
+	ng.build()
+	return &ng, nil
+}
+
+// Sandwiches returns the sandwich clues of g.
+func (g *Grid) Sandwiches() []Sandwich {
+	return g.sandwiches
+}
+
+// key identifies the row or column of the clue.
+func (sw Sandwich) key() [2]int {
+	if sw.IsColumn {
+		return [2]int{1, sw.Line}
+	}
+	return [2]int{0, sw.Line}
+}
+
+// lineName describes the row or column of the clue, for error messages.
+func (sw Sandwich) lineName() string {
+	if sw.IsColumn {
+		return fmt.Sprintf("column %v", sw.Line)
+	}
+	return fmt.Sprintf("row %v", sw.Line)
+}
+
+// ParseSandwiches parses sandwich clues for grid g. The clues are given as two
+// lines, one starting with "rows:" and the other with "cols:", each followed
+// by Size clues for the rows (from top to bottom) or columns (from left to
+// right), separated by spaces or commas. A '.' stands for a row or column
+// without a clue. Empty lines and lines starting with '#' are ignored, and
+// either of the lines can be omitted. For example, for a 4x4 grid:
+//
+//	# The top row has 2 and 3 between 1 and 4
+//	rows: 5 . . 0
+//	cols: . 2 . .
+func (g *Grid) ParseSandwiches(str string) ([]Sandwich, error) {
+	var sandwiches []Sandwich
+
+	scanner := bufio.NewScanner(strings.NewReader(str))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kind, cluesStr, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("sandwich clues %q: missing ':'", line)
+		}
+		var isColumn bool
+		switch strings.TrimSpace(kind) {
+		case "rows":
+			isColumn = false
+		case "cols":
+			isColumn = true
+		default:
+			return nil, fmt.Errorf("sandwich clues %q: want rows or cols, got %q", line, kind)
+		}
+
+		clues := strings.FieldsFunc(cluesStr, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(clues) != g.size {
+			return nil, fmt.Errorf("sandwich clues %q: got %v clues, want %v", line, len(clues), g.size)
+		}
+		for i, clue := range clues {
+			if clue == "." {
+				continue
+			}
+			sum, err := strconv.Atoi(clue)
+			if err != nil {
+				return nil, fmt.Errorf("sandwich clues %q: invalid sum: %w", line, err)
+			}
+			sandwiches = append(sandwiches, Sandwich{Line: i, IsColumn: isColumn, Sum: sum})
+		}
+	}
+	return sandwiches, scanner.Err()
+}
+
+// sandwichConstraint is the constraint for a single sandwich clue.
+type sandwichConstraint struct {
+	clue Sandwich
+
+	// sqs are the squares of the clue's row or column, in order.
+	sqs []Index
+
+	// crusts is the set of the smallest and largest digits, and combos[n]
+	// holds all the sets of n other digits that add up to the clue's sum.
+	crusts Digits
+	combos [][]Digits
+}
+
+func newSandwichConstraint(g *Grid, clue Sandwich) *sandwichConstraint {
+	sc := &sandwichConstraint{clue: clue}
+	for i := 0; i < g.size; i++ {
+		if clue.IsColumn {
+			sc.sqs = append(sc.sqs, g.index(i, clue.Line))
+		} else {
+			sc.sqs = append(sc.sqs, g.index(clue.Line, i))
+		}
+	}
+
+	sc.crusts = SingleDigitSet(1).Add(uint16(g.size))
+	fillings := g.full &^ sc.crusts
+	sc.combos = make([][]Digits, g.size-1)
+	var numCombos int
+	for d := Digits(0); d <= fillings; d++ {
+		if d&^fillings == 0 && digitsSum(d) == clue.Sum {
+			sc.combos[d.Size()] = append(sc.combos[d.Size()], d)
+			numCombos++
+		}
+	}
+	if numCombos == 0 {
+		sc.combos = nil
+	}
+	return sc
+}
+
+func (sc *sandwichConstraint) squares() []Index {
+	return sc.sqs
+}
+
+// propagate tries all the possible placements of the smallest and largest
+// digits in the line, and restricts the candidates of each square to the
+// digits it can have in some placement. A placement is possible if the
+// squares between the crusts can have one of the combinations of digits that
+// add up to the clue's sum.
+func (sc *sandwichConstraint) propagate(g *Grid, values Values) bool {
+	low, high := SingleDigitSet(1), SingleDigitSet(uint16(g.size))
+
+	// The squares outside the sandwich can have any digit except the crusts;
+	// squares that can only have crusts have to be the sandwich's ends, so
+	// they limit where the ends can be.
+	var outsideAllowed [maxGridSize]Digits
+	firstCrust, lastCrust := len(sc.sqs), -1
+	for k, sq := range sc.sqs {
+		outsideAllowed[k] = values[sq] &^ sc.crusts
+		if outsideAllowed[k] == 0 {
+			firstCrust = min(firstCrust, k)
+			lastCrust = k
+		}
+	}
+
+	var allowed [maxGridSize]Digits
+	found := false
+	for i := 0; i < len(sc.sqs) && i <= firstCrust; i++ {
+		for j := max(i+1, lastCrust); j < len(sc.sqs); j++ {
+			for _, crusts := range [][2]Digits{{low, high}, {high, low}} {
+				if values[sc.sqs[i]]&crusts[0] == 0 || values[sc.sqs[j]]&crusts[1] == 0 {
+					continue
+				}
+
+				var fillingAllowed [maxGridSize]Digits
+				if !addComboSupport(values, sc.sqs[i+1:j], sc.combos[j-i-1], fillingAllowed[:]) {
+					continue
+				}
+
+				found = true
+				allowed[i] |= crusts[0]
+				allowed[j] |= crusts[1]
+				for k := range sc.sqs {
+					if k < i || k > j {
+						allowed[k] |= outsideAllowed[k]
+					} else if k > i && k < j {
+						allowed[k] |= fillingAllowed[k-i-1]
+					}
+				}
+			}
+		}
+	}
+
+	if !found {
+		return false
+	}
+	for i, sq := range sc.sqs {
+		if !g.eliminateDigits(values, sq, values[sq]&^allowed[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sc *sandwichConstraint) satisfied(values Values) bool {
+	return sc.sumIn(values) == sc.clue.Sum
+}
+
+// encode goes over the placements of the crusts, like propagate. For each
+// placement, there's an auxiliary variable for each combination of digits
+// that can be between them: if the crusts are placed there, one of these is
+// true, and each square between them has a digit of the true combination.
+// Since the line is a unit, the squares have all of the combination's digits.
+func (sc *sandwichConstraint) encode(enc *cnfEncoder) {
+	low, high := uint16(1), uint16(enc.g.size)
+	for i := 0; i < len(sc.sqs); i++ {
+		for j := i + 1; j < len(sc.sqs); j++ {
+			combos := sc.combos[j-i-1]
+			if slices.Contains(combos, 0) {
+				// Nothing between the crusts, and a sum of 0.
+				continue
+			}
+
+			var comboVars []int
+			for _, combo := range combos {
+				v := enc.newVariable()
+				comboVars = append(comboVars, v)
+				for _, sq := range sc.sqs[i+1 : j] {
+					clause := []int{-v}
+					for d := combo; d != 0; d &= d - 1 {
+						clause = append(clause, enc.variable(sq, uint16(minDigit(d))))
+					}
+					enc.add(clause...)
+				}
+			}
+			for _, ends := range [][2]uint16{{low, high}, {high, low}} {
+				clause := []int{-enc.variable(sc.sqs[i], ends[0]), -enc.variable(sc.sqs[j], ends[1])}
+				enc.add(append(clause, comboVars...)...)
+			}
+		}
+	}
+}
+
+// GenerateSandwich generates a random sandwich Sudoku puzzle with a single
+// solution on grid g. It returns a new grid with the puzzle's sandwich clues
+// (as created by WithSandwiches), and the board with the puzzle's hints.
+//
+// The generator creates clues for all the rows and columns of a random solved
+// board, and then removes hints from the board like Generate, until there are
+// at most hintCount left or no more can be removed. The clues are strong
+// enough that only a few hints are usually left. Only the Rand field of
+// options is used.
+func (g *Grid) GenerateSandwich(hintCount int, options ...GenerateOptions) (*Grid, Values) {
+	rng := randOrGlobal(generateOptions("GenerateSandwich", options).Rand)
+	solution, solved := g.Solve(g.EmptyBoard(), SolveOptions{Randomize: true, Rand: rng})
+	if !solved || !g.IsSolved(solution) {
+		log.Fatal("unable to generate solved board from empty")
+	}
+
+	var clues []Sandwich
+	for _, isColumn := range []bool{false, true} {
+		for line := 0; line < g.size; line++ {
+			clue := Sandwich{Line: line, IsColumn: isColumn}
+			clue.Sum = newSandwichConstraint(g, clue).sumIn(solution)
+			clues = append(clues, clue)
+		}
+	}
+
+	sg, err := g.WithSandwiches(clues)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return sg, sg.removeHints(sg.engine(BackendPropagation), unboundedSearch(), rng, solution, hintCount)
+}
+
+// sumIn returns the sum of the digits between the crusts in the (solved)
+// board values.
+func (sc *sandwichConstraint) sumIn(values Values) int {
+	sum := 0
+	inside := false
+	for _, sq := range sc.sqs {
+		if values[sq]&sc.crusts != 0 {
+			inside = !inside
+		} else if inside {
+			sum += minDigit(values[sq])
+		}
+	}
+	return sum
+}
+
+// drawSandwiches draws the sandwich clues of g onto canvas, outside the board:
+// row clues to the left of their rows, and column clues above their columns.
+func (g *Grid) drawSandwiches(canvas *svg.Canvas, startX, startY, cellsize int) {
+	style := "font-family:Helvetica; font-size:20px; fill:black"
+	for _, sw := range g.sandwiches {
+		if sw.IsColumn {
+			canvas.Text(startX+sw.Line*cellsize+cellsize/2, startY-12, strconv.Itoa(sw.Sum), "text-anchor:middle; "+style)
+		} else {
+			canvas.Text(startX-12, startY+sw.Line*cellsize+cellsize/2, strconv.Itoa(sw.Sum), "text-anchor:end; dominant-baseline:middle; "+style)
+		}
+	}
+}
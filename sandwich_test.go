@@ -0,0 +1,152 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var sandwichClues string = `
+rows: . 6 31 7 24 . 11 . .
+cols: . 5 . 32 14 . 23 25 .`
+
+// A sandwich puzzle with the sandwichClues clues.
+var sandwichBoard string = `
+. . . |. . . |. . .
+. . 7 |. . . |. . .
+. . . |. . . |3 . .
+------+------+------
+. . . |. . . |. . .
+1 . . |. . . |. . .
+. . . |5 . . |. . .
+------+------+------
+. . . |. . . |. . .
+. . 9 |. . . |. . .
+. . . |. . . |. . .`
+
+func TestParseSandwiches(t *testing.T) {
+	clues, err := standard.ParseSandwiches(sandwichClues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clues) != 10 {
+		t.Errorf("got %v clues, want 10", len(clues))
+	}
+	if clues[1] != (Sandwich{Line: 2, Sum: 31}) || clues[9] != (Sandwich{Line: 7, IsColumn: true, Sum: 25}) {
+		t.Errorf("got clues %v, %v, want row 2: 31, column 7: 25", clues[1], clues[9])
+	}
+
+	for _, bad := range []string{"rows 1 . . . . . . . .", "diags: . . . . . . . . .", "rows: 1 2", "cols: x . . . . . . . ."} {
+		if _, err := standard.ParseSandwiches(bad); err == nil {
+			t.Errorf("ParseSandwiches(%q): got no error", bad)
+		}
+	}
+}
+
+func TestWithSandwichesErrors(t *testing.T) {
+	var tests = []struct {
+		clues   []Sandwich
+		wantErr string
+	}{
+		{[]Sandwich{{Line: 9, Sum: 0}}, "out of range"},
+		{[]Sandwich{{Line: 1, Sum: 1}}, "no digits"},
+		{[]Sandwich{{Line: 1, Sum: 36}}, "no digits"},
+		{[]Sandwich{{Line: 1, Sum: 5}, {Line: 1, Sum: 7}}, "more than one"},
+	}
+
+	for _, tt := range tests {
+		_, err := standard.WithSandwiches(tt.clues)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("WithSandwiches(%v): got error %v, want %q", tt.clues, err, tt.wantErr)
+		}
+	}
+
+	if _, err := NewSamurai().WithSandwiches(nil); err == nil {
+		t.Errorf("want error for multi-grid")
+	}
+}
+
+func TestSandwichPropagation(t *testing.T) {
+	// With a sum of 35 (all of 2-8), 1 and 9 are at the ends of the row. With a
+	// sum of 0, they're next to each other.
+	sg, err := standard.WithSandwiches([]Sandwich{{Line: 0, Sum: 35}, {Line: 4, IsColumn: true, Sum: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := sg.EmptyBoard()
+	if !sg.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	d19 := SingleDigitSet(1).Add(9)
+	if v[0] != d19 || v[8] != d19 {
+		t.Errorf("got %v, %v, want 19 for both", v[0], v[8])
+	}
+	for sq := 1; sq < 8; sq++ {
+		if v[sq]&d19 != 0 {
+			t.Errorf("got %v for square %v, want no 1 or 9", v[sq], sq)
+		}
+	}
+
+	// Column 4 has 1 and 9 next to each other, so assigning 1 in it leaves 9
+	// only in the squares around it.
+	if !sg.assign(v, 31, 1) {
+		t.Fatal("contradiction on assign")
+	}
+	for row := 0; row < 9; row++ {
+		sq := standard.index(row, 4)
+		if want := sq == 22 || sq == 40; v[sq].IsMember(9) != want {
+			t.Errorf("got %v for square %v, want 9 as a candidate: %v", v[sq], sq, want)
+		}
+	}
+}
+
+func TestSandwichSolve(t *testing.T) {
+	clues, err := standard.ParseSandwiches(sandwichClues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg, err := standard.WithSandwiches(clues)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := sg.ParseBoard(sandwichBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := sg.SolveAll(v, -1)
+	if len(vs) != 1 {
+		t.Fatalf("got %v solutions, want 1", len(vs))
+	}
+	if !sg.IsSolved(vs[0]) {
+		t.Errorf("got unsolved board:\n%v", sg.Display(vs[0]))
+	}
+}
+
+func TestGenerateSandwich(t *testing.T) {
+	sg, board := standard.GenerateSandwich(0)
+	if len(sg.Sandwiches()) != 18 {
+		t.Errorf("got %v clues, want 18", len(sg.Sandwiches()))
+	}
+	if !sg.HasUniqueSolution(board) {
+		t.Errorf("expect a unique solution")
+	}
+}
+
+func TestSandwichSVG(t *testing.T) {
+	sg, err := standard.WithSandwiches([]Sandwich{{Line: 0, Sum: 35}, {Line: 4, IsColumn: true, Sum: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	sg.DisplayAsSVG(&buf, sg.EmptyBoard(), 1.0)
+	svgText := buf.String()
+
+	if !strings.Contains(svgText, `<text x="38" y="90" style="text-anchor:end;`) {
+		t.Errorf("expect row clue left of the first row")
+	}
+	if !strings.Contains(svgText, `<text x="410" y="38"`) || !strings.Contains(svgText, ">0</text>") {
+		t.Errorf("expect column clue above the fifth column")
+	}
+}
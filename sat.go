@@ -0,0 +1,565 @@
+package sudoku
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// This file contains a small CDCL (conflict-driven clause learning) SAT
+// solver, used by BackendSAT to solve the formulas created by EncodeCNF. It
+// has the usual ingredients of MiniSat-like solvers - two watched literals per
+// clause, first-UIP conflict analysis with backjumping, VSIDS variable
+// activities, phase saving and Luby restarts - but no clause deletion or
+// preprocessing, since the formulas of boards are small.
+
+// satLit is a literal of the SAT solver: 2*v for variable v, and 2*v+1 for
+// its negation. Variables are numbered from 0, unlike in CNF.
+type satLit int32
+
+func newSATLit(cnfLit int) satLit {
+	if cnfLit < 0 {
+		return satLit(2*(-cnfLit-1) + 1)
+	}
+	return satLit(2 * (cnfLit - 1))
+}
+
+func (l satLit) variable() int {
+	return int(l >> 1)
+}
+
+func (l satLit) negated() bool {
+	return l&1 != 0
+}
+
+func (l satLit) not() satLit {
+	return l ^ 1
+}
+
+// noReason is the reason of variables that weren't implied by a clause: the
+// decisions, and the units of the formula.
+const noReason = -1
+
+// satRestartUnit is the number of conflicts in a unit of the Luby sequence of
+// restarts.
+const satRestartUnit = 100
+
+// satSolver is the state of the CDCL solver for a formula.
+type satSolver struct {
+	numVars int
+
+	// ok is false once the formula is known to be unsatisfiable.
+	ok bool
+
+	// clauses holds the clauses of the formula and the learnt clauses. Each
+	// clause is watched by its first two literals: watches[l] lists the
+	// clauses that watch literal l.
+	clauses [][]satLit
+	watches [][]int
+
+	// assigns holds the value of each variable: 1 for true, -1 for false and 0
+	// if it's unassigned. level is the decision level where it was assigned,
+	// and reason the clause that implied it, or noReason.
+	assigns []int8
+	level   []int
+	reason  []int
+
+	// trail lists the assigned literals in order of assignment, and
+	// trailLim[i] is where decision level i+1 starts on the trail. qhead is
+	// the next literal on the trail to propagate.
+	trail    []satLit
+	trailLim []int
+	qhead    int
+
+	// activity is the VSIDS activity of each variable, which is bumped by
+	// varInc when it takes part in a conflict; order is a heap of the
+	// variables by activity, for picking decisions. phase is the last value of
+	// each variable, which decisions repeat.
+	activity []float64
+	varInc   float64
+	order    satHeap
+	phase    []bool
+
+	// seen marks variables during conflict analysis.
+	seen []bool
+}
+
+// newSATSolver creates a solver for the formula cnf. If rng isn't nil, it's
+// used to break the ties between the initial activities of the variables, so
+// that the solver finds a random model.
+func newSATSolver(cnf *CNF, rng *rand.Rand) *satSolver {
+	n := cnf.NumVars
+	x := &satSolver{
+		numVars:  n,
+		ok:       true,
+		watches:  make([][]int, 2*n),
+		assigns:  make([]int8, n),
+		level:    make([]int, n),
+		reason:   make([]int, n),
+		activity: make([]float64, n),
+		varInc:   1,
+		phase:    make([]bool, n),
+		seen:     make([]bool, n),
+	}
+	if rng != nil {
+		for v := range x.activity {
+			x.activity[v] = rng.Float64() * 1e-3
+		}
+	}
+	x.order = satHeap{activity: x.activity, indices: make([]int, n)}
+	for v := 0; v < n; v++ {
+		x.order.indices[v] = -1
+		x.order.insert(v)
+	}
+
+	clause := make([]satLit, 0, 16)
+	for _, cnfClause := range cnf.Clauses {
+		clause = clause[:0]
+		for _, lit := range cnfClause {
+			clause = append(clause, newSATLit(lit))
+		}
+		x.addClause(clause)
+	}
+	return x
+}
+
+// value returns the value of literal l: 1 if it's true, -1 if it's false and
+// 0 if its variable is unassigned.
+func (x *satSolver) value(l satLit) int8 {
+	v := x.assigns[l.variable()]
+	if l.negated() {
+		return -v
+	}
+	return v
+}
+
+func (x *satSolver) decisionLevel() int {
+	return len(x.trailLim)
+}
+
+// addClause adds a clause to the formula, which has to be at decision level
+// 0. The literals are copied.
+func (x *satSolver) addClause(lits []satLit) {
+	if !x.ok {
+		return
+	}
+
+	// Drop the false literals and the duplicates, and the whole clause if it's
+	// already satisfied.
+	var clause []satLit
+	for _, l := range lits {
+		switch {
+		case x.value(l) > 0 || slices.Contains(clause, l.not()):
+			return
+		case x.value(l) == 0 && !slices.Contains(clause, l):
+			clause = append(clause, l)
+		}
+	}
+
+	switch len(clause) {
+	case 0:
+		x.ok = false
+	case 1:
+		x.enqueue(clause[0], noReason)
+		x.ok = x.propagate() == noReason
+	default:
+		x.attach(clause)
+	}
+}
+
+// attach adds clause to the clauses and watches its first two literals, and
+// returns its index.
+func (x *satSolver) attach(clause []satLit) int {
+	ci := len(x.clauses)
+	x.clauses = append(x.clauses, clause)
+	x.watches[clause[0]] = append(x.watches[clause[0]], ci)
+	x.watches[clause[1]] = append(x.watches[clause[1]], ci)
+	return ci
+}
+
+// enqueue assigns the unassigned literal l to be true, implied by the clause
+// reason.
+func (x *satSolver) enqueue(l satLit, reason int) {
+	v := l.variable()
+	x.assigns[v] = 1
+	if l.negated() {
+		x.assigns[v] = -1
+	}
+	x.level[v] = x.decisionLevel()
+	x.reason[v] = reason
+	x.trail = append(x.trail, l)
+}
+
+// propagate propagates the assignments on the trail that weren't propagated
+// yet, assigning the literals of the clauses that have a single unassigned
+// literal left. It returns the index of a clause whose literals are all
+// false, if it finds one, and noReason otherwise.
+func (x *satSolver) propagate() int {
+	for x.qhead < len(x.trail) {
+		falseLit := x.trail[x.qhead].not()
+		x.qhead++
+
+		// Find new literals to watch in the clauses that watch falseLit; the
+		// clauses that keep watching it are compacted to the start of ws.
+		ws := x.watches[falseLit]
+		j := 0
+	ClauseLoop:
+		for i := 0; i < len(ws); i++ {
+			ci := ws[i]
+			c := x.clauses[ci]
+			if c[0] == falseLit {
+				c[0], c[1] = c[1], c[0]
+			}
+			if x.value(c[0]) > 0 {
+				ws[j] = ci
+				j++
+				continue
+			}
+			for k := 2; k < len(c); k++ {
+				if x.value(c[k]) >= 0 {
+					c[1], c[k] = c[k], c[1]
+					x.watches[c[1]] = append(x.watches[c[1]], ci)
+					continue ClauseLoop
+				}
+			}
+
+			ws[j] = ci
+			j++
+			if x.value(c[0]) < 0 {
+				j += copy(ws[j:], ws[i+1:])
+				x.watches[falseLit] = ws[:j]
+				x.qhead = len(x.trail)
+				return ci
+			}
+			x.enqueue(c[0], ci)
+		}
+		x.watches[falseLit] = ws[:j]
+	}
+	return noReason
+}
+
+// analyze finds the first-UIP clause learnt from the conflicting clause confl,
+// and the decision level to backjump to. The first literal of the clause is
+// the one that becomes true after backjumping, and the second one, if any, is
+// from the level to backjump to.
+func (x *satSolver) analyze(confl int) ([]satLit, int) {
+	learnt := []satLit{0}
+	pathCount := 0
+	p := satLit(-1)
+	i := len(x.trail) - 1
+	for {
+		c := x.clauses[confl]
+		if p >= 0 {
+			// The first literal of a reason is the one it implied.
+			c = c[1:]
+		}
+		for _, q := range c {
+			v := q.variable()
+			if x.seen[v] || x.level[v] == 0 {
+				continue
+			}
+			x.bump(v)
+			x.seen[v] = true
+			if x.level[v] == x.decisionLevel() {
+				pathCount++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+
+		// Continue with the last literal on the trail that's part of the
+		// conflict.
+		for !x.seen[x.trail[i].variable()] {
+			i--
+		}
+		p = x.trail[i]
+		i--
+		confl = x.reason[p.variable()]
+		x.seen[p.variable()] = false
+		pathCount--
+		if pathCount == 0 {
+			break
+		}
+	}
+	learnt[0] = p.not()
+
+	level := 0
+	for k := 1; k < len(learnt); k++ {
+		x.seen[learnt[k].variable()] = false
+		if l := x.level[learnt[k].variable()]; l > level {
+			level = l
+			learnt[1], learnt[k] = learnt[k], learnt[1]
+		}
+	}
+	return learnt, level
+}
+
+// learn adds the clause learnt by analyze, after backjumping, and assigns its
+// first literal.
+func (x *satSolver) learn(learnt []satLit) {
+	if len(learnt) == 1 {
+		x.enqueue(learnt[0], noReason)
+		return
+	}
+	x.enqueue(learnt[0], x.attach(learnt))
+}
+
+// bump increases the activity of variable v.
+func (x *satSolver) bump(v int) {
+	x.activity[v] += x.varInc
+	if x.activity[v] > 1e100 {
+		for u := range x.activity {
+			x.activity[u] *= 1e-100
+		}
+		x.varInc *= 1e-100
+	}
+	x.order.update(v)
+}
+
+// cancelUntil undoes the assignments of the decision levels above level,
+// leaving their search nodes.
+func (x *satSolver) cancelUntil(s *searchState, level int) {
+	if x.decisionLevel() <= level {
+		return
+	}
+	for k := len(x.trail) - 1; k >= x.trailLim[level]; k-- {
+		v := x.trail[k].variable()
+		x.phase[v] = x.assigns[v] > 0
+		x.assigns[v] = 0
+		x.order.insert(v)
+	}
+	for range x.decisionLevel() - level {
+		s.leave()
+	}
+	x.trail = x.trail[:x.trailLim[level]]
+	x.trailLim = x.trailLim[:level]
+	x.qhead = len(x.trail)
+}
+
+// solve searches for a model of the formula, counting each decision as a
+// search node in s. It returns true if it found one, which is left in
+// x.assigns; otherwise the formula is unsatisfiable, or the search was
+// aborted and s.err is set. After a model is found, block has to be called
+// before solving again.
+func (x *satSolver) solve(s *searchState) bool {
+	if !x.ok {
+		return false
+	}
+	conflicts, restarts := 0, 0
+	for {
+		if confl := x.propagate(); confl != noReason {
+			if x.decisionLevel() == 0 {
+				x.ok = false
+				return false
+			}
+			learnt, level := x.analyze(confl)
+			for range x.decisionLevel() - level {
+				s.backtrack()
+			}
+			x.cancelUntil(s, level)
+			x.learn(learnt)
+			x.varInc /= 0.95
+			conflicts++
+			continue
+		}
+
+		if conflicts >= satRestartUnit*luby(restarts) {
+			conflicts = 0
+			restarts++
+			x.cancelUntil(s, 0)
+		}
+
+		v := x.order.removeMax(x.assigns)
+		if v < 0 {
+			return true
+		}
+		if !s.visit() {
+			x.cancelUntil(s, 0)
+			return false
+		}
+		x.trailLim = append(x.trailLim, len(x.trail))
+		l := satLit(2 * v)
+		if !x.phase[v] {
+			l = l.not()
+		}
+		x.enqueue(l, noReason)
+	}
+}
+
+// block returns to decision level 0, and adds a clause that rules out the
+// model found by solve: at least one of the given variables that are true in
+// it has to be false. solve then looks for a different model.
+func (x *satSolver) block(s *searchState, vars []int) {
+	var clause []satLit
+	for _, v := range vars {
+		if x.assigns[v] > 0 {
+			clause = append(clause, satLit(2*v).not())
+		}
+	}
+	x.cancelUntil(s, 0)
+	x.addClause(clause)
+}
+
+// luby returns the i-th element of the Luby sequence: 1, 1, 2, 1, 1, 2, 4, 1,
+// 1, 2, 1, 1, 2, 4, 8, ...
+func luby(i int) int {
+	size, seq := 1, 0
+	for size < i+1 {
+		seq++
+		size = 2*size + 1
+	}
+	for size-1 != i {
+		size = (size - 1) / 2
+		seq--
+		i %= size
+	}
+	return 1 << seq
+}
+
+// satHeap is a max-heap of variables by activity, which keeps the position of
+// each variable in the heap so their activities can be updated.
+type satHeap struct {
+	activity []float64
+
+	// heap holds the variables; indices is the position of each variable in
+	// heap, or -1 if it's not there.
+	heap    []int
+	indices []int
+}
+
+func (h *satHeap) less(i, j int) bool {
+	return h.activity[h.heap[i]] > h.activity[h.heap[j]]
+}
+
+func (h *satHeap) swap(i, j int) {
+	h.heap[i], h.heap[j] = h.heap[j], h.heap[i]
+	h.indices[h.heap[i]] = i
+	h.indices[h.heap[j]] = j
+}
+
+func (h *satHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *satHeap) down(i int) {
+	for {
+		child := 2*i + 1
+		if child >= len(h.heap) {
+			break
+		}
+		if child+1 < len(h.heap) && h.less(child+1, child) {
+			child++
+		}
+		if !h.less(child, i) {
+			break
+		}
+		h.swap(i, child)
+		i = child
+	}
+}
+
+// insert adds v to the heap, unless it's already there.
+func (h *satHeap) insert(v int) {
+	if h.indices[v] >= 0 {
+		return
+	}
+	h.heap = append(h.heap, v)
+	h.indices[v] = len(h.heap) - 1
+	h.up(len(h.heap) - 1)
+}
+
+// update restores the heap after the activity of v increased.
+func (h *satHeap) update(v int) {
+	if h.indices[v] >= 0 {
+		h.up(h.indices[v])
+	}
+}
+
+// removeMax removes the unassigned variable with the highest activity from
+// the heap and returns it, dropping the assigned variables it finds on the
+// way. It returns -1 if all the variables are assigned.
+func (h *satHeap) removeMax(assigns []int8) int {
+	for len(h.heap) > 0 {
+		v := h.heap[0]
+		last := len(h.heap) - 1
+		h.swap(0, last)
+		h.heap = h.heap[:last]
+		h.indices[v] = -1
+		h.down(0)
+		if assigns[v] == 0 {
+			return v
+		}
+	}
+	return -1
+}
+
+// satEngine is the engine of BackendSAT.
+type satEngine struct {
+	g *Grid
+}
+
+func (e satEngine) solve(s *searchState, values Values, rng *rand.Rand) (Values, bool) {
+	vresult, solved := values, false
+	e.search(s, values, rng, func(solution Values) bool {
+		vresult, solved = solution, true
+		return false
+	})
+	return vresult, solved
+}
+
+func (e satEngine) enumerate(s *searchState, values Values, yield func(Values) bool) bool {
+	return e.search(s, values, nil, yield)
+}
+
+func (e satEngine) count(s *searchState, values Values, limit int) int {
+	if s == nil {
+		s = unboundedSearch()
+	}
+	count := 0
+	e.search(s, values, nil, func(Values) bool {
+		count++
+		return limit <= 0 || count < limit
+	})
+	return count
+}
+
+// split splits the search like the propagation engine. The SAT solver finds
+// the solutions of each subproblem in an arbitrary order anyway, but it's the
+// same order for any number of workers.
+func (e satEngine) split(s *searchState, values Values, depth int, yield func(subproblem) bool) bool {
+	return propagationEngine{e.g}.split(s, values, depth, yield)
+}
+
+// search solves the formula of values, and calls yield with each solution.
+// Each model found is blocked by its square variables, so that the next one
+// is a different solution.
+// It returns false if the search was stopped.
+func (e satEngine) search(s *searchState, values Values, rng *rand.Rand, yield func(Values) bool) bool {
+	x := newSATSolver(e.g.EncodeCNF(values), rng)
+	squareVars := make([]int, e.g.NumSquares()*e.g.size)
+	for v := range squareVars {
+		squareVars[v] = v
+	}
+
+	for x.solve(s) {
+		solution := make(Values, len(values))
+		for sq := range solution {
+			for d := uint16(1); d <= uint16(e.g.size); d++ {
+				if x.assigns[e.g.CNFVariable(sq, d)-1] > 0 {
+					solution[sq] = SingleDigitSet(d)
+				}
+			}
+		}
+		x.block(s, squareVars)
+		if !yield(solution) {
+			return false
+		}
+	}
+	return s.err == nil
+}
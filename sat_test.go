@@ -0,0 +1,158 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+var satOptions = SolveOptions{Backend: BackendSAT}
+
+// checkSATAgrees checks that the SAT backend finds the same solutions as the
+// propagation backend for the board values of grid g. Unlike
+// checkBackendsAgree, it doesn't use DLX, which is slow on grids with
+// additional constraints.
+func checkSATAgrees(t *testing.T, g *Grid, values Values) {
+	t.Helper()
+	want := g.solveAllWithElimination(values, 10)
+	got, err := g.SolveAllContext(context.Background(), values, 10, satOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v solutions with SAT, want %v", len(got), len(want))
+	}
+	for _, v := range got {
+		if !g.IsSolved(v) {
+			t.Errorf("got unsolved board with SAT:\n%v", g.Display(v))
+		}
+		for sq, d := range values {
+			if v[sq]&^d != 0 {
+				t.Errorf("SAT solution has %v in square %v, want one of %v", v[sq], sq, d)
+			}
+		}
+		// With 10 solutions, the backends may have found different ones.
+		if len(want) < 10 && !slices.ContainsFunc(want, func(w Values) bool { return slices.Equal(v, w) }) {
+			t.Errorf("SAT solution not found by propagation:\n%v", g.Display(v))
+		}
+	}
+}
+
+func TestSATVariants(t *testing.T) {
+	cages, err := standard.ParseCages(killerCages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kg, err := standard.WithCages(cages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSATAgrees(t, kg, kg.EmptyBoard())
+
+	arrows, err := standard.ParseArrows(arrowList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ag, err := standard.WithArrows(arrows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := ag.ParseBoard(arrowBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSATAgrees(t, ag, v)
+
+	clues, err := standard.ParseSandwiches(sandwichClues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg, err := standard.WithSandwiches(clues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = sg.ParseBoard(sandwichBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSATAgrees(t, sg, v)
+
+	rng := rand.New(rand.NewSource(1))
+	eg, v := standard.GenerateWithEdges([]EdgeMarker{WhiteDot, BlackDot}, false, GenerateOptions{Rand: rng})
+	checkSATAgrees(t, eg, v)
+	eg, v = standard.GenerateWithEdges([]EdgeMarker{MarkerX, MarkerV}, true, GenerateOptions{Rand: rng})
+	checkSATAgrees(t, eg, v)
+
+	// With fewer clues and hints, the boards have several solutions.
+	kg, err = standard.WithCages(cages[:len(cages)-6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSATAgrees(t, kg, kg.EmptyBoard())
+	sg, err = standard.WithSandwiches(clues[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err = sg.ParseBoard(sandwichBoard, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSATAgrees(t, sg, v)
+}
+
+func TestSATCount(t *testing.T) {
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats SolveStats
+	if n := g.CountSolutions(g.EmptyBoard(), -1, SolveOptions{Backend: BackendSAT, Stats: &stats}); n != 288 {
+		t.Errorf("got %v solutions, want 288", n)
+	}
+	if stats.NumSearches == 0 || stats.MaxDepth == 0 {
+		t.Errorf("got stats %+v, want searches", stats)
+	}
+
+	v, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	v[1] = v[0]
+	if n := CountSolutions(v, -1, satOptions); n != 0 {
+		t.Errorf("got %v solutions, want 0", n)
+	}
+
+	_, err = SolveAllContext(context.Background(), EmptyBoard(), -1, SolveOptions{Backend: BackendSAT, MaxNodes: 1000})
+	if !errors.Is(err, ErrNodeBudgetExceeded) {
+		t.Errorf("got error %v, want ErrNodeBudgetExceeded", err)
+	}
+}
+
+func TestSATRandomize(t *testing.T) {
+	solve := func(seed int64) Values {
+		options := SolveOptions{Backend: BackendSAT, Randomize: true, Rand: rand.New(rand.NewSource(seed))}
+		v, solved := Solve(EmptyBoard(), options)
+		if !solved || !IsSolved(v) {
+			t.Fatalf("got unsolved board:\n%v", Display(v))
+		}
+		return v
+	}
+	if !slices.Equal(solve(1), solve(1)) {
+		t.Errorf("got different solutions with the same seed")
+	}
+	if slices.Equal(solve(1), solve(2)) {
+		t.Errorf("got the same solution with different seeds")
+	}
+}
+
+func TestLuby(t *testing.T) {
+	want := []int{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8, 1}
+	for i, w := range want {
+		if got := luby(i); got != w {
+			t.Errorf("luby(%v) = %v, want %v", i, got, w)
+		}
+	}
+}
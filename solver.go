@@ -0,0 +1,272 @@
+package sudoku
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Solver solves boards of a grid with a specific backend and options; it's
+// created with NewSolver. A Solver is safe for concurrent use by multiple
+// goroutines, unless it was created with a Rand in its options.
+type Solver interface {
+	// Solve finds a solution of the board values, which is not modified. It
+	// returns the solution and true if one was found, and values and false
+	// otherwise. The search is aborted when ctx is done or when it exceeds the
+	// solver's node budget; in this case it returns values, false and a
+	// *SearchAbortedError.
+	Solve(ctx context.Context, values Values) (Values, bool, error)
+
+	// SolveAll finds the solutions of the board values, up to max of them if
+	// max > 0. When the search is aborted, it returns the solutions found so
+	// far and a *SearchAbortedError.
+	SolveAll(ctx context.Context, values Values, max int) ([]Values, error)
+
+	// CountSolutions counts the solutions of the board values, up to limit if
+	// limit > 0; values doesn't have to be eliminated. When the search is
+	// aborted, it returns the count so far and a *SearchAbortedError.
+	CountSolutions(ctx context.Context, values Values, limit int) (int, error)
+
+	// Stats returns statistics of all the searches the solver has run.
+	Stats() SolverStats
+}
+
+// SolverStats holds statistics of the searches run by a Solver.
+type SolverStats struct {
+	// NumCalls is the number of searches: calls to the solver's methods.
+	NumCalls uint64
+
+	// SolveStats has the totals of the statistics of all the searches, except
+	// for MaxDepth, which is the maximum.
+	SolveStats
+}
+
+// SolveStats holds statistics of a single search; see SolveOptions.Stats.
+type SolveStats struct {
+	// NumSearches is the number of search nodes visited: the places where the
+	// solver had to guess (squares for the propagation backend, exact cover
+	// columns for DLX, decisions for SAT).
+	NumSearches uint64
+
+	// NumAssigns and NumEliminations are the number of digits assigned to
+	// squares and the number of candidates eliminated from squares, including
+	// the ones done by constraint propagation. Only the propagation backend
+	// counts them.
+	NumAssigns      uint64
+	NumEliminations uint64
+
+	// NumBacktracks is the number of guesses the search undid, either because
+	// they led to a contradiction or to try the next candidate.
+	NumBacktracks uint64
+
+	// MaxDepth is the maximal number of nested guesses.
+	MaxDepth int
+
+	// Elapsed is the duration of the search.
+	Elapsed time.Duration
+}
+
+// NewSolver creates a Solver for boards of the standard grid, configured by
+// options like Solve. The Stats field of options is ignored, since a Solver
+// may run several searches at once; use the Solver's Stats method instead.
+func NewSolver(options ...SolveOptions) Solver {
+	return standard.NewSolver(options...)
+}
+
+// NewSolver is the equivalent of the package-level NewSolver for boards of
+// grid g.
+func (g *Grid) NewSolver(options ...SolveOptions) Solver {
+	opts := solveOptions("NewSolver", options)
+	opts.Stats = nil
+	return g.newSolver(opts)
+}
+
+func (g *Grid) newSolver(opts SolveOptions) *solver {
+	g = g.withOptions(opts)
+	sv := &solver{g: g, engine: g.engine(opts.Backend), opts: opts, workers: opts.Workers}
+	if opts.Randomize {
+		sv.rng = randOrGlobal(opts.Rand)
+	}
+	if sv.workers < 0 {
+		sv.workers = runtime.GOMAXPROCS(0)
+	}
+	return sv
+}
+
+// solver is the implementation of Solver for all the backends; the backends
+// differ in their engine. If opts.Stats isn't nil, each search stores its
+// statistics there; this is only used by the solvers created for a single
+// call, like the ones of SolveContext.
+type solver struct {
+	g      *Grid
+	engine engine
+	opts   SolveOptions
+
+	// workers is the number of workers of parallel searches; SolveAll and
+	// CountSolutions run parallel searches if it's more than 1.
+	workers int
+
+	// rng is the source of randomness of randomized solvers, and nil for
+	// others.
+	rng *rand.Rand
+
+	numCalls, numSearches, numAssigns, numEliminations, numBacktracks atomic.Uint64
+	maxDepth, elapsed                                                 atomic.Int64
+}
+
+func (sv *solver) Solve(ctx context.Context, values Values) (Values, bool, error) {
+	vresult, solved, _, err := sv.solve(ctx, values)
+	return vresult, solved, err
+}
+
+// solve is like Solve, but it also returns the statistics of the search.
+func (sv *solver) solve(ctx context.Context, values Values) (Values, bool, SolveStats, error) {
+	s, start := newSearchState(ctx, sv.opts.MaxNodes), time.Now()
+	vresult, solved := sv.engine.solve(s, values, sv.rng)
+	sv.record(s, start)
+	if s.err != nil {
+		return values, false, s.stats, s.err
+	}
+	return vresult, solved, s.stats, nil
+}
+
+func (sv *solver) SolveAll(ctx context.Context, values Values, max int) ([]Values, error) {
+	s, start := newSearchState(ctx, sv.opts.MaxNodes), time.Now()
+	var allSolved []Values
+	if sv.workers > 1 {
+		allSolved = sv.g.enumerateParallel(sv.engine, s, sv.workers, values, max)
+	} else {
+		sv.engine.enumerate(s, values, func(solution Values) bool {
+			allSolved = append(allSolved, solution)
+			return max <= 0 || len(allSolved) < max
+		})
+	}
+	sv.record(s, start)
+	return allSolved, s.err
+}
+
+func (sv *solver) CountSolutions(ctx context.Context, values Values, limit int) (int, error) {
+	s, start := newSearchState(ctx, sv.opts.MaxNodes), time.Now()
+	var count int
+	if sv.workers > 1 {
+		count = sv.g.countParallel(sv.engine, s, sv.workers, values, limit)
+	} else {
+		count = sv.engine.count(s, values, limit)
+	}
+	sv.record(s, start)
+	return count, s.err
+}
+
+func (sv *solver) Stats() SolverStats {
+	return SolverStats{
+		NumCalls: sv.numCalls.Load(),
+		SolveStats: SolveStats{
+			NumSearches:     sv.numSearches.Load(),
+			NumAssigns:      sv.numAssigns.Load(),
+			NumEliminations: sv.numEliminations.Load(),
+			NumBacktracks:   sv.numBacktracks.Load(),
+			MaxDepth:        int(sv.maxDepth.Load()),
+			Elapsed:         time.Duration(sv.elapsed.Load()),
+		},
+	}
+}
+
+// record adds the statistics of the search s, which started at start, to the
+// solver's.
+func (sv *solver) record(s *searchState, start time.Time) {
+	s.stats.Elapsed = time.Since(start)
+	if sv.opts.Stats != nil {
+		*sv.opts.Stats = s.stats
+	}
+
+	sv.numCalls.Add(1)
+	sv.numSearches.Add(s.stats.NumSearches)
+	sv.numAssigns.Add(s.stats.NumAssigns)
+	sv.numEliminations.Add(s.stats.NumEliminations)
+	sv.numBacktracks.Add(s.stats.NumBacktracks)
+	sv.elapsed.Add(int64(s.stats.Elapsed))
+	for {
+		depth := sv.maxDepth.Load()
+		if int64(s.stats.MaxDepth) <= depth || sv.maxDepth.CompareAndSwap(depth, int64(s.stats.MaxDepth)) {
+			break
+		}
+	}
+}
+
+// engine is the search algorithm of a solver backend. Its methods take the
+// search state, so that several searches (like the ones run by the generator)
+// can share a context and a node budget. None of them modify values.
+type engine interface {
+	// solve finds a solution of values, trying digits in random order if rng
+	// isn't nil.
+	solve(s *searchState, values Values, rng *rand.Rand) (Values, bool)
+
+	// enumerate calls yield with each solution of values, and returns false if
+	// the enumeration was stopped, by yield or because the search was aborted.
+	enumerate(s *searchState, values Values, yield func(Values) bool) bool
+
+	// count counts the solutions of values, up to limit if limit > 0; values
+	// doesn't have to be eliminated. s may be nil for searches that are never
+	// aborted.
+	count(s *searchState, values Values, limit int) int
+
+	// split calls yield with the subproblems of a parallel search of values:
+	// the boards at the nodes where the search has made depth guesses, and
+	// the solutions it finds with fewer guesses, in search order. Searching
+	// the subproblems in order has to find the same solutions as searching
+	// values, in the same order. The nodes above the subproblems are counted
+	// in s, which is never aborted. split returns false if yield stopped it.
+	split(s *searchState, values Values, depth int, yield func(subproblem) bool) bool
+}
+
+// engine returns the engine of the given backend for grid g.
+func (g *Grid) engine(backend Backend) engine {
+	switch backend {
+	case BackendPropagation:
+		return propagationEngine{g}
+	case BackendDLX:
+		return dlxEngine{g}
+	case BackendSAT:
+		return satEngine{g}
+	}
+	panic(fmt.Sprintf("unknown solver backend %v", backend))
+}
+
+// propagationEngine is the engine of BackendPropagation.
+type propagationEngine struct {
+	g *Grid
+}
+
+// The methods of propagationEngine use the fast solver when the grid supports
+// it.
+
+func (e propagationEngine) solve(s *searchState, values Values, rng *rand.Rand) (Values, bool) {
+	if e.g.fast != nil {
+		return e.g.fast.solve(s, values, rng)
+	}
+	return e.g.withStats(&s.stats).search(s, values, rng)
+}
+
+func (e propagationEngine) enumerate(s *searchState, values Values, yield func(Values) bool) bool {
+	if e.g.fast != nil {
+		return e.g.fast.enumerate(s, values, yield)
+	}
+	return e.g.withStats(&s.stats).enumerate(s, slices.Clone(values), yield)
+}
+
+func (e propagationEngine) split(s *searchState, values Values, depth int, yield func(subproblem) bool) bool {
+	return e.g.withStats(&s.stats).split(s, values, depth, yield)
+}
+
+func (e propagationEngine) count(s *searchState, values Values, limit int) int {
+	if e.g.fast != nil {
+		return e.g.fast.count(s, values, limit)
+	}
+	c := e.g.getSolutionCounter(s)
+	defer putSolutionCounter(c)
+	return c.countSolutions(values, limit)
+}
@@ -0,0 +1,167 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"testing"
+)
+
+func TestSolverBackends(t *testing.T) {
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	multiple, err := ParseBoard(hardlong, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, backend := range []Backend{BackendPropagation, BackendDLX} {
+		t.Run(backend.String(), func(t *testing.T) {
+			ctx := context.Background()
+			solver := NewSolver(SolveOptions{Backend: backend})
+
+			vs, solved, err := solver.Solve(ctx, v)
+			if err != nil || !solved || !IsSolved(vs) {
+				t.Errorf("got solved=%v, err=%v, want solved board", solved, err)
+			}
+
+			solutions, err := solver.SolveAll(ctx, v, -1)
+			if err != nil || len(solutions) != 1 {
+				t.Errorf("got %v solutions and error %v, want 1", len(solutions), err)
+			}
+
+			n, err := solver.CountSolutions(ctx, v, -1)
+			if err != nil || n != 1 {
+				t.Errorf("got %v solutions and error %v, want 1", n, err)
+			}
+
+			stats := solver.Stats()
+			if stats.NumCalls != 3 || stats.NumSearches == 0 {
+				t.Errorf("got stats %+v, want 3 calls and some searches", stats)
+			}
+
+			if backend == BackendPropagation {
+				// hardlong takes seconds to count with the propagation backend.
+				return
+			}
+			n, err = solver.CountSolutions(ctx, multiple, 50)
+			if err != nil || n != 50 {
+				t.Errorf("got %v solutions and error %v, want 50", n, err)
+			}
+		})
+	}
+}
+
+func TestSolverBudget(t *testing.T) {
+	for _, backend := range []Backend{BackendPropagation, BackendDLX} {
+		solver := NewSolver(SolveOptions{Backend: backend, MaxNodes: 10})
+		if _, err := solver.CountSolutions(context.Background(), EmptyBoard(), -1); !errors.Is(err, ErrNodeBudgetExceeded) {
+			t.Errorf("%v: got error %v, want ErrNodeBudgetExceeded", backend, err)
+		}
+		if stats := solver.Stats(); stats.NumCalls != 1 || stats.NumSearches != 10 {
+			t.Errorf("%v: got stats %+v, want 1 call and 10 searches", backend, stats)
+		}
+	}
+}
+
+func TestSolverConcurrent(t *testing.T) {
+	solver := NewSolver(SolveOptions{Randomize: true})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vs, solved, err := solver.Solve(context.Background(), EmptyBoard())
+			if err != nil || !solved || !IsSolved(vs) {
+				t.Errorf("got solved=%v, err=%v, want solved board", solved, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := solver.Stats().NumCalls; n != 8 {
+		t.Errorf("got %v calls, want 8", n)
+	}
+}
+
+func TestSolveStatsConcurrent(t *testing.T) {
+	var boards []Values
+	for _, board := range []string{hardboard1, hardboard2, easyboard1} {
+		v, err := ParseBoard(board, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		boards = append(boards, v)
+	}
+
+	// Each search's stats should be the same when searches run concurrently.
+	want := make([]SolveStats, len(boards))
+	for i, v := range boards {
+		Solve(v, SolveOptions{Stats: &want[i]})
+		want[i].Elapsed = 0
+	}
+
+	solver := NewSolver()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for j, v := range boards {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var stats SolveStats
+				if _, solved := Solve(v, SolveOptions{Stats: &stats}); !solved {
+					t.Errorf("board %v not solved", j)
+				}
+				if stats.Elapsed <= 0 {
+					t.Errorf("got elapsed %v, want > 0", stats.Elapsed)
+				}
+				stats.Elapsed = 0
+				if stats != want[j] {
+					t.Errorf("got stats %+v for board %v, want %+v", stats, j, want[j])
+				}
+				solver.Solve(context.Background(), v)
+			}()
+		}
+	}
+	wg.Wait()
+
+	stats := solver.Stats()
+	if stats.NumCalls != 8*uint64(len(boards)) {
+		t.Errorf("got %v calls, want %v", stats.NumCalls, 8*len(boards))
+	}
+	var total SolveStats
+	for _, s := range want {
+		total.NumSearches += 8 * s.NumSearches
+		total.NumAssigns += 8 * s.NumAssigns
+		total.MaxDepth = max(total.MaxDepth, s.MaxDepth)
+	}
+	if stats.NumSearches != total.NumSearches || stats.NumAssigns != total.NumAssigns || stats.MaxDepth != total.MaxDepth {
+		t.Errorf("got solver stats %+v, want %+v", stats, total)
+	}
+}
+
+func TestUnknownBackend(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for unknown backend")
+		}
+	}()
+	NewSolver(SolveOptions{Backend: Backend(100)})
+}
+
+func TestGenerateWithBackend(t *testing.T) {
+	board, err := GenerateContext(context.Background(), 28, GenerateOptions{Backend: BackendDLX})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasUniqueSolution(board) {
+		t.Errorf("got board without a single solution")
+	}
+
+	d, err := EvaluateDifficulty(board, DifficultyOptions{Solver: NewSolver(SolveOptions{Randomize: true, Backend: BackendDLX})})
+	if err != nil || d < 1 || d > 5 {
+		t.Errorf("got difficulty %v and error %v, want 1-5", d, err)
+	}
+}
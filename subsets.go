@@ -0,0 +1,214 @@
+package sudoku
+
+import "slices"
+
+// ApplyNakedSubsets applies the "naked subsets" Sudoku strategy to the given
+// board and updates it, for subsets of 2 to maxSize squares; sizes above 4
+// are taken as 4, the size of quads. It returns the steps it made, and false
+// if there was a contradiction discovered while applying the strategy.
+//
+// The strategy generalizes the "naked twins" of ApplyTwinsStrategy: when N
+// squares in a unit have only N digits as candidates between them, these
+// digits must occupy these squares, so no other square in the unit may have
+// them. The squares don't need to have the same candidates; for example, the
+// squares with candidates 12, 23 and 13 are a naked triple of 1, 2 and 3.
+//
+// Each step lists the candidates the strategy eliminated; like
+// ApplyTwinsStrategy, the eliminations propagate constraints, which may
+// eliminate more candidates. The strategy is repeated until it doesn't change
+// the board, going over each unit in turn rather than starting over after
+// each step.
+func ApplyNakedSubsets(values Values, maxSize int) ([]Step, bool) {
+	return standard.ApplyNakedSubsets(values, maxSize)
+}
+
+// ApplyNakedSubsets is the equivalent of the package-level ApplyNakedSubsets
+// for boards of grid g.
+func (g *Grid) ApplyNakedSubsets(values Values, maxSize int) ([]Step, bool) {
+	var steps []Step
+	ok := g.applySubsets(values, maxSize, (*logicState).nakedSubset, &steps)
+	return steps, ok
+}
+
+// ApplyHiddenSubsets applies the "hidden subsets" Sudoku strategy to the given
+// board and updates it, for subsets of 2 to maxSize digits (at most 4). It
+// returns the steps it made, and false if there was a contradiction
+// discovered while applying the strategy.
+//
+// The strategy is the converse of the naked subsets of ApplyNakedSubsets: when
+// N digits are candidates of only N squares in a unit, these squares must
+// have these digits, so their other candidates can be eliminated. Like with
+// ApplyNakedSubsets, the eliminations propagate constraints, and the strategy
+// is repeated until it doesn't change the board.
+func ApplyHiddenSubsets(values Values, maxSize int) ([]Step, bool) {
+	return standard.ApplyHiddenSubsets(values, maxSize)
+}
+
+// ApplyHiddenSubsets is the equivalent of the package-level ApplyHiddenSubsets
+// for boards of grid g.
+func (g *Grid) ApplyHiddenSubsets(values Values, maxSize int) ([]Step, bool) {
+	var steps []Step
+	ok := g.applySubsets(values, maxSize, (*logicState).hiddenSubset, &steps)
+	return steps, ok
+}
+
+// withSubsets returns a copy of g whose searches apply the naked and hidden
+// subset strategies of up to size squares after each guess; see
+// SolveOptions.SubsetSize. The copy doesn't use the fast solver, which doesn't
+// support them.
+func (g *Grid) withSubsets(size int) *Grid {
+	ng := *g
+	ng.subsetSize = size
+	ng.fast = nil
+	return &ng
+}
+
+// withOptions returns the grid whose searches use opts: a copy of g with
+// subsets if opts.SubsetSize asks for them with the propagation backend, and
+// g otherwise.
+func (g *Grid) withOptions(opts SolveOptions) *Grid {
+	if opts.SubsetSize >= 2 && opts.Backend == BackendPropagation {
+		return g.withSubsets(opts.SubsetSize)
+	}
+	return g
+}
+
+// guess assigns digit to values[square] for a guess of the search, like
+// assign, and applies the subset strategies if the searches of g use them.
+func (g *Grid) guess(values Values, square Index, digit uint16) bool {
+	if !g.assign(values, square, digit) {
+		return false
+	}
+	if g.subsetSize < 2 {
+		return true
+	}
+	return g.applySubsets(values, g.subsetSize, (*logicState).nakedSubset, nil) &&
+		g.applySubsets(values, g.subsetSize, (*logicState).hiddenSubset, nil)
+}
+
+// applySubsets applies the subset strategy whose steps in a unit are found by
+// find to values, for subsets of 2 to maxSize, and appends its steps to steps
+// unless it's nil. It returns false if there was a contradiction.
+func (g *Grid) applySubsets(values Values, maxSize int, find func(ls *logicState, unit Unit, n int) *Step, steps *[]Step) bool {
+	ls := g.strategyState(values)
+	maxSize = min(maxSize, 4)
+	for changed := true; changed; {
+		changed = false
+		for _, unit := range g.unitlist {
+			for n := 2; n <= maxSize; n++ {
+				for step := find(ls, unit, n); step != nil; step = find(ls, unit, n) {
+					if !ls.eliminate(step) {
+						return false
+					}
+					if steps != nil {
+						*steps = append(*steps, *step)
+					}
+					changed = true
+				}
+			}
+		}
+	}
+	return true
+}
+
+// strategyState returns the state for applying the strategies of logical
+// techniques to values in place, like ApplyNakedSubsets. The squares with a
+// single candidate are solved, since eliminate has removed their digits from
+// their peers.
+func (g *Grid) strategyState(values Values) *logicState {
+	ls := &logicState{g: g, values: values, solved: make([]bool, len(values))}
+	ls.updateSolved()
+	return ls
+}
+
+// updateSolved marks the squares with a single candidate as solved.
+func (ls *logicState) updateSolved() {
+	for sq, d := range ls.values {
+		ls.solved[sq] = d.Size() == 1
+	}
+}
+
+// eliminate makes the eliminations of step with constraint propagation, for
+// the states of strategyState. It returns false if there was a contradiction.
+func (ls *logicState) eliminate(step *Step) bool {
+	for _, c := range step.Eliminations {
+		if !ls.g.eliminate(ls.values, c.Square, c.Digit) {
+			return false
+		}
+	}
+	ls.updateSolved()
+	return true
+}
+
+// nakedSubset returns the step of a naked subset of n squares in unit, or nil
+// if there's none with eliminations.
+func (ls *logicState) nakedSubset(unit Unit, n int) *Step {
+	var squares []Index
+	for _, sq := range unit {
+		if size := ls.values[sq].Size(); !ls.solved[sq] && size >= 2 && size <= n {
+			squares = append(squares, sq)
+		}
+	}
+	for subset := range combinations(squares, n) {
+		var digits Digits
+		for _, sq := range subset {
+			digits |= ls.values[sq]
+		}
+		if digits.Size() != n {
+			continue
+		}
+		elims := ls.eliminations(unit, digits, func(sq Index) bool {
+			return slices.Contains(subset, sq)
+		})
+		if len(elims) > 0 {
+			return &Step{
+				Technique:    NakedPair + Technique(n-2),
+				Digits:       digits,
+				Squares:      slices.Clone(subset),
+				Units:        []Unit{unit},
+				Eliminations: elims,
+			}
+		}
+	}
+	return nil
+}
+
+// hiddenSubset returns the step of a hidden subset of n digits in unit, or nil
+// if there's none with eliminations.
+func (ls *logicState) hiddenSubset(unit Unit, n int) *Step {
+	placed := ls.placed(unit)
+	var digits []uint16
+	for d := uint16(1); d <= uint16(ls.g.size); d++ {
+		if !placed.IsMember(d) {
+			if count := len(ls.positions(unit, d)); count >= 2 && count <= n {
+				digits = append(digits, d)
+			}
+		}
+	}
+	for subset := range combinations(digits, n) {
+		var set Digits
+		for _, d := range subset {
+			set = set.Add(d)
+		}
+		var squares []Index
+		for _, sq := range unit {
+			if !ls.solved[sq] && ls.values[sq]&set != 0 {
+				squares = append(squares, sq)
+			}
+		}
+		if len(squares) != n {
+			continue
+		}
+		elims := ls.eliminations(squares, ls.g.full&^set, func(Index) bool { return false })
+		if len(elims) > 0 {
+			return &Step{
+				Technique:    HiddenPair + Technique(n-2),
+				Digits:       set,
+				Squares:      squares,
+				Units:        []Unit{unit},
+				Eliminations: elims,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package sudoku
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestApplyNakedSubsets(t *testing.T) {
+	// The first three squares of an empty board are a naked triple of 1, 2 and
+	// 3, although none of them has all three digits.
+	v := EmptyBoard()
+	v[0] = Digits(0).Add(1).Add(2)
+	v[1] = Digits(0).Add(2).Add(3)
+	v[2] = Digits(0).Add(1).Add(3)
+	steps, ok := ApplyNakedSubsets(v, 4)
+	if !ok {
+		t.Fatal("got contradiction")
+	}
+	if len(steps) != 2 {
+		t.Fatalf("got %v steps, want 2", len(steps))
+	}
+	for i, unit := range []Unit{standard.unitlist[0], standard.unitlist[18]} {
+		step := steps[i]
+		if step.Technique != NakedTriple || step.Digits != v[0]|v[1] || !slices.Equal(step.Squares, []Index{0, 1, 2}) || !slices.Equal(step.Units[0], unit) || len(step.Eliminations) != 18 {
+			t.Errorf("got step %+v, want naked triple in unit %v", step, unit)
+		}
+	}
+	for _, sq := range []Index{3, 4, 5, 6, 7, 8, 9, 10, 11, 18, 19, 20} {
+		if v[sq]&(v[0]|v[1]) != 0 {
+			t.Errorf("got board[%v]=%s, expect no 1, 2 or 3", sq, v[sq])
+		}
+	}
+
+	// Without quads, a board with four squares that only have three digits has
+	// no contradiction; with quads, the naked triple of three of the squares
+	// empties the fourth.
+	v = EmptyBoard()
+	for sq := range 4 {
+		v[sq] = Digits(0).Add(1).Add(2).Add(3)
+	}
+	if steps, ok := ApplyNakedSubsets(slices.Clone(v), 2); !ok || len(steps) != 0 {
+		t.Errorf("got %v steps with pairs, want none", len(steps))
+	}
+	if _, ok := ApplyNakedSubsets(v, 3); ok {
+		t.Errorf("got no contradiction")
+	}
+}
+
+func TestApplyHiddenSubsets(t *testing.T) {
+	// 1 and 2 can only go in the first two squares of the first row.
+	v := EmptyBoard()
+	for sq := 2; sq < 9; sq++ {
+		v[sq] = v[sq].Remove(1).Remove(2)
+	}
+	steps, ok := ApplyHiddenSubsets(v, 4)
+	if !ok {
+		t.Fatal("got contradiction")
+	}
+	d12 := Digits(0).Add(1).Add(2)
+	if len(steps) != 1 || steps[0].Technique != HiddenPair || steps[0].Digits != d12 || !slices.Equal(steps[0].Squares, []Index{0, 1}) || len(steps[0].Eliminations) != 14 {
+		t.Fatalf("got steps %+v, want hidden pair", steps)
+	}
+	if v[0] != d12 || v[1] != d12 {
+		t.Errorf("got board[0]=%s and board[1]=%s, want 12", v[0], v[1])
+	}
+}
+
+func TestApplySubsetsInputs(t *testing.T) {
+	// The subsets never eliminate the digits of the solution.
+	for _, board := range readInputBoards(t, "inputs/norvig-hard.txt") {
+		v, err := ParseBoard(board, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		solution, _ := Solve(v)
+		for _, apply := range []func(Values, int) ([]Step, bool){ApplyNakedSubsets, ApplyHiddenSubsets} {
+			vcopy := slices.Clone(v)
+			steps, ok := apply(vcopy, 4)
+			if !ok {
+				t.Fatalf("got contradiction for board %v", board)
+			}
+			checkSteps(t, steps, solution)
+			for sq, d := range vcopy {
+				if d&solution[sq] == 0 {
+					t.Fatalf("square %v lost its digit %v", sq, solution[sq])
+				}
+			}
+		}
+	}
+}
+
+func TestSolveSubsets(t *testing.T) {
+	var searches, subsetSearches uint64
+	for _, board := range readInputBoards(t, "inputs/norvig-hard.txt") {
+		v, err := ParseBoard(board, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stats SolveStats
+		want, _ := Solve(v, SolveOptions{Stats: &stats})
+		searches += stats.NumSearches
+		solution, solved := Solve(v, SolveOptions{SubsetSize: 3, Stats: &stats})
+		subsetSearches += stats.NumSearches
+		if !solved || !slices.Equal(solution, want) {
+			t.Errorf("got solution\n%v\nwant\n%v", Display(solution), Display(want))
+		}
+	}
+	if subsetSearches >= searches {
+		t.Errorf("got %v searches with subsets, want fewer than %v", subsetSearches, searches)
+	}
+
+	// Counting also applies the subsets, and guesses less.
+	v, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var countStats, subsetCountStats SolveStats
+	if n := CountSolutions(v, 2, SolveOptions{Stats: &countStats}); n != 1 {
+		t.Errorf("got %v solutions, want 1", n)
+	}
+	if n := CountSolutions(v, 2, SolveOptions{SubsetSize: 4, Stats: &subsetCountStats}); n != 1 {
+		t.Errorf("got %v solutions with subsets, want 1", n)
+	}
+	if n := CountSolutions(v, 2, SolveOptions{SubsetSize: 4}); n != 1 {
+		t.Errorf("got %v solutions with subsets and without stats, want 1", n)
+	}
+	if subsetCountStats.NumSearches >= countStats.NumSearches {
+		t.Errorf("got %v searches counting with subsets, want fewer than %v", subsetCountStats.NumSearches, countStats.NumSearches)
+	}
+
+	// Counting and enumerating find all the solutions, including in parallel.
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{0, 2} {
+		options := SolveOptions{SubsetSize: 4, Workers: workers}
+		if n := g.CountSolutions(g.EmptyBoard(), -1, options); n != 288 {
+			t.Errorf("got %v solutions with %v workers, want 288", n, workers)
+		}
+		solutions, err := g.SolveAllContext(context.Background(), g.EmptyBoard(), -1, options)
+		if err != nil || len(solutions) != 288 {
+			t.Errorf("got %v solutions and error %v with %v workers, want 288", len(solutions), err, workers)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package sudoku
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"iter"
 	"math/rand"
 	"strings"
+	"sync"
 
 	"slices"
 
@@ -12,9 +15,10 @@ import (
 )
 
 // Index represents a square on the Sudoku board; it's a number in the inclusive
-// range [0, 80] that stands for row*9+col.
+// range [0, NumSquares-1] that stands for row*size+col, where size is the
+// number of rows in the grid.
 //
-// These are the squares designated by an Index:
+// These are the squares designated by an Index on the standard 9x9 grid:
 //
 //	0  1  2 |  3  4  5 |  6  7  8
 //	9 10 11 | 12 13 14 | 15 16 17
@@ -31,9 +35,9 @@ import (
 type Index = int
 
 // Unit is a list of square indices that belong to the same Sudoku
-// unit - a row, column or 3x3 block which should contain unique digits.
+// unit - a row, column or box which should contain unique digits.
 // There are overlaps between many units - e.g. a single square will be a member
-// of a row unit, a column unit and a 3x3 block unit.
+// of a row unit, a column unit and a box unit.
 type Unit = []Index
 
 // Values represents a Sudoku board in a format that's usable for solving.
@@ -42,82 +46,6 @@ type Unit = []Index
 // digits for this square.
 type Values []Digits
 
-// unitlist is the list of all units that exist on the board.
-var unitlist []Unit
-
-// units maps an index to a list of units that contain that square.
-// The mapping is a slice, i.e. units[i] is a list of all the units
-// that contain the square with index i.
-var units [][]Unit
-
-// peers maps an index to a list of unique peers - other indices that share
-// some unit with this index (it won't contain the index itself).
-var peers [][]Index
-
-func init() {
-	index := func(row, col int) Index {
-		return row*9 + col
-	}
-
-	// row units
-	for row := 0; row < 9; row++ {
-		var rowUnit []Index
-		for col := 0; col < 9; col++ {
-			rowUnit = append(rowUnit, index(row, col))
-		}
-		unitlist = append(unitlist, rowUnit)
-	}
-
-	// column units
-	for col := 0; col < 9; col++ {
-		var colUnit []Index
-		for row := 0; row < 9; row++ {
-			colUnit = append(colUnit, index(row, col))
-		}
-		unitlist = append(unitlist, colUnit)
-	}
-
-	// 3x3 block units
-	for blockRow := 0; blockRow < 3; blockRow++ {
-		for blockCol := 0; blockCol < 3; blockCol++ {
-			var blockUnit []Index
-
-			for row := 0; row < 3; row++ {
-				for col := 0; col < 3; col++ {
-					blockUnit = append(blockUnit, index(blockRow*3+row, blockCol*3+col))
-				}
-			}
-			unitlist = append(unitlist, blockUnit)
-		}
-	}
-
-	// For each index i, units[i] is a list of all units that contain i.
-	units = make([][]Unit, 81)
-	for i := 0; i < 81; i++ {
-		for _, unit := range unitlist {
-			if slices.Index(unit, i) >= 0 {
-				units[i] = append(units[i], slices.Clone(unit))
-			}
-		}
-	}
-
-	// For each index i, peers[i] is a list of unique indices that share some
-	// unit with i.
-	peers = make([][]Index, 81)
-	for i := 0; i < 81; i++ {
-		for _, unit := range units[i] {
-			for _, candidate := range unit {
-				// This uses linear search to ensure uniqueness, but this calculation is
-				// only done once at solver creation so we don't particularly care about
-				// its speed.
-				if candidate != i && slices.Index(peers[i], candidate) < 0 {
-					peers[i] = append(peers[i], candidate)
-				}
-			}
-		}
-	}
-}
-
 // ParseBoard parses a Sudoku board given in textual representation, and returns
 // it as Values. The textual representation is as described in
 // http://norvig.com/sudoku.html: a string with a sequence of 81 runes in the
@@ -131,25 +59,73 @@ func init() {
 // and return the result. This is recommended when the board is then passed to
 // a solver.
 // It returns an error if there was an issue parsing the board, or if the board
-// isn't a valid Sudoku board (e.g. contradictions exist).
+// isn't a valid Sudoku board (e.g. contradictions exist); in the latter case
+// the error wraps a *ValidationError describing the contradiction (see
+// Validate).
+//
+// ParseBoard parses boards for the standard 9x9 grid; see Grid.ParseBoard for
+// other grids.
 func ParseBoard(str string, runElimination bool) (Values, error) {
+	return standard.ParseBoard(str, runElimination)
+}
+
+// ParseBoard parses a board for grid g given in textual representation, and
+// returns it as Values. It's similar to the package-level ParseBoard, but it
+// expects NumSquares runes representing digits instead of 81. Grids larger
+// than 9x9 use the letters A-G (either case) for the digits 10-16; on smaller
+// grids letters are ignored just like other unsupported runes. Digits that
+// don't fit in the grid (e.g. 7 on a 6x6 grid) are reported as errors.
+//
+// For multi-grids (see NewMultiGrid), the board is given in layout order; the
+// gaps between the subgrids are either left blank, or filled with empty
+// squares so that each layout row has the same number of squares.
+func (g *Grid) ParseBoard(str string, runElimination bool) (Values, error) {
 	var dgs []uint16
 
 	// Iterate and grab only the supported runes; ignore all others.
 	for _, r := range str {
-		if r >= '0' && r <= '9' {
-			dgs = append(dgs, uint16(r)-uint16('0'))
-		} else if r == '.' {
+		var d uint16
+		switch {
+		case r == '0' || r == '.':
 			dgs = append(dgs, 0)
+			continue
+		case r >= '1' && r <= '9':
+			d = uint16(r - '0')
+		case g.size > 9 && r >= 'A' && r <= 'G':
+			d = uint16(r-'A') + 10
+		case g.size > 9 && r >= 'a' && r <= 'g':
+			d = uint16(r-'a') + 10
+		default:
+			continue
+		}
+
+		if int(d) > g.size {
+			return nil, fmt.Errorf("digit %c out of range for a %vx%v grid", r, g.size, g.size)
+		}
+		dgs = append(dgs, d)
+	}
+
+	// Boards of multi-grids may be given with the gaps between the subgrids
+	// filled with empty squares, instead of leaving them blank.
+	numSquares := g.NumSquares()
+	if len(dgs) == g.layoutRows*g.layoutCols && len(dgs) != numSquares {
+		var squareDgs []uint16
+		for pos, d := range dgs {
+			if g.layoutSquares[pos] >= 0 {
+				squareDgs = append(squareDgs, d)
+			} else if d != 0 {
+				return nil, fmt.Errorf("digit %v in a gap between subgrids", d)
+			}
 		}
+		dgs = squareDgs
 	}
 
-	if len(dgs) != 81 {
-		return nil, fmt.Errorf("got only %v digits in board, want 81", len(dgs))
+	if len(dgs) != numSquares {
+		return nil, fmt.Errorf("got only %v digits in board, want %v", len(dgs), numSquares)
 	}
 
 	// Start with an empty board.
-	values := EmptyBoard()
+	values := g.EmptyBoard()
 
 	// Assign square digits based on the parsed board. Note that this runs
 	// constraint propagation and may discover contradictions.
@@ -159,8 +135,14 @@ func ParseBoard(str string, runElimination bool) (Values, error) {
 		}
 	}
 
-	if runElimination && !EliminateAll(values) {
-		return nil, fmt.Errorf("contradiction when eliminating board")
+	if runElimination {
+		givens := slices.Clone(values)
+		if !g.EliminateAll(values) {
+			if problems := g.localProblems(givens); len(problems) > 0 {
+				return nil, fmt.Errorf("contradiction when eliminating board: %w", problems[0])
+			}
+			return nil, fmt.Errorf("contradiction when eliminating board")
+		}
 	}
 
 	return values, nil
@@ -170,6 +152,18 @@ func ParseBoard(str string, runElimination bool) (Values, error) {
 // first-order Sudoku heuristics on the entire board. Returns true if the
 // elimination is successful, and false if the board has a contradiction.
 func EliminateAll(values Values) bool {
+	return standard.EliminateAll(values)
+}
+
+// EliminateAll is the equivalent of the package-level EliminateAll for boards
+// of grid g.
+func (g *Grid) EliminateAll(values Values) bool {
+	// Additional constraints may eliminate candidates even on an empty board
+	// (e.g. a killer cage with a small sum).
+	if !g.propagateConstraints(values) {
+		return false
+	}
+
 	for sq, d := range values {
 		if d.Size() == 1 {
 			// Because of how eliminate() works, we prepare for it by remembering
@@ -177,10 +171,10 @@ func EliminateAll(values Values) bool {
 			// set of digits and then calling eliminate on all digits except the
 			// assigned one.
 			digit := d.SingleMemberDigit()
-			values[sq] = FullDigitsSet()
-			for dn := uint16(1); dn <= 9; dn++ {
+			values[sq] = g.full
+			for dn := uint16(1); dn <= uint16(g.size); dn++ {
 				if dn != digit {
-					if !eliminate(values, sq, dn) {
+					if !g.eliminate(values, sq, dn) {
 						return false
 					}
 				}
@@ -201,20 +195,28 @@ func CountHints(values Values) int {
 	return hintcount
 }
 
+// withStats returns a copy of g that collects the statistics of assignments
+// and eliminations in stats.
+func (g *Grid) withStats(stats *SolveStats) *Grid {
+	ng := *g
+	ng.stats = stats
+	return &ng
+}
+
 // assign attempts to assign digit to values[square], propagating
 // constraints from the assignment. values is modified.
 // It returns true if the assignment succeeded, and false if the assignment
 // fails resulting in an invalid Sudoku board.
-func assign(values Values, square Index, digit uint16) bool {
-	if EnableStats {
-		Stats.NumAssigns++
+func (g *Grid) assign(values Values, square Index, digit uint16) bool {
+	if g.stats != nil {
+		g.stats.NumAssigns++
 	}
 
-	for d := uint16(1); d <= 9; d++ {
-		// For each d 1..9 that's != digit, if d is set in
-		// values[square], try to eliminate it.
+	for d := uint16(1); d <= uint16(g.size); d++ {
+		// For each d that's != digit, if d is set in values[square], try to
+		// eliminate it.
 		if values[square].IsMember(d) && d != digit {
-			if !eliminate(values, square, d) {
+			if !g.eliminate(values, square, d) {
 				return false
 			}
 		}
@@ -226,7 +228,7 @@ func assign(values Values, square Index, digit uint16) bool {
 // constraints. values is modified.
 // It returns false if this results in an invalid Sudoku board; otherwise
 // returns true.
-func eliminate(values Values, square Index, digit uint16) bool {
+func (g *Grid) eliminate(values Values, square Index, digit uint16) bool {
 	if !values[square].IsMember(digit) {
 		// Already eliminated
 		return true
@@ -234,6 +236,9 @@ func eliminate(values Values, square Index, digit uint16) bool {
 
 	// Remove digit from the candidates in square.
 	values[square] = values[square].Remove(digit)
+	if g.stats != nil {
+		g.stats.NumEliminations++
+	}
 
 	switch values[square].Size() {
 	case 0:
@@ -243,8 +248,8 @@ func eliminate(values Values, square Index, digit uint16) bool {
 		// A single digit candidate remaining in the square -- this creates a new
 		// constraint. Eliminate this digit from all peer squares.
 		remaining := values[square].SingleMemberDigit()
-		for _, peer := range peers[square] {
-			if !eliminate(values, peer, remaining) {
+		for _, peer := range g.peers[square] {
+			if !g.eliminate(values, peer, remaining) {
 				return false
 			}
 		}
@@ -253,7 +258,7 @@ func eliminate(values Values, square Index, digit uint16) bool {
 	// Since digit was eliminated from square, it's possible that we'll find a
 	// position for this digit in one of the units the square belongs to.
 UnitLoop:
-	for _, unit := range units[square] {
+	for _, unit := range g.units[square] {
 		// Looking for a single square in this unit that has 'digit' as one of its
 		// candidates. sqd marks the square, or -1 if no such square was found.
 		sqd := -1
@@ -275,7 +280,15 @@ UnitLoop:
 
 		// There's only a single place left in the unit for 'digit' to go, so
 		// assign it.
-		if !assign(values, sqd, digit) {
+		if !g.assign(values, sqd, digit) {
+			return false
+		}
+	}
+
+	// Give the additional constraints that apply to this square a chance to
+	// eliminate more candidates.
+	for _, c := range g.squareConstraints[square] {
+		if !c.propagate(g, values) {
 			return false
 		}
 	}
@@ -286,6 +299,11 @@ UnitLoop:
 // Display returns a visual representation of values, with all the digit
 // candidates as a string in each cell.
 func Display(values Values) string {
+	return standard.Display(values)
+}
+
+// Display is the equivalent of the package-level Display for boards of grid g.
+func (g *Grid) Display(values Values) string {
 	// Find maximum length of one square.
 	var maxlen int = 0
 	for _, d := range values {
@@ -295,51 +313,100 @@ func Display(values Values) string {
 	}
 	width := maxlen + 1
 
-	line := strings.Join([]string{
-		strings.Repeat("-", width*3),
-		strings.Repeat("-", width*3),
-		strings.Repeat("-", width*3)}, "+")
-
-	var sb strings.Builder
-	for sq, d := range values {
-		fmt.Fprintf(&sb, "%[1]*s", -width, fmt.Sprintf("%[1]*s", (width+d.Size())/2, d))
-		if sq%9 == 2 || sq%9 == 5 {
-			sb.WriteString("|")
-		}
-		if sq%9 == 8 {
-			sb.WriteRune('\n')
-		}
-		if sq == 26 || sq == 53 {
-			sb.WriteString(line + "\n")
-		}
-	}
-	return sb.String()
+	return g.displayWithSeparators(values, width*g.boxCols, func(sb *strings.Builder, d Digits) {
+		fmt.Fprintf(sb, "%[1]*s", -width, fmt.Sprintf("%[1]*s", (width+d.Size())/2, d))
+	})
 }
 
 // DisplayAsInput returns a 2D Sudoku input board corresponding to values.
 // It treats solved squares (with one candidate) as hints that are filled into
 // the board, and unsolved squares (with more than one candidate) as empty.
 func DisplayAsInput(values Values) string {
-	line := strings.Join([]string{
-		strings.Repeat("-", 6),
-		strings.Repeat("-", 6),
-		strings.Repeat("-", 6)}, "+")
+	return standard.DisplayAsInput(values)
+}
 
-	var sb strings.Builder
-	for sq, d := range values {
+// DisplayAsInput is the equivalent of the package-level DisplayAsInput for
+// boards of grid g.
+func (g *Grid) DisplayAsInput(values Values) string {
+	return g.displayWithSeparators(values, 2*g.boxCols, func(sb *strings.Builder, d Digits) {
 		ds := d.String()
 		if d.Size() > 1 {
 			ds = "."
 		}
-		fmt.Fprintf(&sb, "%s ", ds)
-		if sq%9 == 2 || sq%9 == 5 {
-			sb.WriteString("|")
+		fmt.Fprintf(sb, "%s ", ds)
+	})
+}
+
+// displayWithSeparators is a helper for displaying boards in textual form.
+// It invokes writeSquare to write each square into the output and adds
+// separators between boxes; boxWidth is the width of a box in runes, used to
+// draw the horizontal separator lines. Grids with irregular regions have no
+// separators, since the regions can't be drawn with straight lines.
+func (g *Grid) displayWithSeparators(values Values, boxWidth int, writeSquare func(*strings.Builder, Digits)) string {
+	if g.regions != nil {
+		var sb strings.Builder
+		for sq, d := range values {
+			writeSquare(&sb, d)
+			if g.col(sq) == g.size-1 {
+				sb.WriteRune('\n')
+			}
 		}
-		if sq%9 == 8 {
-			sb.WriteRune('\n')
+		return sb.String()
+	}
+
+	// Multi-grids have gaps between the subgrids; these are left blank, and
+	// separators are only drawn next to squares.
+	squareWidth := boxWidth / g.boxCols
+	hasSquare := func(row, col int) bool {
+		_, ok := g.squareAt(row, col)
+		return ok
+	}
+
+	var sb strings.Builder
+	for row := 0; row < g.layoutRows; row++ {
+		var line strings.Builder
+		for col := 0; col < g.layoutCols; col++ {
+			if sq, ok := g.squareAt(row, col); ok {
+				writeSquare(&line, values[sq])
+			} else {
+				line.WriteString(strings.Repeat(" ", squareWidth))
+			}
+			if col%g.boxCols == g.boxCols-1 && col != g.layoutCols-1 {
+				if hasSquare(row, col) || hasSquare(row, col+1) {
+					line.WriteString("|")
+				} else {
+					line.WriteString(" ")
+				}
+			}
 		}
-		if sq == 26 || sq == 53 {
-			sb.WriteString(line + "\n")
+		lineStr := line.String()
+		if !hasSquare(row, g.layoutCols-1) {
+			lineStr = strings.TrimRight(lineStr, " ")
+		}
+		sb.WriteString(lineStr + "\n")
+
+		if row%g.boxRows == g.boxRows-1 && row != g.layoutRows-1 {
+			// The separator line between rows of boxes, with a segment for each
+			// column of boxes that has squares on either side of it.
+			var sepLine strings.Builder
+			prevDrawn := false
+			for col := 0; col < g.layoutCols; col += g.boxCols {
+				drawn := hasSquare(row, col) || hasSquare(row+1, col)
+				if col > 0 {
+					if drawn || prevDrawn {
+						sepLine.WriteString("+")
+					} else {
+						sepLine.WriteString(" ")
+					}
+				}
+				if drawn {
+					sepLine.WriteString(strings.Repeat("-", boxWidth))
+				} else {
+					sepLine.WriteString(strings.Repeat(" ", boxWidth))
+				}
+				prevDrawn = drawn
+			}
+			sb.WriteString(strings.TrimRight(sepLine.String(), " ") + "\n")
 		}
 	}
 	return sb.String()
@@ -348,35 +415,97 @@ func DisplayAsInput(values Values) string {
 // DisplayAsSVG write the board's visual representation in SVG format into w.
 // The difficulty is emitted too.
 func DisplayAsSVG(w io.Writer, values Values, difficulty float64) {
+	standard.DisplayAsSVG(w, values, difficulty)
+}
+
+// DisplayAsSVG is the equivalent of the package-level DisplayAsSVG for boards
+// of grid g. The board is always drawn at the same overall size, so the
+// squares of larger grids are smaller. If g has diagonal units, the diagonals
+// are shaded; if it has irregular regions, their borders are drawn instead of
+// the boxes. Killer cages are drawn as dashed outlines with their sums, and
+// thermometers and arrows in gray. Edge markers are drawn on the borders
+// between their squares, and sandwich clues outside the board. Multi-grids
+// are drawn in their layout, with all the subgrids together.
+func (g *Grid) DisplayAsSVG(w io.Writer, values Values, difficulty float64) {
 	startX := 50
 	startY := 50
 	width := 800
 	height := 900
-	cellsize := 80
+	cellsize := 720 / max(g.layoutRows, g.layoutCols)
+	fontsize := 32 * cellsize / 80
 	canvas := svg.New(w, width, height)
 
-	for sq, d := range values {
-		col := sq % 9
+	for sq := range values {
+		col := g.col(sq)
 		x := startX + col*cellsize
 
-		row := sq / 9
+		row := g.row(sq)
 		y := startY + row*cellsize
 
-		canvas.Rect(x, y, cellsize, cellsize, "stroke:black; stroke-width:2; fill:white")
+		fill := "white"
+		if g.diagonals && g.isOnDiagonal(sq) {
+			fill = "lightgray"
+		}
+		canvas.Rect(x, y, cellsize, cellsize, "stroke:black; stroke-width:2; fill:"+fill)
+	}
+
+	// Thermometers and arrows are drawn under the digits.
+	if len(g.thermometers) > 0 {
+		g.drawThermometers(canvas, startX, startY, cellsize)
+	}
+	if len(g.arrows) > 0 {
+		g.drawArrows(canvas, startX, startY, cellsize)
+	}
+
+	for sq, d := range values {
 		if d.Size() == 1 {
-			canvas.Text(x+cellsize/2, y+cellsize/2, d.String(), "text-anchor:middle; dominant-baseline:middle; font-family:Helvetica; font-size:32px; fill:black")
+			x := startX + g.col(sq)*cellsize
+			y := startY + g.row(sq)*cellsize
+			canvas.Text(x+cellsize/2, y+cellsize/2, d.String(), fmt.Sprintf("text-anchor:middle; dominant-baseline:middle; font-family:Helvetica; font-size:%vpx; fill:black", fontsize))
 		}
 	}
 
-	// Wider squares around 3x3 blocks
-	for br := 0; br < 3; br++ {
-		for bc := 0; bc < 3; bc++ {
-			canvas.Rect(startX+bc*cellsize*3, startY+br*cellsize*3, cellsize*3, cellsize*3, "stroke:black; stroke-width:5; fill-opacity:0.0")
+	if len(g.cages) > 0 {
+		g.drawCages(canvas, startX, startY, cellsize)
+	}
+
+	if g.regions != nil {
+		// Wider lines along the edges between irregular regions, and a wider
+		// square around the whole board.
+		regionStyle := "stroke:black; stroke-width:5; stroke-linecap:square"
+		for sq := range values {
+			col, row := g.col(sq), g.row(sq)
+			x := startX + col*cellsize
+			y := startY + row*cellsize
+			if col < g.size-1 && g.regions[sq] != g.regions[sq+1] {
+				canvas.Line(x+cellsize, y, x+cellsize, y+cellsize, regionStyle)
+			}
+			if row < g.size-1 && g.regions[sq] != g.regions[sq+g.size] {
+				canvas.Line(x, y+cellsize, x+cellsize, y+cellsize, regionStyle)
+			}
+		}
+		canvas.Rect(startX, startY, cellsize*g.size, cellsize*g.size, "stroke:black; stroke-width:5; fill-opacity:0.0")
+	} else {
+		// Wider squares around boxes; boxes shared by overlapping subgrids are
+		// drawn once, from their top-left square.
+		for sq := range values {
+			row, col := g.row(sq), g.col(sq)
+			if row%g.boxRows == 0 && col%g.boxCols == 0 {
+				canvas.Rect(startX+col*cellsize, startY+row*cellsize, cellsize*g.boxCols, cellsize*g.boxRows, "stroke:black; stroke-width:5; fill-opacity:0.0")
+			}
 		}
 	}
 
+	// Edge markers are drawn over the borders between squares.
+	if len(g.edges) > 0 {
+		g.drawEdges(canvas, startX, startY, cellsize)
+	}
+	if len(g.sandwiches) > 0 {
+		g.drawSandwiches(canvas, startX, startY, cellsize)
+	}
+
 	difficultyText := fmt.Sprintf("Difficulty: %.2f out of 5", difficulty)
-	canvas.Text(startX, startY+9*cellsize+cellsize/2, difficultyText, "font-family:Helvetica; font-size:16px; fill:black")
+	canvas.Text(startX, startY+g.layoutRows*cellsize+cellsize/2, difficultyText, "font-family:Helvetica; font-size:16px; fill:black")
 
 	canvas.End()
 }
@@ -384,9 +513,15 @@ func DisplayAsSVG(w io.Writer, values Values, difficulty float64) {
 // EmptyBoard creates an "empty" Sudoku board, where each square can potentially
 // contain any digit.
 func EmptyBoard() Values {
-	vals := make(Values, 81)
+	return standard.EmptyBoard()
+}
+
+// EmptyBoard creates an "empty" board for grid g, where each square can
+// potentially contain any digit.
+func (g *Grid) EmptyBoard() Values {
+	vals := make(Values, g.NumSquares())
 	for sq := range vals {
-		vals[sq] = FullDigitsSet()
+		vals[sq] = g.full
 	}
 	return vals
 }
@@ -394,7 +529,13 @@ func EmptyBoard() Values {
 // IsSolved checks whether values is a properly solved Sudoku board, with all
 // the constraints satisfied.
 func IsSolved(values Values) bool {
-	for _, unit := range unitlist {
+	return standard.IsSolved(values)
+}
+
+// IsSolved checks whether values is a properly solved board of grid g, with
+// all the constraints satisfied.
+func (g *Grid) IsSolved(values Values) bool {
+	for _, unit := range g.unitlist {
 		var dset Digits
 		for _, sq := range unit {
 			// Some squares have more than a single candidate? Not solved.
@@ -404,18 +545,30 @@ func IsSolved(values Values) bool {
 			dset = dset.Add(values[sq].SingleMemberDigit())
 		}
 		// Not all digits covered by this unit? Not solved.
-		if dset != FullDigitsSet() {
+		if dset != g.full {
 			return false
 		}
 	}
-	return true
+
+	// Squares in peer groups must have different digits.
+	for _, group := range g.peerGroups {
+		var dset Digits
+		for _, sq := range group {
+			if values[sq].Size() != 1 || dset&values[sq] != 0 {
+				return false
+			}
+			dset |= values[sq]
+		}
+	}
+
+	return g.constraintsSatisfied(values)
 }
 
 // findSquareWithFewestCandidates finds a square in values with more than one
 // digit candidate, but the smallest number of such candidates.
-func findSquareWithFewestCandidates(values Values) Index {
+func (g *Grid) findSquareWithFewestCandidates(values Values) Index {
 	var squareToTry Index = -1
-	var minSize int = 10
+	var minSize int = g.size + 1
 	for sq, d := range values {
 		if d.Size() > 1 && d.Size() < minSize {
 			minSize = d.Size()
@@ -428,10 +581,60 @@ func findSquareWithFewestCandidates(values Values) Index {
 // SolveOptions is a container of options for the Solve function.
 type SolveOptions struct {
 	// Randomize tells the solver to randomly shuffle its digit selection when
-	// attempting to guess a value for a square. For actual randomness, the
-	// rand package's default randomness source should be properly seeded before
-	// invoking Solve.
+	// attempting to guess a value for a square.
 	Randomize bool
+
+	// Rand is the source of randomness when Randomize is true; if it's nil, the
+	// global source of math/rand is used. Set it to a seeded *rand.Rand for
+	// reproducible searches. Like the *rand.Rand, searches (and Solvers) that
+	// share it aren't safe for concurrent use.
+	Rand *rand.Rand
+
+	// MaxNodes is the node budget of the search: the maximal number of search
+	// nodes (squares where the solver has to guess a digit) to visit before
+	// giving up. 0 means no limit. When the budget is exceeded, the
+	// context-aware functions like SolveContext return a SearchAbortedError.
+	MaxNodes uint64
+
+	// Backend selects the search algorithm; the default is
+	// BackendPropagation.
+	Backend Backend
+
+	// Stats, if not nil, is where the statistics of the search are stored when
+	// it's done. Unlike global state, it's safe to use with searches running
+	// concurrently, as long as each has its own SolveStats.
+	Stats *SolveStats
+
+	// Workers is the number of goroutines SolveAll and CountSolutions split
+	// their search among, to use multiple cores; a negative value means
+	// runtime.GOMAXPROCS(0), and 0 or 1 means the search isn't split. The
+	// solutions are in the same order regardless of the number of workers
+	// (with the propagation backend, it's the order of a search that isn't
+	// split), and the node budget is shared by the workers, though they may
+	// slightly exceed it.
+	Workers int
+
+	// SubsetSize, if it's 2 or more, makes the propagation backend apply the
+	// naked and hidden subset strategies (see ApplyNakedSubsets and
+	// ApplyHiddenSubsets) of up to SubsetSize squares after each guess, as an
+	// additional level of constraint propagation. The search makes fewer
+	// guesses, but each takes longer, and the fast solver isn't used. It's
+	// ignored by the other backends.
+	SubsetSize int
+}
+
+// solveOptions returns the single SolveOptions in options, or the default
+// options if there are none. fn is the name of the calling function, for the
+// panic message when there are several.
+func solveOptions(fn string, options []SolveOptions) SolveOptions {
+	if len(options) > 1 {
+		panic(fn + " cannot accept more than a single SolveOptions")
+	}
+	var opts SolveOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	return opts
 }
 
 // Solve runs a backtracking search to solve the board given in values.
@@ -440,25 +643,48 @@ type SolveOptions struct {
 // false. The input values is not modified.
 // The solution process can be configured by providing SolveOptions.
 func Solve(values Values, options ...SolveOptions) (Values, bool) {
-	if len(options) > 1 {
-		panic("Solve cannot accept more than a single SolveOptions")
-	}
+	return standard.Solve(values, options...)
+}
+
+// Solve is the equivalent of the package-level Solve for boards of grid g.
+func (g *Grid) Solve(values Values, options ...SolveOptions) (Values, bool) {
+	vresult, solved, _ := g.SolveContext(context.Background(), values, options...)
+	return vresult, solved
+}
+
+// SolveContext is like Solve, but the search is aborted when ctx is done or
+// when it exceeds the node budget set in options; in this case it returns
+// values, false and a *SearchAbortedError.
+func SolveContext(ctx context.Context, values Values, options ...SolveOptions) (Values, bool, error) {
+	return standard.SolveContext(ctx, values, options...)
+}
 
-	squareToTry := findSquareWithFewestCandidates(values)
+// SolveContext is the equivalent of the package-level SolveContext for boards
+// of grid g.
+func (g *Grid) SolveContext(ctx context.Context, values Values, options ...SolveOptions) (Values, bool, error) {
+	return g.newSolver(solveOptions("Solve", options)).Solve(ctx, values)
+}
+
+// search is the recursive backtracking search of Solve. If rng isn't nil,
+// digits are tried in random order.
+func (g *Grid) search(s *searchState, values Values, rng *rand.Rand) (Values, bool) {
+	squareToTry := g.findSquareWithFewestCandidates(values)
 
 	// If we didn't find any square with more than one candidate, the board is
-	// solved!
+	// solved! Unless its additional constraints aren't satisfied, which may
+	// happen when values weren't eliminated.
 	if squareToTry == -1 {
-		return values, true
+		return values, g.constraintsSatisfied(values)
 	}
 
-	if EnableStats {
-		Stats.NumSearches++
+	if !s.visit() {
+		return values, false
 	}
+	defer s.leave()
 
-	var candidates = []uint16{1, 2, 3, 4, 5, 6, 7, 8, 9}
-	if len(options) > 0 && options[0].Randomize {
-		rand.Shuffle(len(candidates), func(i, j int) {
+	candidates := g.digitCandidates()
+	if rng != nil {
+		rng.Shuffle(len(candidates), func(i, j int) {
 			candidates[i], candidates[j] = candidates[j], candidates[i]
 		})
 	}
@@ -468,85 +694,253 @@ func Solve(values Values, options ...SolveOptions) (Values, bool) {
 		// in a successful Solve() - we've solved the board!
 		if values[squareToTry].IsMember(d) {
 			vcopy := slices.Clone(values)
-			if assign(vcopy, squareToTry, d) {
-				if vresult, solved := Solve(vcopy, options...); solved {
+			if g.guess(vcopy, squareToTry, d) {
+				if vresult, solved := g.search(s, vcopy, rng); solved {
 					return vresult, true
+				} else if s.err != nil {
+					break
 				}
 			}
+			s.backtrack()
 		}
 	}
 	return values, false
 }
 
 // SolveAll finds all solutions to the given board and returns them. If no
-// solutions were found, an empty list is returned. max can specify the
-// (approximate) maximal number of solutions to find; a value <= 0 means "all of
-// them". Often more solutions than max will be returned, but not a lot more
-// (maybe 2-3x as many). values is not modified.
+// solutions were found, an empty list is returned. max can specify the maximal
+// number of solutions to find; a value <= 0 means "all of them". values is not
+// modified.
 // Warning: this function can take a LONG time to run for boards with multiple
 // solutions, and it can consume enormous amounts of memory because it has to
 // remember each solution it finds. For some boards it will run forever (e.g.
 // finding all solutions on an empty board). If in doubt, use the max parameter
-// to restrict the number.
+// to restrict the number, use SolveAllContext, or go over the solutions one at
+// a time with Solutions.
 func SolveAll(values Values, max int) []Values {
-	squareToTry := findSquareWithFewestCandidates(values)
+	return standard.SolveAll(values, max)
+}
+
+// SolveAll is the equivalent of the package-level SolveAll for boards of grid
+// g.
+func (g *Grid) SolveAll(values Values, max int) []Values {
+	solutions, _ := g.SolveAllContext(context.Background(), values, max)
+	return solutions
+}
+
+// SolveAllContext is like SolveAll, but the search is aborted when ctx is done
+// or when it exceeds the node budget set in options (Randomize is ignored). In
+// this case it returns the solutions found so far, and a *SearchAbortedError.
+func SolveAllContext(ctx context.Context, values Values, max int, options ...SolveOptions) ([]Values, error) {
+	return standard.SolveAllContext(ctx, values, max, options...)
+}
+
+// SolveAllContext is the equivalent of the package-level SolveAllContext for
+// boards of grid g.
+func (g *Grid) SolveAllContext(ctx context.Context, values Values, max int, options ...SolveOptions) ([]Values, error) {
+	return g.newSolver(solveOptions("SolveAll", options)).SolveAll(ctx, values, max)
+}
+
+// Solutions returns an iterator over the solutions of the given board. The
+// solutions are found one at a time as the iteration proceeds, so only the
+// ones the consumer keeps take up memory, and stopping the iteration stops the
+// search. values is not modified, and each yielded solution is a fresh Values
+// owned by the consumer. Only the Backend field of options is used.
+// For example, to look at the first 1000 solutions of a board:
+//
+//	for solution := range Solutions(values) {
+//		...
+//		if n++; n == 1000 {
+//			break
+//		}
+//	}
+func Solutions(values Values, options ...SolveOptions) iter.Seq[Values] {
+	return standard.Solutions(values, options...)
+}
+
+// Solutions is the equivalent of the package-level Solutions for boards of
+// grid g.
+func (g *Grid) Solutions(values Values, options ...SolveOptions) iter.Seq[Values] {
+	e := g.engine(solveOptions("Solutions", options).Backend)
+	return func(yield func(Values) bool) {
+		e.enumerate(unboundedSearch(), values, yield)
+	}
+}
+
+// enumerate runs a backtracking search for all the solutions of values, and
+// calls yield with each one it finds. values may be modified, and may be
+// yielded itself if it's already solved. It returns false if the enumeration
+// was stopped, either by yield or because the search was aborted.
+func (g *Grid) enumerate(s *searchState, values Values, yield func(Values) bool) bool {
+	squareToTry := g.findSquareWithFewestCandidates(values)
 
 	// If we didn't find any square with more than one candidate, the board is
-	// solved!
+	// solved! Unless its additional constraints aren't satisfied, which may
+	// happen when values weren't eliminated.
 	if squareToTry == -1 {
-		return []Values{values}
+		if !g.constraintsSatisfied(values) {
+			return true
+		}
+		return yield(values)
 	}
 
-	var allSolved []Values
+	if !s.visit() {
+		return false
+	}
+	defer s.leave()
 
-	for d := uint16(1); d <= 9; d++ {
-		// Try to assign sq with each one of its candidate digits. If this results
-		// in a successful Solve() - we've solved the board!
+	for d := uint16(1); d <= uint16(g.size); d++ {
+		// Try to assign sq with each one of its candidate digits, and enumerate
+		// the solutions of the resulting board.
 		if values[squareToTry].IsMember(d) {
 			vcopy := slices.Clone(values)
-			if assign(vcopy, squareToTry, d) {
-				if vsolved := SolveAll(vcopy, max); len(vsolved) > 0 {
-					allSolved = append(allSolved, vsolved...)
-					if max > 0 && len(allSolved) >= max {
-						return allSolved
-					}
-				}
+			if g.guess(vcopy, squareToTry, d) && !g.enumerate(s, vcopy, yield) {
+				return false
 			}
+			s.backtrack()
 		}
 	}
-	return allSolved
+	return true
 }
 
-// EnableStats enables statistics collection during the processes of solving.
-// When stats are enabled, solving will be slightly slower.
-//
-// Note: statistics collection is NOT SAFE FOR CONCURRENT ACCESS.
-var EnableStats bool = false
+// CountSolutions counts the solutions of the board given by values. limit can
+// specify the maximal number of solutions to count; a value <= 0 means "all of
+// them". values is not modified, and doesn't have to be eliminated.
+// Unlike SolveAll, the solutions aren't kept, and the search reuses its
+// buffers between calls, so counting is faster and doesn't allocate memory.
+// Only the Backend, Stats, Workers and SubsetSize fields of options are used;
+// the DLX backend is often faster for counting a large number of solutions.
+func CountSolutions(values Values, limit int, options ...SolveOptions) int {
+	return standard.CountSolutions(values, limit, options...)
+}
 
-type StatsCollector struct {
-	NumSearches uint64
-	NumAssigns  uint64
+// CountSolutions is the equivalent of the package-level CountSolutions for
+// boards of grid g.
+func (g *Grid) CountSolutions(values Values, limit int, options ...SolveOptions) int {
+	opts := solveOptions("CountSolutions", options)
+	if opts.Stats != nil || opts.Workers != 0 {
+		sv := g.newSolver(SolveOptions{Backend: opts.Backend, Stats: opts.Stats, Workers: opts.Workers, SubsetSize: opts.SubsetSize})
+		count, _ := sv.CountSolutions(context.Background(), values, limit)
+		return count
+	}
+	return g.withOptions(opts).engine(opts.Backend).count(nil, values, limit)
 }
 
-// Stats is the global variable for accessing statistics from this package.
-// It's recommended to call Stats.Reset() before solving a board, and access
-// the Stats fields after it's done.
-var Stats StatsCollector
+// HasUniqueSolution checks whether the board given by values has exactly one
+// solution. values is not modified, and doesn't have to be eliminated.
+func HasUniqueSolution(values Values) bool {
+	return standard.HasUniqueSolution(values)
+}
 
-func (s *StatsCollector) Reset() {
-	s.NumSearches = 0
-	s.NumAssigns = 0
+// HasUniqueSolution is the equivalent of the package-level HasUniqueSolution
+// for boards of grid g.
+func (g *Grid) HasUniqueSolution(values Values) bool {
+	return g.CountSolutions(values, 2) == 1
 }
 
-// WithStats helps run any block of code with stats enabled.
-func WithStats(f func()) {
-	EnableStats = true
-	defer func() {
-		EnableStats = false
-	}()
-	Stats.Reset()
+// solutionCounter counts solutions with a backtracking search that copies
+// boards into a buffer per search depth instead of cloning them.
+type solutionCounter struct {
+	// grid is a copy of the counted board's grid that collects the statistics
+	// of the search, and g points to it.
+	g    *Grid
+	grid Grid
+
+	// s is the state of the search; unbounded is used for searches that are
+	// never aborted, to avoid allocating a state for each one.
+	s         *searchState
+	unbounded searchState
+
+	limit int
+	count int
+
+	// boards[i] is the buffer for the board at search depth i; boards[0] holds
+	// the eliminated copy of the counted board.
+	boards []Values
+}
+
+// counterPool holds solutionCounters for reuse between calls.
+var counterPool = sync.Pool{
+	New: func() any {
+		return new(solutionCounter)
+	},
+}
+
+// getSolutionCounter gets a solutionCounter for grid g from the pool, with
+// search state s; if s is nil, the search is never aborted. The counter
+// should be returned with putSolutionCounter when it's no longer needed.
+func (g *Grid) getSolutionCounter(s *searchState) *solutionCounter {
+	c := counterPool.Get().(*solutionCounter)
+	if len(c.boards) > 0 && len(c.boards[0]) != g.NumSquares() {
+		c.boards = c.boards[:0]
+	}
+	if s == nil {
+		c.unbounded = searchState{}
+		s = &c.unbounded
+	}
+	c.s = s
+	c.grid = *g
+	c.grid.stats = &s.stats
+	c.g = &c.grid
+	return c
+}
 
-	f()
+func putSolutionCounter(c *solutionCounter) {
+	c.g, c.s = nil, nil
+	c.grid = Grid{}
+	counterPool.Put(c)
+}
+
+// board returns the buffer for the board at the given search depth.
+func (c *solutionCounter) board(depth int) Values {
+	for len(c.boards) <= depth {
+		c.boards = append(c.boards, make(Values, c.g.NumSquares()))
+	}
+	return c.boards[depth]
+}
+
+// countSolutions counts the solutions of values, up to limit if limit > 0.
+// values is not modified. If the search is aborted, the count so far is
+// returned and c.s.err is set.
+func (c *solutionCounter) countSolutions(values Values, limit int) int {
+	c.count, c.limit = 0, limit
+	board := c.board(0)
+	copy(board, values)
+	if c.g.EliminateAll(board) {
+		c.search(board, 1)
+	}
+	return c.count
+}
+
+// search counts the solutions of values, using the buffers from depth on. It
+// returns false if the search should stop, either because the limit was
+// reached or because it was aborted.
+func (c *solutionCounter) search(values Values, depth int) bool {
+	g := c.g
+	squareToTry := g.findSquareWithFewestCandidates(values)
+	if squareToTry == -1 {
+		if g.constraintsSatisfied(values) {
+			c.count++
+		}
+		return c.limit <= 0 || c.count < c.limit
+	}
+
+	if !c.s.visit() {
+		return false
+	}
+	defer c.s.leave()
+
+	next := c.board(depth)
+	for d := uint16(1); d <= uint16(g.size); d++ {
+		if values[squareToTry].IsMember(d) {
+			copy(next, values)
+			if g.guess(next, squareToTry, d) && !c.search(next, depth+1) {
+				return false
+			}
+			c.s.backtrack()
+		}
+	}
+	return true
 }
 
 // ApplyTwinsStrategy applies the "naked twins" Sudoku strategy to the given
@@ -559,12 +953,20 @@ func WithStats(f func()) {
 // candidates, which means that 3 and 8 must occupy these squares (though we
 // don't know which goes where), and that no other square in the unit may have
 // either 3 or 8.
+// ApplyNakedSubsets generalizes this strategy to squares with different
+// candidates, and to subsets of more than two squares.
 func ApplyTwinsStrategy(values Values) bool {
+	return standard.ApplyTwinsStrategy(values)
+}
+
+// ApplyTwinsStrategy is the equivalent of the package-level ApplyTwinsStrategy
+// for boards of grid g.
+func (g *Grid) ApplyTwinsStrategy(values Values) bool {
 	// The strategy is repeated to a "fixed point" where further runs don't end
 	// up changing the board in any way.
 RepeatStrategy:
 	for {
-		for _, unit := range unitlist {
+		for _, unit := range g.unitlist {
 			// dcount will map Digits->count, counting how many times a certain
 			// combination of digit candidates appears in this unit.
 			dcount := make(map[Digits]int)
@@ -582,13 +984,13 @@ RepeatStrategy:
 					for _, sq := range unit {
 						if values[sq].Size() >= 2 && values[sq] != d {
 							if values[sq].IsMember(d1) {
-								if !eliminate(values, sq, d1) {
+								if !g.eliminate(values, sq, d1) {
 									return false
 								}
 								removed = true
 							}
 							if values[sq].IsMember(d2) {
-								if !eliminate(values, sq, d2) {
+								if !g.eliminate(values, sq, d2) {
 									return false
 								}
 								removed = true
@@ -10,7 +10,8 @@ import (
 )
 
 func TestInit(t *testing.T) {
-	// Smoke testing for the top-level vars initialized in init()
+	// Smoke testing for the units and peers of the standard grid
+	unitlist, units, peers := standard.unitlist, standard.units, standard.peers
 	if len(unitlist) != 27 {
 		t.Errorf("got len=%v, want 27", len(unitlist))
 	}
@@ -43,14 +44,14 @@ func TestAssignElimination(t *testing.T) {
 
 	// Assign a digit to square 20; check that this digit is the only candidate
 	// in square 20, and that it was eliminated from all the peers of 20.
-	assign(vals, 20, 5)
+	standard.assign(vals, 20, 5)
 
 	if vals[20].Size() != 1 || vals[20].SingleMemberDigit() != 5 {
 		t.Errorf("got vals[20]=%v", vals[20])
 	}
 
 	for sq := 0; sq <= 80; sq++ {
-		if slices.Contains(peers[20], sq) {
+		if slices.Contains(standard.peers[20], sq) {
 			if vals[sq].IsMember(5) {
 				t.Errorf("got member 5 in peer square %v", sq)
 			}
@@ -177,35 +178,47 @@ func TestSolveBoard(t *testing.T) {
 
 func TestSolveWithStats(t *testing.T) {
 	// The easy board is solved just by calling ParseBoard, needing no search.
-	WithStats(func() {
-		_, err := ParseBoard(easyboard1, true)
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		if Stats.NumAssigns == 0 {
-			t.Errorf("got NumAssigns==0")
-		}
-		if Stats.NumSearches != 0 {
-			t.Errorf("got NumSearches=%v, want 0", Stats.NumSearches)
-		}
+	v, err := ParseBoard(easyboard1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats SolveStats
+	if _, solved := Solve(v, SolveOptions{Stats: &stats}); !solved {
+		t.Errorf("expect easyboard1 to be solved")
+	}
+	if stats.NumSearches != 0 || stats.NumAssigns != 0 || stats.MaxDepth != 0 {
+		t.Errorf("got stats %+v, want no searches or assigns", stats)
+	}
 
-		// For the hard board, we'll find both assigns and searches
-		Stats.Reset()
+	// For the hard board, we'll find both assigns and searches
+	v, err = ParseBoard(hardboard1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = Solve(v, SolveOptions{Stats: &stats})
 
-		v, err := ParseBoard(hardboard1, true)
-		if err != nil {
-			t.Fatal(err)
-		}
-		_, _ = Solve(v)
+	if stats.NumAssigns == 0 || stats.NumEliminations == 0 {
+		t.Errorf("got NumAssigns=%v, NumEliminations=%v", stats.NumAssigns, stats.NumEliminations)
+	}
+	if stats.NumSearches == 0 || stats.NumBacktracks == 0 {
+		t.Errorf("got NumSearches=%v, NumBacktracks=%v", stats.NumSearches, stats.NumBacktracks)
+	}
+	if stats.MaxDepth == 0 || uint64(stats.MaxDepth) > stats.NumSearches {
+		t.Errorf("got MaxDepth=%v for %v searches", stats.MaxDepth, stats.NumSearches)
+	}
+	if stats.Elapsed <= 0 {
+		t.Errorf("got Elapsed=%v", stats.Elapsed)
+	}
 
-		if Stats.NumAssigns == 0 {
-			t.Errorf("got NumAssigns==0")
-		}
-		if Stats.NumSearches == 0 {
-			t.Errorf("got NumSearches==0")
-		}
-	})
+	// Counting solutions has its own stats; it eliminates the board first, so
+	// it does the same searches.
+	var countStats SolveStats
+	if n := CountSolutions(v, -1, SolveOptions{Stats: &countStats}); n != 1 {
+		t.Errorf("got %v solutions, want 1", n)
+	}
+	if countStats.NumSearches == 0 || countStats.NumAssigns == 0 {
+		t.Errorf("got count stats %+v", countStats)
+	}
 }
 
 func TestIsSolved(t *testing.T) {
@@ -293,6 +306,127 @@ func TestSolveAll(t *testing.T) {
 	}
 }
 
+func TestSolveAllExactMax(t *testing.T) {
+	for _, max := range []int{1, 2, 7, 100} {
+		vs := SolveAll(EmptyBoard(), max)
+		if len(vs) != max {
+			t.Errorf("got %v solutions, want %v", len(vs), max)
+		}
+	}
+}
+
+func TestSolutions(t *testing.T) {
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	vcopy := slices.Clone(v)
+
+	var vs []Values
+	for solution := range Solutions(v) {
+		vs = append(vs, solution)
+	}
+	if len(vs) != 1 || !IsSolved(vs[0]) {
+		t.Fatalf("got %v, want a single solution", vs)
+	}
+	if !slices.Equal(v, vcopy) {
+		t.Errorf("Solutions modified its input values")
+	}
+
+	// The board with 1s and 2s interchangeable, as in TestSolveAll, has two
+	// different solutions.
+	board := vs[0]
+	for sq, d := range board {
+		if d.Size() == 1 && (d.IsMember(1) || d.IsMember(2)) {
+			board[sq] = d.Add(1).Add(2)
+		}
+	}
+	vs = slices.Collect(Solutions(board))
+	if len(vs) != 2 || slices.Equal(vs[0], vs[1]) {
+		t.Errorf("got %v, want two different solutions", vs)
+	}
+
+	// The empty board has a practically infinite number of solutions; the
+	// consumer stops the enumeration.
+	n := 0
+	for solution := range Solutions(EmptyBoard()) {
+		if !IsSolved(solution) {
+			t.Errorf("got unsolved board %v", solution)
+		}
+		if n++; n == 50 {
+			break
+		}
+	}
+	if n != 50 {
+		t.Errorf("got %v solutions, want 50", n)
+	}
+}
+
+func TestCountSolutions(t *testing.T) {
+	v, err := ParseBoard(hardboard1, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	vcopy := slices.Clone(v)
+	if n := CountSolutions(v, -1); n != 1 {
+		t.Errorf("got %v solutions, want 1", n)
+	}
+	if !slices.Equal(v, vcopy) {
+		t.Errorf("CountSolutions modified its input values")
+	}
+	if !HasUniqueSolution(v) {
+		t.Errorf("got HasUniqueSolution=false, want true")
+	}
+
+	// The board with 1s and 2s interchangeable, as in TestSolveAll.
+	board := SolveAll(v, 1)[0]
+	for sq, d := range board {
+		if d.Size() == 1 && (d.IsMember(1) || d.IsMember(2)) {
+			board[sq] = d.Add(1).Add(2)
+		}
+	}
+	if n := CountSolutions(board, -1); n != 2 {
+		t.Errorf("got %v solutions, want 2", n)
+	}
+	if n := CountSolutions(board, 1); n != 1 {
+		t.Errorf("got %v solutions with limit 1, want 1", n)
+	}
+	if HasUniqueSolution(board) {
+		t.Errorf("got HasUniqueSolution=true, want false")
+	}
+
+	if n := CountSolutions(EmptyBoard(), 100); n != 100 {
+		t.Errorf("got %v solutions, want 100", n)
+	}
+
+	// The board doesn't have to be eliminated: the same digit in two squares of
+	// a row is caught.
+	v, err = ParseBoard(hardboard1, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if n := CountSolutions(v, -1); n != 1 {
+		t.Errorf("got %v solutions, want 1", n)
+	}
+	v[1] = v[0]
+	if n := CountSolutions(v, -1); n != 0 {
+		t.Errorf("got %v solutions, want 0", n)
+	}
+
+	// Counters are reused between calls; make sure this works across grids
+	// with different numbers of squares.
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := g.CountSolutions(g.EmptyBoard(), -1); n != 288 {
+		t.Errorf("got %v solutions for 4x4 grid, want 288", n)
+	}
+	if n := CountSolutions(vcopy, -1); n != 1 {
+		t.Errorf("got %v solutions, want 1", n)
+	}
+}
+
 func TestHardlong(t *testing.T) {
 	v, err := ParseBoard(hardlong, true)
 	if err != nil {
@@ -301,13 +435,13 @@ func TestHardlong(t *testing.T) {
 
 	// The "hardlong" puzzle has multiple solutions. Norvig says he found 13
 	// different solutions, but there are vastly more. Use SolveAll to explore
-	// the first 1000 or so.
+	// the first 1000.
 
 	// Find the first 1000 solutions
 	vs := SolveAll(v, 1000)
 
-	if len(vs) < 1000 {
-		t.Errorf("got %v solutions, expected at least 1000", len(vs))
+	if len(vs) != 1000 {
+		t.Errorf("got %v solutions, want 1000", len(vs))
 	}
 
 	for _, v := range vs {
@@ -356,18 +490,17 @@ func TestImpossible(t *testing.T) {
 		t.Skip("skipping test in short mode.")
 	}
 
-	WithStats(func() {
-		v, err := ParseBoard(impossible, true)
-		if err != nil {
-			log.Fatal(err)
-		}
-		v, success := Solve(v)
+	v, err := ParseBoard(impossible, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var stats SolveStats
+	v, success := Solve(v, SolveOptions{Stats: &stats})
 
-		if success || IsSolved(v) {
-			t.Errorf("got solved board for impossible")
-		}
-		fmt.Printf("searches=%v, assigns=%v\n", Stats.NumSearches, Stats.NumAssigns)
-	})
+	if success || IsSolved(v) {
+		t.Errorf("got solved board for impossible")
+	}
+	fmt.Printf("searches=%v, assigns=%v\n", stats.NumSearches, stats.NumAssigns)
 }
 
 func TestSolveHardest(t *testing.T) {
@@ -50,3 +50,19 @@ func (c *Canvas) Text(x, y int, text string, style string) {
 	xml.Escape(c.writer, []byte(text))
 	fmt.Fprintf(c.writer, "</text>\n")
 }
+
+func (c *Canvas) Line(x1, y1, x2, y2 int, style string) {
+	fmt.Fprintf(c.writer, `<line x1="%v" y1="%v" x2="%v" y2="%v"`, x1, y1, x2, y2)
+	if len(style) > 0 {
+		fmt.Fprintf(c.writer, ` style="%s"`, style)
+	}
+	fmt.Fprintf(c.writer, "/>\n")
+}
+
+func (c *Canvas) Circle(cx, cy, r int, style string) {
+	fmt.Fprintf(c.writer, `<circle cx="%v" cy="%v" r="%v"`, cx, cy, r)
+	if len(style) > 0 {
+		fmt.Fprintf(c.writer, ` style="%s"`, style)
+	}
+	fmt.Fprintf(c.writer, "/>\n")
+}
@@ -18,6 +18,8 @@ func TestSvgWrite(t *testing.T) {
 	canvas := New(&buf, width, height)
 	canvas.Rect(x, y, 100, 200, "my style")
 	canvas.Text(x+10, y+1, "hello", "")
+	canvas.Line(x, y, x+5, y+6, "stroke:black")
+	canvas.Circle(x, y, 9, "")
 	canvas.End()
 
 	result := buf.String()
@@ -28,6 +30,8 @@ func TestSvgWrite(t *testing.T) {
      xmlns:xlink="http://www.w3.org/1999/xlink">
 <rect x="77" y="88" width="100" height="200" style="my style"/>
 <text x="87" y="89">hello</text>
+<line x1="77" y1="88" x2="82" y2="94" style="stroke:black"/>
+<circle cx="77" cy="88" r="9"/>
 </svg>`
 
 	if strings.TrimSpace(result) != strings.TrimSpace(want) {
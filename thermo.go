@@ -0,0 +1,173 @@
+package sudoku
+
+import (
+	"bufio"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/eliben/go-sudoku/svg"
+)
+
+// Thermometer is a path of squares on which digits have to strictly increase,
+// starting from the bulb at Thermometer[0].
+type Thermometer []Index
+
+// WithThermometers returns a new grid that's a copy of g with the given
+// thermometers added. g itself is not modified. Each two consecutive squares
+// of a thermometer have to be neighbors (sharing an edge or a corner), and a
+// thermometer can't be longer than Size, since its digits are all different.
+// An error is returned if a thermometer is invalid.
+func (g *Grid) WithThermometers(thermos []Thermometer) (*Grid, error) {
+	for _, thermo := range thermos {
+		if len(thermo) < 2 || len(thermo) > g.size {
+			return nil, fmt.Errorf("thermometer with %v squares, want 2-%v", len(thermo), g.size)
+		}
+		for i, sq := range thermo {
+			if sq < 0 || sq >= g.NumSquares() {
+				return nil, fmt.Errorf("thermometer square %v out of range", sq)
+			}
+			if i > 0 && !g.isNeighbor(thermo[i-1], sq) {
+				return nil, fmt.Errorf("thermometer squares %v and %v are not neighbors", thermo[i-1], sq)
+			}
+		}
+	}
+
+	ng := *g
+	for _, thermo := range thermos {
+		ng.addConstraint(thermoConstraint(thermo))
+		ng.peerGroups = append(ng.peerGroups[:len(ng.peerGroups):len(ng.peerGroups)], thermo)
+	}
+	ng.thermometers = append(ng.thermometers[:len(ng.thermometers):len(ng.thermometers)], thermos...)
+	ng.build()
+	return &ng, nil
+}
+
+// Thermometers returns the thermometers of g.
+func (g *Grid) Thermometers() []Thermometer {
+	return g.thermometers
+}
+
+// ParseThermometers parses a textual list of thermometers for grid g. Each
+// thermometer is on a separate line, as a list of the indices of its squares
+// separated by spaces or commas, starting with the bulb. Empty lines and lines
+// starting with '#' are ignored. For example:
+//
+//	# A thermometer going right from the top-left corner, and one going down
+//	0 1 2
+//	9 18 27 36
+func (g *Grid) ParseThermometers(str string) ([]Thermometer, error) {
+	var thermos []Thermometer
+
+	scanner := bufio.NewScanner(strings.NewReader(str))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		squares, err := g.parseSquareList(line)
+		if err != nil {
+			return nil, fmt.Errorf("thermometer %q: %w", line, err)
+		}
+		thermos = append(thermos, Thermometer(squares))
+	}
+	return thermos, scanner.Err()
+}
+
+// isNeighbor checks whether squares sq1 and sq2 share an edge or a corner.
+func (g *Grid) isNeighbor(sq1, sq2 Index) bool {
+	dr := g.row(sq1) - g.row(sq2)
+	dc := g.col(sq1) - g.col(sq2)
+	return sq1 != sq2 && dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1
+}
+
+// thermoConstraint is the constraint for a single thermometer.
+type thermoConstraint Thermometer
+
+func (tc thermoConstraint) squares() []Index {
+	return tc
+}
+
+// propagate enforces bounds along the thermometer: each square's digit has to
+// be larger than the smallest candidate of the square before it, and smaller
+// than the largest candidate of the square after it.
+func (tc thermoConstraint) propagate(g *Grid, values Values) bool {
+	low := 0
+	for _, sq := range tc {
+		if !g.eliminateDigits(values, sq, digitsUpTo(low)) {
+			return false
+		}
+		low = minDigit(values[sq])
+	}
+
+	high := g.size + 1
+	for i := len(tc) - 1; i >= 0; i-- {
+		sq := tc[i]
+		if !g.eliminateDigits(values, sq, g.full&^digitsUpTo(high-1)) {
+			return false
+		}
+		high = maxDigit(values[sq])
+	}
+	return true
+}
+
+func (tc thermoConstraint) satisfied(values Values) bool {
+	for i := 1; i < len(tc); i++ {
+		if minDigit(values[tc[i]]) <= minDigit(values[tc[i-1]]) {
+			return false
+		}
+	}
+	return true
+}
+
+// encode forbids each pair of digits of consecutive squares where the second
+// isn't larger than the first.
+func (tc thermoConstraint) encode(enc *cnfEncoder) {
+	for i := 1; i < len(tc); i++ {
+		for a := uint16(1); a <= uint16(enc.g.size); a++ {
+			for b := uint16(1); b <= a; b++ {
+				enc.add(-enc.variable(tc[i-1], a), -enc.variable(tc[i], b))
+			}
+		}
+	}
+}
+
+// digitsUpTo returns the set of digits 1-n; it's empty if n < 1, and has all
+// the supported digits if n >= maxGridSize.
+func digitsUpTo(n int) Digits {
+	if n < 1 {
+		return 0
+	}
+	n = min(n, maxGridSize)
+	return Digits(1<<(n+1)) - 2
+}
+
+// minDigit returns the smallest digit in d, which must not be empty.
+func minDigit(d Digits) int {
+	return bits.TrailingZeros32(uint32(d))
+}
+
+// maxDigit returns the largest digit in d, which must not be empty.
+func maxDigit(d Digits) int {
+	return 31 - bits.LeadingZeros32(uint32(d))
+}
+
+// drawThermometers draws the thermometers of g onto canvas, as a gray circle
+// for the bulb and a thick gray line through the centers of the other squares.
+func (g *Grid) drawThermometers(canvas *svg.Canvas, startX, startY, cellsize int) {
+	center := func(sq Index) (int, int) {
+		return startX + g.col(sq)*cellsize + cellsize/2, startY + g.row(sq)*cellsize + cellsize/2
+	}
+
+	tubeStyle := fmt.Sprintf("stroke:silver; stroke-width:%v; stroke-linecap:round", cellsize/4)
+	for _, thermo := range g.thermometers {
+		for i := 1; i < len(thermo); i++ {
+			x1, y1 := center(thermo[i-1])
+			x2, y2 := center(thermo[i])
+			canvas.Line(x1, y1, x2, y2, tubeStyle)
+		}
+		x, y := center(thermo[0])
+		canvas.Circle(x, y, cellsize*2/5, "fill:silver")
+	}
+}
@@ -0,0 +1,166 @@
+package sudoku
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var thermoList string = `
+# bulb first
+12 4 13 21
+9 10 18
+76 67 59
+47 48 38
+75 66 74
+69 70 78
+26 34 33
+27 37 45
+50 60 51 61 62
+3 2 1
+`
+
+// A thermo puzzle with the thermoList thermometers.
+var thermoBoard string = `
+. . . |. . . |4 . 2
+. . . |. . . |. . 3
+. . 7 |. . 4 |. . .
+------+------+------
+. . . |. 3 . |. . .
+. 7 . |. . 5 |. 3 .
+. 4 . |. . . |. . .
+------+------+------
+. . . |. . . |. . .
+3 . 2 |. . 7 |1 . .
+. 1 . |3 . . |. . 5`
+
+func TestParseThermometers(t *testing.T) {
+	thermos, err := standard.ParseThermometers(thermoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thermos) != 10 {
+		t.Errorf("got %v thermometers, want 10", len(thermos))
+	}
+	if len(thermos[8]) != 5 || thermos[8][0] != 50 || thermos[8][4] != 62 {
+		t.Errorf("got thermometer %v, want 50 60 51 61 62", thermos[8])
+	}
+
+	for _, bad := range []string{"1 x", "1 81", "1, -1"} {
+		if _, err := standard.ParseThermometers(bad); err == nil {
+			t.Errorf("ParseThermometers(%q): got no error", bad)
+		}
+	}
+}
+
+func TestWithThermometersErrors(t *testing.T) {
+	var tests = []struct {
+		thermos []Thermometer
+		wantErr string
+	}{
+		{[]Thermometer{{0}}, "1 squares"},
+		{[]Thermometer{{0, 1, 2, 3, 4, 5, 6, 7, 8, 17}}, "10 squares"},
+		{[]Thermometer{{0, 2}}, "not neighbors"},
+		{[]Thermometer{{8, 9}}, "not neighbors"},
+		{[]Thermometer{{0, 0}}, "not neighbors"},
+		{[]Thermometer{{80, 81}}, "out of range"},
+	}
+
+	for _, tt := range tests {
+		_, err := standard.WithThermometers(tt.thermos)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("WithThermometers(%v): got error %v, want %q", tt.thermos, err, tt.wantErr)
+		}
+	}
+}
+
+func TestThermoPropagation(t *testing.T) {
+	// A thermometer along a whole row determines the row.
+	tg, err := standard.WithThermometers([]Thermometer{{9, 10, 11, 12, 13, 14, 15, 16, 17}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := tg.EmptyBoard()
+	if !tg.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	for i, sq := range []Index{9, 10, 11, 12, 13, 14, 15, 16, 17} {
+		if v[sq] != SingleDigitSet(uint16(i+1)) {
+			t.Errorf("got %v for square %v, want %v", v[sq], sq, i+1)
+		}
+	}
+
+	// A 3-square thermometer going down-right leaves room for 7 digits in each
+	// square.
+	tg, err = standard.WithThermometers([]Thermometer{{30, 40, 50}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v = tg.EmptyBoard()
+	if !tg.EliminateAll(v) {
+		t.Fatal("contradiction on empty board")
+	}
+	if v[30] != digitsUpTo(7) || v[40] != digitsUpTo(8).Remove(1) || v[50] != digitsUpTo(9)&^digitsUpTo(2) {
+		t.Errorf("got %v, %v, %v, want 1234567, 2345678, 3456789", v[30], v[40], v[50])
+	}
+
+	// Assigning the middle square bounds the others.
+	if !tg.assign(v, 40, 5) {
+		t.Fatal("contradiction on assign")
+	}
+	if v[30] != digitsUpTo(4) || v[50] != digitsUpTo(9)&^digitsUpTo(5) {
+		t.Errorf("got %v, %v, want 1234, 6789", v[30], v[50])
+	}
+}
+
+func TestThermoSolve(t *testing.T) {
+	thermos, err := standard.ParseThermometers(thermoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tg, err := standard.WithThermometers(thermos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := tg.ParseBoard(thermoBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := tg.SolveAll(v, -1)
+	if len(vs) != 1 {
+		t.Fatalf("got %v solutions, want 1", len(vs))
+	}
+	if !tg.IsSolved(vs[0]) {
+		t.Errorf("got unsolved board:\n%v", tg.Display(vs[0]))
+	}
+
+	// Without the thermometers, the board has many solutions.
+	v, err = ParseBoard(thermoBoard, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(SolveAll(v, 2)); got != 2 {
+		t.Errorf("got %v solutions without thermometers, want 2", got)
+	}
+}
+
+func TestThermoSVG(t *testing.T) {
+	tg, err := standard.WithThermometers([]Thermometer{{0, 1, 2}, {40, 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	tg.DisplayAsSVG(&buf, tg.EmptyBoard(), 1.0)
+	svgText := buf.String()
+
+	if got := strings.Count(svgText, "<circle"); got != 2 {
+		t.Errorf("got %v bulbs, want 2", got)
+	}
+	if got := strings.Count(svgText, "stroke:silver"); got != 3 {
+		t.Errorf("got %v tube segments, want 3", got)
+	}
+}
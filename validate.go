@@ -0,0 +1,288 @@
+package sudoku
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ValidationErrorKind is the kind of a problem found by Validate.
+type ValidationErrorKind int
+
+const (
+	// DuplicateDigit means that the same digit was given in several squares
+	// of a unit, or of another group of squares that must have different
+	// digits (like a killer cage).
+	DuplicateDigit ValidationErrorKind = iota
+
+	// NoCandidates means that constraint propagation from the givens leaves a
+	// square without any candidates.
+	NoCandidates
+
+	// NoPlaceForDigit means that constraint propagation from the givens leaves
+	// no place for a digit in a unit.
+	NoPlaceForDigit
+
+	// UnsatisfiableConstraint means that constraint propagation from the
+	// givens leaves a constraint of the grid, like a killer cage's sum, that
+	// can't be satisfied.
+	UnsatisfiableConstraint
+
+	// NoSolution means that the board is consistent as far as constraint
+	// propagation can tell, but it has no solution.
+	NoSolution
+)
+
+var validationErrorKindNames = []string{
+	DuplicateDigit:          "duplicate digit",
+	NoCandidates:            "no candidates",
+	NoPlaceForDigit:         "no place for digit",
+	UnsatisfiableConstraint: "unsatisfiable constraint",
+	NoSolution:              "no solution",
+}
+
+func (k ValidationErrorKind) String() string {
+	if k < 0 || int(k) >= len(validationErrorKindNames) {
+		return fmt.Sprintf("ValidationErrorKind(%d)", int(k))
+	}
+	return validationErrorKindNames[k]
+}
+
+// ValidationError is a problem with a board found by Validate.
+type ValidationError struct {
+	Kind ValidationErrorKind
+
+	// Squares are the squares with the problem, depending on Kind:
+	//
+	//   - DuplicateDigit: the squares where Digit was given.
+	//   - NoCandidates: the square without candidates.
+	//   - NoPlaceForDigit: nil; the unit is in Unit.
+	//   - UnsatisfiableConstraint: the squares of the constraint.
+	//   - NoSolution: a minimal set of clues (squares with a single candidate)
+	//     that has no solution: removing any of them from the board leaves a
+	//     board with a solution. It's empty if the board has no solution even
+	//     without its clues, which happens when the grid's constraints
+	//     contradict each other.
+	Squares []Index
+
+	// Unit is the unit (or other group of squares that must have different
+	// digits) with the duplicate digit, or without a place for Digit; it's
+	// nil for other kinds.
+	Unit Unit
+
+	// Digit is the duplicate digit, or the digit without a place; it's 0 for
+	// other kinds.
+	Digit uint16
+}
+
+func (e *ValidationError) Error() string {
+	switch e.Kind {
+	case DuplicateDigit:
+		return fmt.Sprintf("digit %v appears more than once in unit %v, in squares %v", e.Digit, e.Unit, e.Squares)
+	case NoCandidates:
+		return fmt.Sprintf("square %v has no remaining candidates", e.Squares[0])
+	case NoPlaceForDigit:
+		return fmt.Sprintf("no place left for digit %v in unit %v", e.Digit, e.Unit)
+	case UnsatisfiableConstraint:
+		return fmt.Sprintf("the constraint on squares %v can't be satisfied", e.Squares)
+	case NoSolution:
+		if len(e.Squares) == 0 {
+			return "the board has no solution; the grid's constraints conflict"
+		}
+		return fmt.Sprintf("the board has no solution; the clues in squares %v conflict", e.Squares)
+	}
+	return e.Kind.String()
+}
+
+// Validate checks whether the board values has a solution, and explains why
+// if it doesn't. It returns nil if the board has a solution (which may not be
+// unique), and otherwise the problems found:
+//
+//   - If a digit is given more than once in a unit, the duplicates.
+//   - Otherwise, if constraint propagation from the givens finds a
+//     contradiction, the square without candidates, the unit without a place
+//     for a digit, or the constraint that can't be satisfied it ended with.
+//   - Otherwise, a single NoSolution problem, with a minimal set of clues
+//     that has no solution.
+//
+// values should be the board as given, without elimination, since the
+// squares with a single candidate are taken to be its clues. It's not
+// modified.
+func Validate(values Values) []*ValidationError {
+	return standard.Validate(values)
+}
+
+// Validate is the equivalent of the package-level Validate for boards of grid
+// g.
+func (g *Grid) Validate(values Values) []*ValidationError {
+	problems, _ := g.ValidateContext(context.Background(), values)
+	return problems
+}
+
+// ValidateContext is like Validate, but the searches for solutions are
+// aborted when ctx is done; in this case it returns nil and a
+// *SearchAbortedError. The searches use the SAT backend, which quickly proves
+// that boards have no solution, and supports all the constraints of grids.
+func ValidateContext(ctx context.Context, values Values) ([]*ValidationError, error) {
+	return standard.ValidateContext(ctx, values)
+}
+
+// ValidateContext is the equivalent of the package-level ValidateContext for
+// boards of grid g.
+func (g *Grid) ValidateContext(ctx context.Context, values Values) ([]*ValidationError, error) {
+	if problems := g.localProblems(values); len(problems) > 0 {
+		return problems, nil
+	}
+
+	s, e := newSearchState(ctx, 0), g.engine(BackendSAT)
+	if e.count(s, values, 1) > 0 || s.err != nil {
+		return nil, s.err
+	}
+
+	// Remove the clues one by one, and put back the ones without which the
+	// board has a solution. The clues left are a minimal conflicting set: the
+	// board had a solution without each of them when it had more clues.
+	board := slices.Clone(values)
+	conflict := []Index{}
+	for sq, d := range values {
+		if d.Size() != 1 {
+			continue
+		}
+		board[sq] = g.full
+		if e.count(s, board, 1) > 0 {
+			board[sq] = d
+			conflict = append(conflict, sq)
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	return []*ValidationError{{Kind: NoSolution, Squares: conflict}}, nil
+}
+
+// localProblems returns the problems of values that can be found without
+// searching: duplicate digits, and the contradictions found by constraint
+// propagation. It returns nil if there are none, even if propagation fails.
+func (g *Grid) localProblems(values Values) []*ValidationError {
+	var problems []*ValidationError
+	groups := append(slices.Clone(g.unitlist), g.peerGroups...)
+	for _, group := range groups {
+		var squares [maxGridSize + 1][]Index
+		for _, sq := range group {
+			if values[sq].Size() == 1 {
+				d := values[sq].SingleMemberDigit()
+				squares[d] = append(squares[d], sq)
+			}
+		}
+		for d, sqs := range squares {
+			if len(sqs) > 1 {
+				problems = append(problems, &ValidationError{Kind: DuplicateDigit, Squares: sqs, Unit: group, Digit: uint16(d)})
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	// The simplest contradictions are found without propagation: squares
+	// whose peers have all the digits, and units where the peers of the
+	// empty squares have some digit.
+	candidates := slices.Clone(values)
+	for sq, d := range values {
+		if d.Size() == 1 {
+			for _, peer := range g.peers[sq] {
+				if values[peer].Size() > 1 {
+					candidates[peer] &^= d
+				}
+			}
+		}
+	}
+	if problems := g.emptyPlaces(candidates); len(problems) > 0 {
+		return problems
+	}
+
+	// Otherwise, continue with constraint propagation. Since the digits of the
+	// givens were already eliminated from their peers, propagation doesn't
+	// usually eliminate them from the givens themselves (unlike EliminateAll,
+	// which assigns them again): it stops with the square, the unit or the
+	// constraint where it found a contradiction.
+	if g.propagateCandidates(candidates) {
+		return nil
+	}
+	if problems := g.emptyPlaces(candidates); len(problems) > 0 {
+		return problems
+	}
+	for _, c := range g.constraints {
+		if !c.propagate(g, slices.Clone(candidates)) {
+			return []*ValidationError{{Kind: UnsatisfiableConstraint, Squares: c.squares()}}
+		}
+	}
+	// Propagation failed without a square, unit or constraint to blame; the
+	// search for conflicting clues explains it.
+	return nil
+}
+
+// propagateCandidates runs constraint propagation on values, like
+// EliminateAll does, but without assigning the squares with a single
+// candidate again: it runs the additional constraints, eliminates the digits
+// of these squares from their peers and assigns the digits with a single
+// place in a unit. It returns false if it found a contradiction.
+func (g *Grid) propagateCandidates(values Values) bool {
+	if !g.propagateConstraints(values) {
+		return false
+	}
+	for sq, d := range values {
+		if d.Size() != 1 {
+			continue
+		}
+		for _, peer := range g.peers[sq] {
+			if !g.eliminate(values, peer, d.SingleMemberDigit()) {
+				return false
+			}
+		}
+	}
+	for _, unit := range g.unitlist {
+		for d := uint16(1); d <= uint16(g.size); d++ {
+			place := -1
+			for _, sq := range unit {
+				if values[sq].IsMember(d) {
+					if place >= 0 {
+						place = -2
+						break
+					}
+					place = sq
+				}
+			}
+			if place == -1 || (place >= 0 && !g.assign(values, place, d)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// emptyPlaces returns the problems of the board values where squares have no
+// candidates, or, if there are none, where units have no place for a digit.
+func (g *Grid) emptyPlaces(values Values) []*ValidationError {
+	var problems []*ValidationError
+	for sq, d := range values {
+		if d == 0 {
+			problems = append(problems, &ValidationError{Kind: NoCandidates, Squares: []Index{sq}})
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	for _, unit := range g.unitlist {
+		var union Digits
+		for _, sq := range unit {
+			union |= values[sq]
+		}
+		for d := uint16(1); d <= uint16(g.size); d++ {
+			if !union.IsMember(d) {
+				problems = append(problems, &ValidationError{Kind: NoPlaceForDigit, Unit: unit, Digit: d})
+			}
+		}
+	}
+	return problems
+}
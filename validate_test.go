@@ -0,0 +1,152 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateDuplicates(t *testing.T) {
+	v, err := ParseBoard(hardboard1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := Validate(v); problems != nil {
+		t.Errorf("got problems %v for a valid board", problems)
+	}
+
+	// Squares 0 and 1 share both a row and a box.
+	v[1] = v[0]
+	problems := Validate(v)
+	if len(problems) != 2 {
+		t.Fatalf("got %v problems, want 2", len(problems))
+	}
+	for i, unit := range []Unit{standard.units[0][0], standard.units[0][2]} {
+		p := problems[i]
+		if p.Kind != DuplicateDigit || p.Digit != 4 || !slices.Equal(p.Squares, []Index{0, 1}) || !slices.Equal(p.Unit, unit) {
+			t.Errorf("got problem %+v, want digit 4 in squares 0 and 1 of unit %v", p, unit)
+		}
+	}
+	if msg := problems[0].Error(); !strings.Contains(msg, "digit 4") || !strings.Contains(msg, "[0 1]") {
+		t.Errorf("got message %q", msg)
+	}
+
+	// Squares of a killer cage are checked too.
+	kg, err := standard.WithCages([]Cage{{Sum: 10, Squares: []Index{0, 10}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v = kg.EmptyBoard()
+	v[0], v[10] = SingleDigitSet(5), SingleDigitSet(5)
+	problems = kg.Validate(v)
+	if len(problems) != 2 || !slices.Equal(problems[1].Unit, []Index{0, 10}) {
+		t.Errorf("got problems %v, want duplicates in the box and the cage", problems)
+	}
+}
+
+func TestValidateContradictions(t *testing.T) {
+	var tests = []struct {
+		board string
+		want  ValidationErrorKind
+	}{
+		// Squares 0 and 36 can only have 9, which they can't both have.
+		{".12345678" + strings.Repeat(".", 27) + "9", NoCandidates},
+		// The 1 in the second row leaves no place for 1 in the first.
+		{"...234567..1", NoPlaceForDigit},
+	}
+	for _, tt := range tests {
+		v, err := ParseBoard(tt.board+strings.Repeat(".", 81-len(tt.board)), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		problems := Validate(v)
+		if len(problems) != 1 || problems[0].Kind != tt.want {
+			t.Errorf("got problems %v for board %v, want %v", problems, tt.board, tt.want)
+		}
+	}
+
+	v, err := ParseBoard("...234567..1"+strings.Repeat(".", 69), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := Validate(v)[0]; p.Digit != 1 || !slices.Equal(p.Unit, standard.units[0][0]) {
+		t.Errorf("got problem %v, want no place for 1 in the first row", p)
+	}
+
+	// A cage of 2 squares with a sum of 3 needs a 1, which the box rules out.
+	kg, err := standard.WithCages([]Cage{{Sum: 3, Squares: []Index{0, 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v = kg.EmptyBoard()
+	v[9] = SingleDigitSet(1)
+	problems := kg.Validate(v)
+	if len(problems) != 1 || problems[0].Kind != UnsatisfiableConstraint || !slices.Equal(problems[0].Squares, []Index{0, 1}) {
+		t.Errorf("got problems %v, want unsatisfiable cage", problems)
+	}
+}
+
+func TestValidateNoSolution(t *testing.T) {
+	v, err := ParseBoard(impossible, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := Validate(v)
+	if len(problems) != 1 || problems[0].Kind != NoSolution {
+		t.Fatalf("got problems %v, want no solution", problems)
+	}
+
+	// The conflicting clues have no solution, but any subset of them does.
+	conflict := problems[0].Squares
+	if len(conflict) == 0 || len(conflict) >= CountHints(v) {
+		t.Errorf("got %v conflicting clues, want fewer than all %v", len(conflict), CountHints(v))
+	}
+	board := EmptyBoard()
+	for _, sq := range conflict {
+		board[sq] = v[sq]
+	}
+	if n := CountSolutions(board, 1, satOptions); n != 0 {
+		t.Errorf("got a solution for the conflicting clues")
+	}
+	for _, sq := range conflict {
+		board[sq] = standard.full
+		if n := CountSolutions(board, 1, satOptions); n != 1 {
+			t.Errorf("got no solution without the clue in square %v", sq)
+		}
+		board[sq] = v[sq]
+	}
+}
+
+func TestValidateConflictingConstraints(t *testing.T) {
+	// Both cages need a 1 and a 2, and they're in the same box, so the board
+	// has no solution even without clues.
+	kg, err := standard.WithCages([]Cage{{Sum: 3, Squares: []Index{0, 1}}, {Sum: 3, Squares: []Index{9, 10}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := kg.EmptyBoard()
+	v[80] = SingleDigitSet(5)
+	problems := kg.Validate(v)
+	if len(problems) != 1 || problems[0].Kind != NoSolution || len(problems[0].Squares) != 0 {
+		t.Errorf("got problems %v, want no solution without conflicting clues", problems)
+	}
+	if got, want := problems[0].Error(), "the board has no solution; the grid's constraints conflict"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := kg.ValidateContext(ctx, v); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
+
+func TestParseBoardValidationError(t *testing.T) {
+	_, err := ParseBoard("11"+strings.Repeat(".", 79), true)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Kind != DuplicateDigit || verr.Digit != 1 {
+		t.Errorf("got error %v, want duplicate digit 1", err)
+	}
+}
@@ -0,0 +1,77 @@
+package sudoku
+
+import "slices"
+
+// This file contains Sudoku variants that add units or peers to a grid,
+// without needing any special constraint propagation beyond that.
+
+// WithDiagonals returns a new grid that's a copy of g with both main
+// diagonals added as units, as in the "Sudoku-X" variant: each diagonal has to
+// contain every digit exactly once. g itself is not modified.
+func (g *Grid) WithDiagonals() *Grid {
+	ng := *g
+	ng.diagonals = true
+	ng.build()
+	return &ng
+}
+
+// isOnDiagonal checks whether sq is on one of the main diagonals of g (of any
+// of its subgrids, for multi-grids).
+func (g *Grid) isOnDiagonal(sq Index) bool {
+	for _, sub := range g.subgrids {
+		row, col := g.row(sq)-sub[0], g.col(sq)-sub[1]
+		if row >= 0 && row < g.size && col >= 0 && col < g.size && (row == col || row == g.size-1-col) {
+			return true
+		}
+	}
+	return false
+}
+
+// knightMoves and kingDiagonalMoves are (row, col) offsets for the chess
+// constraints. Only the diagonal king moves are needed, since orthogonally
+// adjacent squares already share a row or a column.
+var knightMoves = [][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+var kingDiagonalMoves = [][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+
+// WithAntiKnight returns a new grid that's a copy of g with the "anti-knight"
+// constraint added: squares a chess knight's move apart can't have the same
+// digit. g itself is not modified.
+func (g *Grid) WithAntiKnight() *Grid {
+	ng := *g
+	if !g.antiKnight {
+		ng.antiKnight = true
+		ng.addPeerMoves(knightMoves)
+		ng.build()
+	}
+	return &ng
+}
+
+// WithAntiKing returns a new grid that's a copy of g with the "anti-king"
+// constraint added: squares a chess king's move apart (i.e. touching, even
+// diagonally) can't have the same digit. g itself is not modified.
+func (g *Grid) WithAntiKing() *Grid {
+	ng := *g
+	if !g.antiKing {
+		ng.antiKing = true
+		ng.addPeerMoves(kingDiagonalMoves)
+		ng.build()
+	}
+	return &ng
+}
+
+// addPeerMoves adds a peer group for every pair of squares that are a move
+// apart, where moves is a list of (row, col) offsets.
+func (g *Grid) addPeerMoves(moves [][2]int) {
+	groups := slices.Clone(g.peerGroups)
+	for sq := 0; sq < g.NumSquares(); sq++ {
+		row, col := g.row(sq), g.col(sq)
+		for _, move := range moves {
+			target, ok := g.squareAt(row+move[0], col+move[1])
+			// Add each pair only once.
+			if ok && target > sq {
+				groups = append(groups, []Index{sq, target})
+			}
+		}
+	}
+	g.peerGroups = groups
+}
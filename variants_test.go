@@ -0,0 +1,188 @@
+package sudoku
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestWithDiagonals(t *testing.T) {
+	xg := standard.WithDiagonals()
+	if len(xg.unitlist) != 29 {
+		t.Errorf("got %v units, want 29", len(xg.unitlist))
+	}
+	if len(standard.unitlist) != 27 {
+		t.Errorf("WithDiagonals modified the original grid")
+	}
+
+	// The center square is on both diagonals; a square on a single diagonal has
+	// 6 extra peers, and a square off the diagonals has no extra peers.
+	if len(xg.units[40]) != 5 || len(xg.peers[40]) != 32 {
+		t.Errorf("got %v units, %v peers for center", len(xg.units[40]), len(xg.peers[40]))
+	}
+	if len(xg.units[0]) != 4 || len(xg.peers[0]) != 26 {
+		t.Errorf("got %v units, %v peers for corner", len(xg.units[0]), len(xg.peers[0]))
+	}
+	if len(xg.units[1]) != 3 || len(xg.peers[1]) != 20 {
+		t.Errorf("got %v units, %v peers for square 1", len(xg.units[1]), len(xg.peers[1]))
+	}
+}
+
+func TestDiagonalIsSolved(t *testing.T) {
+	xg := standard.WithDiagonals()
+
+	// The filled board is a valid standard Sudoku, but its diagonals have
+	// repeating digits.
+	v, err := xg.ParseBoard(filled, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !IsSolved(v) {
+		t.Errorf("expect filled board to be solved")
+	}
+	if xg.IsSolved(v) {
+		t.Errorf("expect filled board to not be solved as Sudoku-X")
+	}
+	if _, err := xg.ParseBoard(filled, true); err == nil {
+		t.Errorf("expect contradiction parsing filled board as Sudoku-X")
+	}
+}
+
+func TestDiagonalSolveAndGenerate(t *testing.T) {
+	xg := standard.WithDiagonals()
+
+	vs, solved := xg.Solve(xg.EmptyBoard(), SolveOptions{Randomize: true})
+	if !solved || !xg.IsSolved(vs) {
+		t.Fatalf("want solved Sudoku-X board; got:\n%v", xg.Display(vs))
+	}
+
+	board := xg.Generate(28)
+	solutions := xg.solveAllWithElimination(board, -1)
+	if len(solutions) != 1 {
+		t.Errorf("got %v solutions, want 1", len(solutions))
+	}
+	if !xg.IsSolved(solutions[0]) {
+		t.Errorf("got unsolved board")
+	}
+
+	board = xg.GenerateSymmetrical(30)
+	solutions = xg.solveAllWithElimination(board, -1)
+	if len(solutions) != 1 {
+		t.Errorf("got %v solutions, want 1", len(solutions))
+	}
+}
+
+func TestDiagonalSmallGrid(t *testing.T) {
+	g, err := NewGrid(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xg := g.WithDiagonals()
+
+	// A 4x4 board with a single hint has 72 solutions, but only 12 of them
+	// have unique digits on the diagonals.
+	v, err := xg.ParseBoard("1...............", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(xg.SolveAll(v, -1)); n != 12 {
+		t.Errorf("got %v Sudoku-X solutions, want 12", n)
+	}
+
+	v, err = g.ParseBoard("1...............", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.SolveAll(v, -1)); n != 72 {
+		t.Errorf("got %v solutions, want 72", n)
+	}
+}
+
+func TestDiagonalSVG(t *testing.T) {
+	xg := standard.WithDiagonals()
+
+	var buf bytes.Buffer
+	xg.DisplayAsSVG(&buf, xg.EmptyBoard(), 1.0)
+	if got := strings.Count(buf.String(), "fill:lightgray"); got != 17 {
+		t.Errorf("got %v shaded squares, want 17", got)
+	}
+
+	buf.Reset()
+	DisplayAsSVG(&buf, EmptyBoard(), 1.0)
+	if strings.Contains(buf.String(), "fill:lightgray") {
+		t.Errorf("expect no shaded squares on a standard board")
+	}
+}
+
+func TestWithAntiKnight(t *testing.T) {
+	kg := standard.WithAntiKnight()
+
+	// All knight moves from the center leave its box; from square 30 (row 3,
+	// col 3), two of the moves stay in the same box, and from the corner both
+	// moves do.
+	if len(kg.peers[40]) != 28 {
+		t.Errorf("got %v peers for center, want 28", len(kg.peers[40]))
+	}
+	if len(kg.peers[30]) != 26 {
+		t.Errorf("got %v peers for square 30, want 26", len(kg.peers[30]))
+	}
+	if len(kg.peers[0]) != 20 {
+		t.Errorf("got %v peers for corner, want 20", len(kg.peers[0]))
+	}
+	if len(standard.peers[40]) != 20 {
+		t.Errorf("WithAntiKnight modified the original grid")
+	}
+
+	// Applying the constraint twice doesn't change anything.
+	if kg2 := kg.WithAntiKnight(); len(kg2.peerGroups) != len(kg.peerGroups) {
+		t.Errorf("got %v peer groups, want %v", len(kg2.peerGroups), len(kg.peerGroups))
+	}
+
+	v := kg.EmptyBoard()
+	kg.assign(v, 40, 5)
+	for _, sq := range []Index{21, 23, 57, 59, 29, 33, 47, 51} {
+		if v[sq].IsMember(5) {
+			t.Errorf("got 5 as candidate in square %v, a knight's move from 40", sq)
+		}
+	}
+}
+
+func TestWithAntiKing(t *testing.T) {
+	kg := standard.WithAntiKing()
+
+	if len(kg.peers[40]) != 20 {
+		t.Errorf("got %v peers for center, want 20", len(kg.peers[40]))
+	}
+	if len(kg.peers[30]) != 23 {
+		t.Errorf("got %v peers for square 30, want 23", len(kg.peers[30]))
+	}
+}
+
+func TestChessSolveAndGenerate(t *testing.T) {
+	for _, cg := range []*Grid{standard.WithAntiKnight(), standard.WithAntiKing()} {
+		vs, solved := cg.Solve(cg.EmptyBoard(), SolveOptions{Randomize: true})
+		if !solved || !cg.IsSolved(vs) {
+			t.Fatalf("want solved board; got:\n%v", cg.Display(vs))
+		}
+
+		board := cg.Generate(25)
+		solutions := cg.solveAllWithElimination(board, -1)
+		if len(solutions) != 1 {
+			t.Errorf("got %v solutions, want 1", len(solutions))
+		}
+		if !cg.IsSolved(solutions[0]) {
+			t.Errorf("got unsolved board")
+		}
+	}
+
+	// The filled board is a valid Sudoku, but it has the same digits a knight's
+	// move apart.
+	v, err := ParseBoard(filled, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSolved(v) || standard.WithAntiKnight().IsSolved(v) {
+		t.Errorf("expect filled board to be solved, but not with anti-knight")
+	}
+}
@@ -0,0 +1,143 @@
+package sudoku
+
+// This file has the techniques of wings: patterns of a few squares, most of
+// them with two candidates, around a pivot or a strong link.
+
+func (ls *logicState) findXYWing() *Step {
+	g := ls.g
+	for pivot, xy := range ls.values {
+		if !ls.isBivalue(pivot) {
+			continue
+		}
+		for _, a := range g.peers[pivot] {
+			if !ls.isBivalue(a) || (ls.values[a]&xy).Size() != 1 {
+				continue
+			}
+			// a has xz, so the other pincer has yz.
+			yz := ls.values[a] ^ xy
+			z := yz &^ xy
+			for _, b := range g.peers[pivot] {
+				if !ls.isBivalue(b) || ls.values[b] != yz {
+					continue
+				}
+				elims := ls.eliminations(g.peers[a], z, func(sq Index) bool {
+					return sq == b || !ls.sees(b, sq)
+				})
+				if len(elims) > 0 {
+					x, y := (ls.values[a] & xy).SingleMemberDigit(), (xy &^ ls.values[a]).SingleMemberDigit()
+					zd := z.SingleMemberDigit()
+					return &Step{
+						Technique:    XYWing,
+						Digits:       xy | yz,
+						Squares:      []Index{pivot, a, b},
+						Links:        chainLinks(Candidate{a, zd}, Candidate{a, x}, Candidate{pivot, x}, Candidate{pivot, y}, Candidate{b, y}, Candidate{b, zd}),
+						Eliminations: elims,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (ls *logicState) findXYZWing() *Step {
+	g := ls.g
+	for pivot, xyz := range ls.values {
+		if ls.solved[pivot] || xyz.Size() != 3 {
+			continue
+		}
+		for i, a := range g.peers[pivot] {
+			if !ls.isBivalue(a) || ls.values[a]&^xyz != 0 {
+				continue
+			}
+			for _, b := range g.peers[pivot][i+1:] {
+				// The pincers have xz and yz, which make up the pivot's xyz.
+				if !ls.isBivalue(b) || ls.values[b]&^xyz != 0 || ls.values[a]|ls.values[b] != xyz {
+					continue
+				}
+				z := ls.values[a] & ls.values[b]
+				elims := ls.eliminations(g.peers[pivot], z, func(sq Index) bool {
+					return sq == a || sq == b || !ls.sees(a, sq) || !ls.sees(b, sq)
+				})
+				if len(elims) > 0 {
+					// If a pincer doesn't have z, it has its other digit, which the
+					// pivot can't have.
+					zd := z.SingleMemberDigit()
+					x, y := (ls.values[a] &^ z).SingleMemberDigit(), (ls.values[b] &^ z).SingleMemberDigit()
+					return &Step{
+						Technique:    XYZWing,
+						Digits:       xyz,
+						Squares:      []Index{pivot, a, b},
+						Links:        append(chainLinks(Candidate{a, zd}, Candidate{a, x}, Candidate{pivot, x}), chainLinks(Candidate{b, zd}, Candidate{b, y}, Candidate{pivot, y})...),
+						Eliminations: elims,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (ls *logicState) findWWing() *Step {
+	g := ls.g
+	for a, xy := range ls.values {
+		if !ls.isBivalue(a) {
+			continue
+		}
+		for b := a + 1; b < len(ls.values); b++ {
+			if ls.values[b] != xy || !ls.isBivalue(b) || ls.sees(a, b) {
+				continue
+			}
+			x1, x2 := xy.twoMemberDigits()
+			for _, digits := range [][2]uint16{{x1, x2}, {x2, x1}} {
+				x, y := digits[0], digits[1]
+				elims := ls.eliminations(g.peers[a], SingleDigitSet(y), func(sq Index) bool {
+					return !ls.sees(b, sq)
+				})
+				if len(elims) == 0 {
+					continue
+				}
+				// Look for a strong link of x between the peers of a and b.
+				for _, unit := range g.unitlist {
+					if ls.placed(unit).IsMember(x) {
+						continue
+					}
+					link := ls.positions(unit, x)
+					if len(link) != 2 {
+						continue
+					}
+					for _, pq := range [][2]Index{{link[0], link[1]}, {link[1], link[0]}} {
+						p, q := pq[0], pq[1]
+						if p == a || p == b || q == a || q == b || !ls.sees(a, p) || !ls.sees(b, q) {
+							continue
+						}
+						return &Step{
+							Technique:    WWing,
+							Digits:       xy,
+							Squares:      []Index{a, b, p, q},
+							Units:        []Unit{unit},
+							Links:        chainLinks(Candidate{a, y}, Candidate{a, x}, Candidate{p, x}, Candidate{q, x}, Candidate{b, x}, Candidate{b, y}),
+							Eliminations: elims,
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isBivalue checks whether square sq is unsolved with two candidates.
+func (ls *logicState) isBivalue(sq Index) bool {
+	return !ls.solved[sq] && ls.values[sq].Size() == 2
+}
+
+// chainLinks returns the links of an alternating inference chain of the
+// candidates, which starts with a strong link.
+func chainLinks(candidates ...Candidate) []Link {
+	links := make([]Link, len(candidates)-1)
+	for i := range links {
+		links[i] = Link{From: candidates[i], To: candidates[i+1], Strong: i%2 == 0}
+	}
+	return links
+}
@@ -0,0 +1,66 @@
+package sudoku
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestXYWingLinks(t *testing.T) {
+	// The pivot r1c1 has 12, and the pincers r1c5 and r2c2 have 13 and 23.
+	v := EmptyBoard()
+	v[0] = Digits(0).Add(1).Add(2)
+	v[4] = Digits(0).Add(1).Add(3)
+	v[10] = Digits(0).Add(2).Add(3)
+	step := standard.newLogicState(v).findXYWing()
+	if step == nil || !slices.Equal(step.Squares, []Index{0, 4, 10}) || !slices.Equal(step.Eliminations, []Candidate{{1, 3}, {2, 3}, {13, 3}, {12, 3}, {14, 3}}) {
+		t.Fatalf("got step %+v, want XY-Wing", step)
+	}
+	want := chainLinks(Candidate{4, 3}, Candidate{4, 1}, Candidate{0, 1}, Candidate{0, 2}, Candidate{10, 2}, Candidate{10, 3})
+	if !slices.Equal(step.Links, want) {
+		t.Errorf("got links %v, want %v", step.Links, want)
+	}
+}
+
+func TestXYZWing(t *testing.T) {
+	// The pivot r1c1 has 123, and the pincers r1c5 and r2c2 have 13 and 23, so
+	// one of the three has 3.
+	v := EmptyBoard()
+	v[0] = Digits(0).Add(1).Add(2).Add(3)
+	v[4] = Digits(0).Add(1).Add(3)
+	v[10] = Digits(0).Add(2).Add(3)
+	step := standard.newLogicState(v).findXYZWing()
+	if step == nil || step.Technique != XYZWing || step.Digits != v[0] || !slices.Equal(step.Squares, []Index{0, 4, 10}) {
+		t.Fatalf("got step %+v, want XYZ-Wing", step)
+	}
+	if !slices.Equal(step.Eliminations, []Candidate{{1, 3}, {2, 3}}) {
+		t.Errorf("got eliminations %v, want 3 in r1c2 and r1c3", step.Eliminations)
+	}
+	want := append(chainLinks(Candidate{4, 3}, Candidate{4, 1}, Candidate{0, 1}), chainLinks(Candidate{10, 3}, Candidate{10, 2}, Candidate{0, 2})...)
+	if !slices.Equal(step.Links, want) {
+		t.Errorf("got links %v, want %v", step.Links, want)
+	}
+	if got, want := DescribeStep(step), "XYZ-Wing: the pivot r1c1 has 1, 2 and 3, and the pincers r1c5 and r2c2 have 3 with one of the others, so one of the three squares has 3, and it can be eliminated from the squares that see all of them: r1c2 and r1c3."; got != want {
+		t.Errorf("got description\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestWWing(t *testing.T) {
+	// r1c1 and r9c9 have 12, and 1 is only in r5c1 and r5c9 in the fifth row.
+	v := EmptyBoard()
+	v[0] = Digits(0).Add(1).Add(2)
+	v[80] = v[0]
+	for sq := 37; sq < 44; sq++ {
+		v[sq] = v[sq].Remove(1)
+	}
+	step := standard.newLogicState(v).findWWing()
+	if step == nil || step.Technique != WWing || !slices.Equal(step.Squares, []Index{0, 80, 36, 44}) || !slices.Equal(step.Units[0], standard.unitlist[4]) {
+		t.Fatalf("got step %+v, want W-Wing", step)
+	}
+	if !slices.Equal(step.Eliminations, []Candidate{{8, 2}, {72, 2}}) {
+		t.Errorf("got eliminations %v, want 2 in r1c9 and r9c1", step.Eliminations)
+	}
+	want := chainLinks(Candidate{0, 2}, Candidate{0, 1}, Candidate{36, 1}, Candidate{44, 1}, Candidate{80, 1}, Candidate{80, 2})
+	if !slices.Equal(step.Links, want) {
+		t.Errorf("got links %v, want %v", step.Links, want)
+	}
+}